@@ -0,0 +1,59 @@
+package route
+
+import (
+	"net/http"
+	"slices"
+)
+
+// VersionAdapter adapts the requests and the responses of a route for an API version to the next version,
+// e.g. renaming the fields of /v1 to the fields of /v2. Chaining the adapters of every version lets the
+// routes of the old versions share the handler of the current version, see Mux.SetVersionAdapters.
+type VersionAdapter struct {
+	// Request returns the request adapted to the next version, nil leaves the request unchanged,
+	// and so does a nil request returned without error.
+	// It should not modify the request it receives but a copy of it, see http.Request.Clone.
+	// An error is answered with 400 Bad Request.
+	Request func(r *http.Request) (*http.Request, error)
+	// Response adapts the response of the next version, nil leaves the response unchanged
+	Response ResponseTransformer
+}
+
+// SetVersionAdapters sets the adapters of the route registered for the expression, from the adapter of the
+// route version to the adapter to the current version. The requests are adapted in order before reaching
+// the handler and the responses of the handler are adapted in reverse order, e.g. v3 to v2 then v2 to v1.
+// Calling it without adapters removes them.
+func (m *Mux) SetVersionAdapters(expr string, adapters ...VersionAdapter) error {
+	adapters = slices.Clone(adapters)
+	return m.setOptions(expr, func(o *routeOptions) {
+		o.adapters = adapters
+	})
+}
+
+// serveAdapted adapts the request, passes it to the handler and adapts its response
+func serveAdapted(w http.ResponseWriter, r *http.Request, h http.Handler, adapters []VersionAdapter) {
+	for _, a := range adapters {
+		if a.Request == nil {
+			continue
+		}
+		adapted, err := a.Request(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if adapted != nil {
+			r = adapted
+		}
+	}
+	// the response of the handler is adapted from the current version back to the route version
+	var transformers []ResponseTransformer
+	for _, a := range slices.Backward(adapters) {
+		if a.Response != nil {
+			transformers = append(transformers, a.Response)
+		}
+	}
+	if len(transformers) == 0 {
+		h.ServeHTTP(w, r)
+		return
+	}
+	serveTransformed(w, r, h, transformers)
+}
@@ -0,0 +1,101 @@
+package route
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renameQuery returns a request adapter renaming a query parameter
+func renameQuery(old, new string) func(r *http.Request) (*http.Request, error) {
+	return func(r *http.Request) (*http.Request, error) {
+		r = r.Clone(r.Context())
+		query := r.URL.Query()
+		if !query.Has(old) {
+			return nil, errors.New("missing " + old)
+		}
+		query.Set(new, query.Get(old))
+		query.Del(old)
+		r.URL.RawQuery = query.Encode()
+		return r, nil
+	}
+}
+
+func TestVersionAdapters(t *testing.T) {
+	m := NewMux()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "account="+r.URL.Query().Get("account"))
+	})
+	require.NoError(t, m.Handle(`Path("/v3/balance")`, handler))
+	require.NoError(t, m.Handle(`Path("/v2/balance")`, handler))
+	require.NoError(t, m.Handle(`Path("/v1/balance")`, handler))
+
+	v2 := VersionAdapter{
+		Request:  renameQuery("account_id", "account"),
+		Response: replaceTransformer{old: "account=", new: "account_id="},
+	}
+	v1 := VersionAdapter{
+		Request:  renameQuery("id", "account_id"),
+		Response: replaceTransformer{old: "account_id=", new: "id="},
+	}
+	require.NoError(t, m.SetVersionAdapters(`Path("/v2/balance")`, v2))
+	require.NoError(t, m.SetVersionAdapters(`Path("/v1/balance")`, v1, v2))
+
+	tcs := []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{url: "/v3/balance?account=42", status: http.StatusOK, body: "account=42"},
+		{url: "/v2/balance?account_id=42", status: http.StatusOK, body: "account_id=42"},
+		{url: "/v1/balance?id=42", status: http.StatusOK, body: "id=42"},
+		{url: "/v1/balance?account=42", status: http.StatusBadRequest, body: "missing id\n"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.url, func(t *testing.T) {
+			w := newWriter()
+			m.ServeHTTP(w, makeReq(req{url: tc.url}))
+			assert.Equal(t, tc.status, w.header)
+			assert.Equal(t, tc.body, w.buf.String())
+		})
+	}
+
+	// removing the adapters serves the route as is
+	require.NoError(t, m.SetVersionAdapters(`Path("/v1/balance")`))
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/v1/balance?account=42"}))
+	assert.Equal(t, "account=42", w.buf.String())
+
+	assert.Error(t, m.SetVersionAdapters(`Path("/v0/balance")`, v1))
+}
+
+func TestVersionAdaptersPartial(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/v1/status")`, newStatusHandler(http.StatusOK)))
+	// adapters may only adapt the responses
+	require.NoError(t, m.SetVersionAdapters(`Path("/v1/status")`, VersionAdapter{Response: statusTransformer(http.StatusAccepted)}, VersionAdapter{}))
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/v1/status"}))
+	assert.Equal(t, http.StatusAccepted, w.header)
+}
+
+func TestVersionAdaptersNilRequest(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/v1/balance")`, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "account="+r.URL.Query().Get("account"))
+	})))
+
+	// the adapters returning a nil request without error leave the request unchanged
+	noop := VersionAdapter{Request: func(r *http.Request) (*http.Request, error) { return nil, nil }}
+	require.NoError(t, m.SetVersionAdapters(`Path("/v1/balance")`, noop, VersionAdapter{Request: renameQuery("id", "account")}))
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/v1/balance?id=42"}))
+	assert.Equal(t, "account=42", w.buf.String())
+}
@@ -0,0 +1,28 @@
+package route
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SetAutoOptions enables OPTIONS requests with no matching route to be answered with 204 No Content
+// and the Allow header set to the methods of the routes matching the path, see MethodsFor.
+// Routes matching OPTIONS are still served by their handlers, and the CORS preflight requests
+// of the routes with a CORS policy are answered with their policy, see SetCORS.
+func (m *Mux) SetAutoOptions(enabled bool) {
+	m.autoOptions = enabled
+}
+
+// serveOptions answers the OPTIONS request if its path matches routes for other methods
+func (m *Mux) serveOptions(w http.ResponseWriter, r *http.Request) bool {
+	if !m.autoOptions || r.Method != http.MethodOptions {
+		return false
+	}
+	methods := m.methodsFor(r)
+	if len(methods) == 0 {
+		return false
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
@@ -0,0 +1,76 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoOptions(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Method("GET") && Path("/users")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Method("POST") && Path("/users")`, newStatusHandler(http.StatusCreated)))
+	require.NoError(t, m.Handle(`Method("OPTIONS") && Path("/custom")`, newStatusHandler(http.StatusAccepted)))
+	require.NoError(t, m.Handle(`Method("GET") && Path("/custom")`, newStatusHandler(http.StatusOK)))
+
+	options := func(url string) *testWriter {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: url, method: http.MethodOptions}))
+		return w
+	}
+
+	assert.Equal(t, http.StatusNotFound, options("/users").header)
+
+	m.SetAutoOptions(true)
+	m.SetHeadFallback(true)
+	w := options("/users")
+	assert.Equal(t, http.StatusNoContent, w.header)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", w.Header().Get("Allow"))
+
+	// explicit OPTIONS routes take precedence
+	assert.Equal(t, http.StatusAccepted, options("/custom").header)
+
+	// unknown paths are not found
+	assert.Equal(t, http.StatusNotFound, options("/missing").header)
+
+	// the Allow header of the method not allowed responses lists OPTIONS as well
+	m.SetMethodNotAllowed(MethodNotAllowed)
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/users", method: http.MethodDelete}))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.header)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", w.Header().Get("Allow"))
+}
+
+func TestAutoOptionsCORS(t *testing.T) {
+	m := NewMux()
+	m.SetAutoOptions(true)
+	require.NoError(t, m.Handle(`Method("PUT") && Path("/items")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Method("DELETE") && Path("/items")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.SetCORS(`Method("PUT") && Path("/items")`, &CORSPolicy{
+		AllowedOrigins: []string{"https://example.com"},
+		AllowedMethods: []string{http.MethodPut},
+	}))
+
+	preflight := func(method string) *testWriter {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/items", method: http.MethodOptions, headers: http.Header{
+			"Origin":                        {"https://example.com"},
+			"Access-Control-Request-Method": {method},
+		}}))
+		return w
+	}
+
+	// preflight requests are answered with the policy of the route
+	w := preflight(http.MethodPut)
+	assert.Equal(t, http.StatusNoContent, w.header)
+	assert.Equal(t, "https://example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Allow"))
+
+	// routes without policy get the automatic response without CORS headers, so the browser rejects them
+	w = preflight(http.MethodDelete)
+	assert.Equal(t, http.StatusNoContent, w.header)
+	assert.Equal(t, "DELETE, OPTIONS, PUT", w.Header().Get("Allow"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,162 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync/atomic"
+)
+
+// HashUpstream is an upstream of a HashBalancer
+type HashUpstream struct {
+	// Name identifies the upstream on the hash ring, e.g. its address. The points of an upstream
+	// depend only on its name, so the keys of the other upstreams don't move when it is removed.
+	Name string
+	// Handler serves the requests assigned to the upstream
+	Handler http.Handler
+}
+
+// HashOptions configures the ConsistentHash balancer
+type HashOptions struct {
+	// Replicas is the number of points every upstream gets on the hash ring, 100 by default.
+	// More replicas give a more even distribution at the cost of memory.
+	Replicas int
+	// LoadFactor enables consistent hashing with bounded loads when greater than 1:
+	// an upstream never gets more than LoadFactor times the average number of in-flight requests,
+	// requests that would exceed it spill over to the next upstream on the ring.
+	LoadFactor float64
+}
+
+const defaultReplicas = 100
+
+// HashBalancer is a http.Handler sending requests with the same key to the same upstream
+type HashBalancer struct {
+	upstreams []*Upstream
+	keyFunc   func(*http.Request) string
+	ring      []ringPoint
+	factor    float64
+	inflight  atomic.Int64
+}
+
+type ringPoint struct {
+	hash     uint64
+	upstream int
+}
+
+// ConsistentHash returns a handler balancing requests between upstreams using a consistent hash ring
+// over the request keys returned by keyFunc, e.g. a cache key, so that adding or removing an upstream
+// only moves the keys of that upstream. The names of the upstreams should be unique.
+// It can be used as a route handler.
+func ConsistentHash(upstreams []HashUpstream, keyFunc func(*http.Request) string, options HashOptions) (*HashBalancer, error) {
+	if len(upstreams) == 0 {
+		return nil, errors.New("at least one upstream is required")
+	}
+	if keyFunc == nil {
+		return nil, errors.New("key function cannot be nil")
+	}
+	if options.Replicas < 0 {
+		return nil, errors.New("replicas cannot be negative")
+	}
+	if options.LoadFactor != 0 && options.LoadFactor <= 1 {
+		return nil, errors.New("load factor should be greater than 1")
+	}
+
+	replicas := options.Replicas
+	if replicas == 0 {
+		replicas = defaultReplicas
+	}
+
+	b := &HashBalancer{
+		keyFunc: keyFunc,
+		factor:  options.LoadFactor,
+		ring:    make([]ringPoint, 0, len(upstreams)*replicas),
+	}
+	names := make(map[string]bool, len(upstreams))
+	for i, u := range upstreams {
+		if u.Handler == nil {
+			return nil, errors.New("upstream cannot be nil")
+		}
+		if u.Name == "" {
+			return nil, errors.New("upstream name cannot be empty")
+		}
+		if names[u.Name] {
+			return nil, fmt.Errorf("upstream name %s is used twice", u.Name)
+		}
+		names[u.Name] = true
+		b.upstreams = append(b.upstreams, newUpstream(u.Handler))
+		for r := 0; r < replicas; r++ {
+			b.ring = append(b.ring, ringPoint{hash: hashKey(u.Name + "-" + strconv.Itoa(r)), upstream: i})
+		}
+	}
+	slices.SortFunc(b.ring, func(a, b ringPoint) int {
+		switch {
+		case a.hash < b.hash:
+			return -1
+		case a.hash > b.hash:
+			return 1
+		}
+		return a.upstream - b.upstream
+	})
+	return b, nil
+}
+
+// ServeHTTP passes the request to the upstream owning the request key
+func (b *HashBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the request is counted once picked, pick accounts for it
+	i := b.pick(b.keyFunc(r))
+	b.inflight.Add(1)
+	defer b.inflight.Add(-1)
+
+	b.upstreams[i].ServeHTTP(w, r)
+}
+
+// Upstreams returns the upstreams of the balancer in the order they were given
+func (b *HashBalancer) Upstreams() []*Upstream {
+	return b.upstreams
+}
+
+// pick returns the index of the upstream for the key
+func (b *HashBalancer) pick(key string) int {
+	hash := hashKey(key)
+	i, _ := slices.BinarySearchFunc(b.ring, hash, func(p ringPoint, h uint64) int {
+		switch {
+		case p.hash < h:
+			return -1
+		case p.hash > h:
+			return 1
+		}
+		return 0
+	})
+
+	if b.factor == 0 {
+		return b.ring[i%len(b.ring)].upstream
+	}
+
+	limit := int64(math.Ceil(b.factor * float64(b.inflight.Load()+1) / float64(len(b.upstreams))))
+	for n := 0; n < len(b.ring); n++ {
+		p := b.ring[(i+n)%len(b.ring)]
+		if b.upstreams[p.upstream].inflight.Load()+1 <= limit {
+			return p.upstream
+		}
+	}
+	return b.ring[i%len(b.ring)].upstream
+}
+
+// hashKey returns a well distributed hash of the key
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+
+	// fnv is not well distributed for short keys, mix the bits using the splitmix64 finalizer
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,158 @@
+package route
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hashUpstreams names the handlers after their index
+func hashUpstreams(handlers ...http.Handler) []HashUpstream {
+	out := make([]HashUpstream, len(handlers))
+	for i, h := range handlers {
+		out[i] = HashUpstream{Name: "upstream-" + strconv.Itoa(i), Handler: h}
+	}
+	return out
+}
+
+func TestConsistentHash(t *testing.T) {
+	upstreams := hashUpstreams(
+		newStatusHandler(http.StatusOK),
+		newStatusHandler(http.StatusCreated),
+		newStatusHandler(http.StatusAccepted),
+	)
+	keyFunc := func(r *http.Request) string {
+		return r.URL.Path
+	}
+
+	b, err := ConsistentHash(upstreams, keyFunc, HashOptions{})
+	require.NoError(t, err)
+
+	counts := make(map[int]int)
+	for i := 0; i < 3000; i++ {
+		path := "/" + strconv.Itoa(i)
+
+		w := newWriter()
+		b.ServeHTTP(w, makeReq(req{url: path}))
+		counts[w.header]++
+
+		// the same key is always sent to the same upstream
+		again := newWriter()
+		b.ServeHTTP(again, makeReq(req{url: path}))
+		assert.Equal(t, w.header, again.header)
+	}
+
+	require.Len(t, counts, 3)
+	for _, c := range counts {
+		assert.InDelta(t, 1000, c, 300)
+	}
+}
+
+func TestConsistentHashStability(t *testing.T) {
+	keyFunc := func(r *http.Request) string {
+		return r.URL.Path
+	}
+	all := hashUpstreams(
+		newStatusHandler(http.StatusOK),
+		newStatusHandler(http.StatusCreated),
+		newStatusHandler(http.StatusAccepted),
+		newStatusHandler(http.StatusNoContent),
+	)
+
+	before, err := ConsistentHash(all[:3], keyFunc, HashOptions{Replicas: 200})
+	require.NoError(t, err)
+	after, err := ConsistentHash(all, keyFunc, HashOptions{Replicas: 200})
+	require.NoError(t, err)
+
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		if before.pick(key) != after.pick(key) {
+			moved++
+		}
+	}
+	// ideally a quarter of the keys move to the new upstream
+	assert.Less(t, moved, 400)
+
+	// removing an upstream only moves its keys, whatever its position
+	without := append(slices.Clone(all[:1]), all[2:]...)
+	removed, err := ConsistentHash(without, keyFunc, HashOptions{Replicas: 200})
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		if picked := all[after.pick(key)].Name; picked != all[1].Name {
+			assert.Equal(t, picked, without[removed.pick(key)].Name)
+		}
+	}
+}
+
+func TestConsistentHashBoundedLoad(t *testing.T) {
+	keyFunc := func(r *http.Request) string {
+		return "hot"
+	}
+	b, err := ConsistentHash(hashUpstreams(newStatusHandler(http.StatusOK), newStatusHandler(http.StatusOK)), keyFunc, HashOptions{LoadFactor: 1.25})
+	require.NoError(t, err)
+
+	first := b.pick("hot")
+	b.upstreams[first].inflight.Add(2)
+	b.inflight.Add(2)
+
+	assert.NotEqual(t, first, b.pick("hot"))
+}
+
+func TestConsistentHashBoundedLoadServe(t *testing.T) {
+	release := make(chan struct{})
+	blocking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Block") != "" {
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	keyFunc := func(r *http.Request) string {
+		return "hot"
+	}
+	b, err := ConsistentHash(hashUpstreams(blocking, blocking, blocking), keyFunc, HashOptions{LoadFactor: 1.5})
+	require.NoError(t, err)
+	first := b.pick("hot")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.ServeHTTP(newWriter(), makeReq(req{url: "/", headers: http.Header{"X-Block": {"1"}}}))
+	}()
+	require.Eventually(t, func() bool { return b.upstreams[first].inflight.Load() == 1 }, time.Second, time.Millisecond)
+
+	// the limit is ceil(1.5 * 2 requests / 3 upstreams) = 1 request per upstream
+	b.ServeHTTP(newWriter(), makeReq(req{url: "/"}))
+	close(release)
+	<-done
+	stats := b.upstreams[first].Stats()
+	assert.Equal(t, int64(1), stats.Requests)
+}
+
+func TestConsistentHashErrors(t *testing.T) {
+	keyFunc := func(r *http.Request) string {
+		return ""
+	}
+	upstreams := hashUpstreams(newStatusHandler(http.StatusOK))
+
+	_, err := ConsistentHash(nil, keyFunc, HashOptions{})
+	assert.Error(t, err)
+	_, err = ConsistentHash(upstreams, nil, HashOptions{})
+	assert.Error(t, err)
+	_, err = ConsistentHash(upstreams, keyFunc, HashOptions{Replicas: -1})
+	assert.Error(t, err)
+	_, err = ConsistentHash(upstreams, keyFunc, HashOptions{LoadFactor: 0.5})
+	assert.Error(t, err)
+	_, err = ConsistentHash([]HashUpstream{{Name: "a"}}, keyFunc, HashOptions{})
+	assert.Error(t, err)
+	_, err = ConsistentHash([]HashUpstream{{Handler: newStatusHandler(http.StatusOK)}}, keyFunc, HashOptions{})
+	assert.Error(t, err)
+	_, err = ConsistentHash(append(upstreams, upstreams...), keyFunc, HashOptions{})
+	assert.Error(t, err)
+}
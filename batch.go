@@ -0,0 +1,115 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// RequestKey holds the parts of a request used for routing, e.g. parsed from an access log line
+type RequestKey struct {
+	// Method is the request method, GET by default
+	Method string
+	Host   string
+	// URI is the request URI as sent by the client, e.g. /v1/users?id=1
+	URI    string
+	Header http.Header
+}
+
+// Result is the result of matching a request key
+type Result struct {
+	// Value is the value of the matched route, nil if no route matches
+	Value interface{}
+	// Err is set when the request key is invalid
+	Err error
+}
+
+// BatchMatcher is implemented by the routers that match many request keys more efficiently
+// than one by one, e.g. the routers returned by New, see MatchBatch.
+type BatchMatcher interface {
+	MatchBatch([]RequestKey) []Result
+}
+
+// MatchBatch matches the request keys, e.g. parsed from access logs, against the routes of the router
+// and returns the results in the same order. The keys are matched by the router if it is a BatchMatcher
+// and with Route one by one otherwise.
+func MatchBatch(r Router, keys []RequestKey) []Result {
+	if b, ok := r.(BatchMatcher); ok {
+		return b.MatchBatch(keys)
+	}
+
+	results := make([]Result, len(keys))
+	for i, key := range keys {
+		req := &http.Request{}
+		if err := key.fill(req); err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Value, results[i].Err = r.Route(req)
+	}
+	return results
+}
+
+// MatchBatch matches the request keys against the routes, the lock is taken once
+// and the request used for matching is reused across keys.
+func (r *router) MatchBatch(keys []RequestKey) []Result {
+	results := make([]Result, len(keys))
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	req := &http.Request{}
+	for i, key := range keys {
+		if err := key.fill(req); err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Value = r.route(req)
+	}
+	return results
+}
+
+// MatchBatch matches the request keys, e.g. to replay an access log against a new route table, like Match
+// and returns the results in the same order. The value of a result is the MatchResult of the key,
+// its error is ErrNoMatch if no route matches the key. The request used for matching is reused across keys.
+func (m *Mux) MatchBatch(keys []RequestKey) []Result {
+	results := make([]Result, len(keys))
+
+	req := &http.Request{}
+	for i, key := range keys {
+		if err := key.fill(req); err != nil {
+			results[i].Err = err
+			continue
+		}
+		result, err := m.Match(req)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Value = result
+	}
+	return results
+}
+
+// fill resets the request to the one of the key, it returns an error if the request URI is invalid
+func (key RequestKey) fill(req *http.Request) error {
+	u, err := url.ParseRequestURI(key.URI)
+	if err != nil {
+		return fmt.Errorf("bad request URI %q: %w", key.URI, err)
+	}
+
+	*req = http.Request{
+		Method:     key.Method,
+		Host:       key.Host,
+		URL:        u,
+		RequestURI: key.URI,
+		Header:     key.Header,
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchBatch(t *testing.T) {
+	r := New()
+	require.NoError(t, r.InitRoutes(map[string]interface{}{
+		`Host("api.example.com") && Path("/v1/users/<id>")`:          "users",
+		`Method("POST") && Path("/v1/orders")`:                       "orders",
+		`Path("/v1/search") && Header("Accept", "application/json")`: "search",
+	}))
+
+	results := MatchBatch(r, []RequestKey{
+		{Host: "api.example.com", URI: "/v1/users/42?fields=name"},
+		{Method: http.MethodPost, URI: "/v1/orders"},
+		{URI: "/v1/orders"},
+		{URI: "/v1/search", Header: http.Header{"Accept": {"application/json"}}},
+		{URI: "not a uri"},
+	})
+	require.Len(t, results, 5)
+
+	assert.Equal(t, Result{Value: "users"}, results[0])
+	assert.Equal(t, Result{Value: "orders"}, results[1])
+	assert.Equal(t, Result{}, results[2])
+	assert.Equal(t, Result{Value: "search"}, results[3])
+	assert.Nil(t, results[4].Value)
+	assert.Error(t, results[4].Err)
+
+	assert.Empty(t, MatchBatch(New(), nil))
+}
+
+// routeOnly hides the MatchBatch method of the router
+type routeOnly struct {
+	Router
+}
+
+func TestMatchBatchRouter(t *testing.T) {
+	r := New()
+	require.NoError(t, r.AddRoute(`Path("/v1/users/<id>")`, "users"))
+
+	results := MatchBatch(routeOnly{r}, []RequestKey{
+		{URI: "/v1/users/42"},
+		{URI: "/v1/orders"},
+		{URI: "not a uri"},
+	})
+	require.Len(t, results, 3)
+
+	assert.Equal(t, Result{Value: "users"}, results[0])
+	assert.Equal(t, Result{}, results[1])
+	assert.Error(t, results[2].Err)
+}
+
+func TestMuxMatchBatch(t *testing.T) {
+	m := NewMux()
+	m.SetHeadFallback(true)
+	users := newStatusHandler(http.StatusOK)
+	require.NoError(t, m.Handle(`Method("GET") && Path("/v1/users/<id>")`, users))
+
+	results := m.MatchBatch([]RequestKey{
+		{URI: "/v1/users/42"},
+		{Method: http.MethodHead, URI: "/v1/users/7"},
+		{Method: http.MethodPost, URI: "/v1/users/42"},
+		{URI: "not a uri"},
+	})
+	require.Len(t, results, 4)
+
+	require.NoError(t, results[0].Err)
+	result := results[0].Value.(MatchResult)
+	assert.Equal(t, `Method("GET") && Path("/v1/users/<id>")`, result.Expr)
+	assert.Equal(t, Params{"id": "42"}, result.Params)
+
+	require.NoError(t, results[1].Err)
+	result = results[1].Value.(MatchResult)
+	assert.True(t, result.HeadFallback)
+	assert.Equal(t, Params{"id": "7"}, result.Params)
+
+	assert.Equal(t, Result{Err: ErrNoMatch}, results[2])
+	assert.Error(t, results[3].Err)
+	assert.Nil(t, results[3].Value)
+}
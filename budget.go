@@ -0,0 +1,119 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Budget configures the error budget of a route, see ErrorBudget
+type Budget struct {
+	// Window is the number of recent requests the budget is computed over, 100 by default
+	Window int
+	// MaxErrorRate is the maximum share of failed requests in the window, e.g. 0.05 for 5%
+	MaxErrorRate float64
+	// MaxLatency counts responses slower than it as failures when set
+	MaxLatency time.Duration
+	// FallbackRatio is the share of the traffic sent to the fallback once the budget is exhausted,
+	// 0.9 by default. The rest still goes to the route handler so that the route can recover.
+	FallbackRatio float64
+	// OnChange is called when the budget gets exhausted and when it recovers
+	OnChange func(exhausted bool)
+}
+
+const defaultBudgetWindow = 100
+
+// budgetHandler sends part of the traffic to a fallback handler when the error budget is exhausted
+type budgetHandler struct {
+	handler  http.Handler
+	fallback http.Handler
+	budget   Budget
+
+	mutex     sync.Mutex
+	failed    []bool
+	next      int
+	count     int
+	failures  int
+	exhausted bool
+}
+
+// ErrorBudget returns a handler tracking the 5xx responses and the latency of the handler against
+// the budget. Once the budget is exhausted, Budget.FallbackRatio of the requests are served by the
+// fallback handler, e.g. a static response or a cached copy, until the error rate is back within budget.
+func ErrorBudget(handler, fallback http.Handler, budget Budget) (http.Handler, error) {
+	if handler == nil || fallback == nil {
+		return nil, errors.New("handler and fallback cannot be nil")
+	}
+	if budget.MaxErrorRate < 0 || budget.MaxErrorRate >= 1 {
+		return nil, errors.New("max error rate should be in [0, 1)")
+	}
+	if budget.FallbackRatio < 0 || budget.FallbackRatio > 1 {
+		return nil, errors.New("fallback ratio should be in [0, 1]")
+	}
+	if budget.Window < 0 {
+		return nil, errors.New("window cannot be negative")
+	}
+	if budget.Window == 0 {
+		budget.Window = defaultBudgetWindow
+	}
+	if budget.FallbackRatio == 0 {
+		budget.FallbackRatio = 0.9
+	}
+	return &budgetHandler{
+		handler:  handler,
+		fallback: fallback,
+		budget:   budget,
+		failed:   make([]bool, budget.Window),
+	}, nil
+}
+
+func (b *budgetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mutex.Lock()
+	exhausted := b.exhausted
+	b.mutex.Unlock()
+
+	if exhausted && clockFor(r).Float64() < b.budget.FallbackRatio {
+		b.fallback.ServeHTTP(w, r)
+		return
+	}
+
+	sw := newStatusWriter(w)
+	c := clockFor(r)
+	start := c.Now()
+	b.handler.ServeHTTP(sw, r)
+
+	failed := sw.Status() >= http.StatusInternalServerError ||
+		(b.budget.MaxLatency > 0 && c.Now().Sub(start) > b.budget.MaxLatency)
+	b.record(failed)
+}
+
+func (b *budgetHandler) record(failed bool) {
+	b.mutex.Lock()
+
+	if b.count == len(b.failed) {
+		if b.failed[b.next] {
+			b.failures--
+		}
+	} else {
+		b.count++
+	}
+	b.failed[b.next] = failed
+	if failed {
+		b.failures++
+	}
+	b.next = (b.next + 1) % len(b.failed)
+
+	// the budget is only evaluated on a full window to avoid flapping on the first requests
+	exhausted := b.exhausted
+	if b.count == len(b.failed) {
+		exhausted = float64(b.failures)/float64(b.count) > b.budget.MaxErrorRate
+	}
+	changed := exhausted != b.exhausted
+	b.exhausted = exhausted
+	b.mutex.Unlock()
+
+	if changed && b.budget.OnChange != nil {
+		b.budget.OnChange(exhausted)
+	}
+}
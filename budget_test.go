@@ -0,0 +1,70 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorBudget(t *testing.T) {
+	status := http.StatusOK
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	})
+
+	var events []bool
+	h, err := ErrorBudget(handler, newStatusHandler(http.StatusNonAuthoritativeInfo), Budget{
+		Window:        10,
+		MaxErrorRate:  0.2,
+		FallbackRatio: 1,
+		OnChange: func(exhausted bool) {
+			events = append(events, exhausted)
+		},
+	})
+	require.NoError(t, err)
+
+	serve := func() int {
+		w := newWriter()
+		h.ServeHTTP(w, makeReq(req{url: "/"}))
+		return w.header
+	}
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, http.StatusOK, serve())
+	}
+
+	status = http.StatusBadGateway
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusBadGateway, serve())
+	}
+	assert.Equal(t, []bool{true}, events)
+
+	// all the traffic goes to the fallback
+	assert.Equal(t, http.StatusNonAuthoritativeInfo, serve())
+
+	// the route recovers once the window is within budget again
+	status = http.StatusOK
+	b := h.(*budgetHandler)
+	for i := 0; i < 10; i++ {
+		b.record(false)
+	}
+	assert.Equal(t, []bool{true, false}, events)
+	assert.Equal(t, http.StatusOK, serve())
+}
+
+func TestErrorBudgetErrors(t *testing.T) {
+	h := newStatusHandler(http.StatusOK)
+
+	_, err := ErrorBudget(nil, h, Budget{})
+	assert.Error(t, err)
+	_, err = ErrorBudget(h, nil, Budget{})
+	assert.Error(t, err)
+	_, err = ErrorBudget(h, h, Budget{MaxErrorRate: 1})
+	assert.Error(t, err)
+	_, err = ErrorBudget(h, h, Budget{FallbackRatio: 2})
+	assert.Error(t, err)
+	_, err = ErrorBudget(h, h, Budget{Window: -1})
+	assert.Error(t, err)
+}
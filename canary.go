@@ -0,0 +1,140 @@
+package route
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// WeightedHandler is a handler of a weighted route with its share of the requests
+type WeightedHandler struct {
+	// Handler serves the requests assigned to it
+	Handler http.Handler
+	// Weight is the share of the requests assigned to the handler relative to the other weights,
+	// e.g. 95 for the stable version and 5 for the canary. A zero weight assigns no requests.
+	Weight int
+}
+
+// WeightedOptions configures the assignment of the requests of a WeightedBalancer.
+// Without sticky key the requests are assigned at random.
+type WeightedOptions struct {
+	// StickyHeader assigns the requests with the same value of the header to the same handler, e.g. X-User-Id
+	StickyHeader string
+	// StickyCookie assigns the requests with the same value of the cookie to the same handler,
+	// it is used when the sticky header is missing
+	StickyCookie string
+}
+
+// WeightedBalancer is a http.Handler splitting the requests between handlers according to their weights
+type WeightedBalancer struct {
+	upstreams []*Upstream
+	// bounds are the cumulative weights of the handlers
+	bounds  []int
+	total   int
+	options WeightedOptions
+}
+
+// Weighted returns a handler splitting the requests between the handlers according to their weights,
+// e.g. 95% to the stable version and 5% to the canary. Requests with a sticky key are assigned by
+// weighted rendezvous hashing of the key, so a client keeps its handler and changing the weight of
+// a handler only moves clients to or from that handler, e.g. increasing the weight of the canary never
+// moves clients between the other handlers. Handlers are identified by their position for the hashing.
+// Requests without sticky key are assigned at random, see SetClock.
+func Weighted(handlers []WeightedHandler, options WeightedOptions) (*WeightedBalancer, error) {
+	if len(handlers) == 0 {
+		return nil, errors.New("at least one handler is required")
+	}
+	b := &WeightedBalancer{options: options}
+	for _, h := range handlers {
+		if h.Handler == nil {
+			return nil, errors.New("handler cannot be nil")
+		}
+		if h.Weight < 0 {
+			return nil, errors.New("weight cannot be negative")
+		}
+		b.total += h.Weight
+		b.upstreams = append(b.upstreams, newUpstream(h.Handler))
+		b.bounds = append(b.bounds, b.total)
+	}
+	if b.total == 0 {
+		return nil, errors.New("at least one handler should have a positive weight")
+	}
+	return b, nil
+}
+
+// HandleWeighted adds a route for the expression splitting its requests between the handlers, see Weighted
+func (m *Mux) HandleWeighted(expr string, handlers []WeightedHandler, options WeightedOptions) error {
+	b, err := Weighted(handlers, options)
+	if err != nil {
+		return err
+	}
+	return m.Handle(expr, b)
+}
+
+// ServeHTTP passes the request to the handler it is assigned to
+func (b *WeightedBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.upstreams[b.pick(r)].ServeHTTP(w, r)
+}
+
+// Upstreams returns the handlers of the balancer in the order they were given,
+// their statistics can be compared to evaluate a canary
+func (b *WeightedBalancer) Upstreams() []*Upstream {
+	return b.upstreams
+}
+
+// pick returns the index of the handler the request is assigned to
+func (b *WeightedBalancer) pick(r *http.Request) int {
+	if key, ok := b.stickyKey(r); ok {
+		return b.pickSticky(key)
+	}
+	target := int(clockFor(r).Float64() * float64(b.total))
+	for i, bound := range b.bounds {
+		if target < bound {
+			return i
+		}
+	}
+	return len(b.bounds) - 1
+}
+
+// pickSticky returns the handler with the highest score for the key, the score of a handler being
+// its weight over the logarithm of the hash of the key and the handler, uniform in (0, 1).
+// The score of a handler does not depend on the other weights, so the key only moves to or from
+// the handler whose weight changed, and each handler gets the keys in proportion to its weight.
+func (b *WeightedBalancer) pickSticky(key string) int {
+	best, bestScore := 0, math.Inf(-1)
+	for i := range b.upstreams {
+		weight := b.weight(i)
+		if weight == 0 {
+			continue
+		}
+		u := (float64(hashKey(strconv.Itoa(i)+"-"+key)>>11) + 0.5) / (1 << 53)
+		if score := float64(weight) / -math.Log(u); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// weight returns the weight of the handler from the cumulative weights
+func (b *WeightedBalancer) weight(i int) int {
+	if i == 0 {
+		return b.bounds[0]
+	}
+	return b.bounds[i] - b.bounds[i-1]
+}
+
+// stickyKey returns the key assigning the request to a handler
+func (b *WeightedBalancer) stickyKey(r *http.Request) (string, bool) {
+	if b.options.StickyHeader != "" {
+		if key := r.Header.Get(b.options.StickyHeader); key != "" {
+			return key, true
+		}
+	}
+	if b.options.StickyCookie != "" {
+		if c, err := r.Cookie(b.options.StickyCookie); err == nil && c.Value != "" {
+			return c.Value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,127 @@
+package route
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeighted(t *testing.T) {
+	c := newFakeClock()
+
+	m := NewMux()
+	m.SetClock(c)
+	require.NoError(t, m.HandleWeighted(`Path("/checkout")`, []WeightedHandler{
+		{Handler: newStatusHandler(http.StatusOK), Weight: 95},
+		{Handler: newStatusHandler(http.StatusAccepted), Weight: 5},
+	}, WeightedOptions{}))
+
+	serve := func() int {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/checkout"}))
+		return w.header
+	}
+
+	c.setRandom(0)
+	assert.Equal(t, http.StatusOK, serve())
+	c.setRandom(0.9499)
+	assert.Equal(t, http.StatusOK, serve())
+	c.setRandom(0.95)
+	assert.Equal(t, http.StatusAccepted, serve())
+	c.setRandom(0.9999)
+	assert.Equal(t, http.StatusAccepted, serve())
+}
+
+func TestWeightedSticky(t *testing.T) {
+	handlers := func(canary int) []WeightedHandler {
+		return []WeightedHandler{
+			{Handler: newStatusHandler(http.StatusOK), Weight: 100 - canary},
+			{Handler: newStatusHandler(http.StatusAccepted), Weight: canary},
+		}
+	}
+	options := WeightedOptions{StickyHeader: "X-User-Id", StickyCookie: "session"}
+
+	assign := func(b *WeightedBalancer) map[string]int {
+		out := make(map[string]int)
+		for i := 0; i < 2000; i++ {
+			user := strconv.Itoa(i)
+			w := newWriter()
+			b.ServeHTTP(w, makeReq(req{url: "/", headers: http.Header{"X-User-Id": {user}}}))
+			out[user] = w.header
+
+			// the same key is always sent to the same handler
+			again := newWriter()
+			b.ServeHTTP(again, makeReq(req{url: "/", headers: http.Header{"Cookie": {"session=" + user}}}))
+			assert.Equal(t, w.header, again.header)
+		}
+		return out
+	}
+
+	b, err := Weighted(handlers(5), options)
+	require.NoError(t, err)
+	before := assign(b)
+
+	b, err = Weighted(handlers(20), options)
+	require.NoError(t, err)
+	after := assign(b)
+
+	canaries := 0
+	for user, status := range before {
+		if status == http.StatusAccepted {
+			canaries++
+			// increasing the canary weight keeps the canary users on the canary
+			assert.Equal(t, http.StatusAccepted, after[user])
+		}
+	}
+	assert.InDelta(t, 100, canaries, 40)
+	assert.Len(t, b.Upstreams(), 2)
+}
+
+func TestWeightedStickyThreeHandlers(t *testing.T) {
+	handlers := func(weights ...int) []WeightedHandler {
+		out := make([]WeightedHandler, len(weights))
+		for i, weight := range weights {
+			out[i] = WeightedHandler{Handler: newStatusHandler(http.StatusOK), Weight: weight}
+		}
+		return out
+	}
+	options := WeightedOptions{StickyHeader: "X-User-Id"}
+
+	before, err := Weighted(handlers(50, 50, 1), options)
+	require.NoError(t, err)
+	after, err := Weighted(handlers(50, 50, 100), options)
+	require.NoError(t, err)
+
+	counts := make([]int, 3)
+	for i := 0; i < 2000; i++ {
+		r := makeReq(req{url: "/", headers: http.Header{"X-User-Id": {strconv.Itoa(i)}}})
+		was, is := before.pick(r), after.pick(r)
+		// increasing the weight of the last handler only moves clients to it
+		if was != is {
+			assert.Equal(t, 2, is)
+		}
+		counts[is]++
+	}
+	assert.InDelta(t, 500, counts[0], 100)
+	assert.InDelta(t, 500, counts[1], 100)
+	assert.InDelta(t, 1000, counts[2], 100)
+}
+
+func TestWeightedErrors(t *testing.T) {
+	_, err := Weighted(nil, WeightedOptions{})
+	assert.Error(t, err)
+
+	_, err = Weighted([]WeightedHandler{{Weight: 1}}, WeightedOptions{})
+	assert.Error(t, err)
+
+	_, err = Weighted([]WeightedHandler{{Handler: newStatusHandler(http.StatusOK), Weight: -1}}, WeightedOptions{})
+	assert.Error(t, err)
+
+	_, err = Weighted([]WeightedHandler{{Handler: newStatusHandler(http.StatusOK)}}, WeightedOptions{})
+	assert.Error(t, err)
+
+	assert.Error(t, NewMux().HandleWeighted(`Path("/")`, nil, WeightedOptions{}))
+}
@@ -0,0 +1,41 @@
+package route
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Clock is the source of time and randomness of the time-based matchers and of the handlers
+// of the package, e.g. Stale matchers, deprecations, sampling, hedging and upstream statistics.
+// Tests can set a fake clock on the Mux with SetClock to make these behaviors deterministic.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+	// Float64 returns a pseudo-random number in the half-open interval [0.0, 1.0)
+	Float64() float64
+}
+
+// SetClock sets the clock of the mux, read by its Stale matchers, its miss cache, its not found limit,
+// its observer and the deprecations of its routes. The handlers of the package served by the mux,
+// e.g. hedging, sampling, error budgets, weighted balancers and upstream statistics, read it from the request.
+// By default the clock is the system clock and math/rand, a nil clock restores the default.
+func (m *Mux) SetClock(c Clock) {
+	m.settings.clock = c
+}
+
+// systemClock is the system time and the math/rand global source
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (systemClock) Float64() float64 {
+	return rand.Float64()
+}
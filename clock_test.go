@@ -0,0 +1,235 @@
+package route
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a clock advanced by the tests returning a fixed random number
+type fakeClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	random  float64
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	c        chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	w := fakeWaiter{deadline: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, w)
+	return w.c
+}
+
+func (c *fakeClock) Float64() float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.random
+}
+
+func (c *fakeClock) setRandom(v float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.random = v
+}
+
+func (c *fakeClock) pending() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.waiters)
+}
+
+// advance moves the clock forward and fires the expired waiters
+func (c *fakeClock) advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.c <- c.now
+	}
+	c.waiters = waiters
+}
+
+func TestSetClock(t *testing.T) {
+	c := newFakeClock()
+
+	m, other := NewMux(), NewMux()
+	m.SetClock(c)
+	assert.Equal(t, c, m.settings.getClock())
+	assert.Equal(t, systemClock{}, other.settings.getClock())
+
+	m.SetClock(nil)
+	assert.Equal(t, systemClock{}, m.settings.getClock())
+}
+
+func TestClockFromRequest(t *testing.T) {
+	c := newFakeClock()
+
+	m := NewMux()
+	var clocks []Clock
+	require.NoError(t, m.HandleFunc(`Path("/")`, func(w http.ResponseWriter, r *http.Request) {
+		clocks = append(clocks, clockFor(r))
+	}))
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/"}))
+	m.SetClock(c)
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/"}))
+	assert.Equal(t, []Clock{systemClock{}, c}, clocks)
+	assert.Equal(t, systemClock{}, clockFor(makeReq(req{url: "/"})))
+}
+
+func TestClockStale(t *testing.T) {
+	c := newFakeClock()
+
+	m, err := staleMatcher("X-Timestamp", "1m")
+	require.NoError(t, err)
+	m.setSettings(&settings{clock: c})
+
+	r := makeReq(req{url: "/", headers: http.Header{"X-Timestamp": {strconv.FormatInt(c.Now().Unix(), 10)}}})
+	assert.Nil(t, m.match(r))
+
+	c.advance(time.Minute)
+	assert.Nil(t, m.match(r))
+
+	c.advance(time.Second)
+	assert.NotNil(t, m.match(r))
+}
+
+func TestClockDeprecationSunset(t *testing.T) {
+	c := newFakeClock()
+
+	m := NewMux()
+	m.SetClock(c)
+	require.NoError(t, m.Handle(`Path("/v1")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.SetDeprecation(`Path("/v1")`, &Deprecation{Sunset: c.Now().Add(time.Hour), Disable: true}))
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/v1"}))
+	assert.Equal(t, http.StatusOK, w.header)
+
+	c.advance(time.Hour)
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/v1"}))
+	assert.Equal(t, http.StatusGone, w.header)
+}
+
+func TestClockNotFoundLimit(t *testing.T) {
+	c := newFakeClock()
+
+	m := NewMux()
+	m.SetClock(c)
+	m.SetNotFoundLimit(1, 1)
+
+	serve := func() int {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/missing"}))
+		return w.header
+	}
+
+	assert.Equal(t, http.StatusNotFound, serve())
+	assert.Equal(t, http.StatusNotFound, serve())
+	assert.Equal(t, int64(1), m.NotFoundStats().Throttled)
+
+	// the bucket is refilled after a second
+	c.advance(time.Second)
+	serve()
+	assert.Equal(t, int64(1), m.NotFoundStats().Throttled)
+	serve()
+	assert.Equal(t, int64(2), m.NotFoundStats().Throttled)
+}
+
+func TestClockSampling(t *testing.T) {
+	c := newFakeClock()
+
+	m := NewMux()
+	m.SetClock(c)
+	require.NoError(t, m.HandleFunc(`Path("/")`, func(w http.ResponseWriter, _ *http.Request) {
+		c.advance(5 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var captures []Capture
+	require.NoError(t, m.SetSampling(`Path("/")`, 0.5, func(capture Capture) {
+		captures = append(captures, capture)
+	}))
+
+	c.setRandom(0.5)
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/"}))
+	assert.Empty(t, captures)
+
+	c.setRandom(0.49)
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/"}))
+	require.Len(t, captures, 1)
+	assert.Equal(t, 5*time.Millisecond, captures[0].Duration)
+}
+
+func TestClockHedge(t *testing.T) {
+	c := newFakeClock()
+
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		w.WriteHeader(http.StatusGatewayTimeout)
+	})
+	fast := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	h, err := Hedge([]http.Handler{slow, fast}, HedgeOptions{Delay: time.Second})
+	require.NoError(t, err)
+	// the next request starts with the slow upstream
+	h.next.Store(1)
+
+	m := NewMux()
+	m.SetClock(c)
+	require.NoError(t, m.Handle(`Path("/")`, h))
+
+	w := newWriter()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.ServeHTTP(w, makeReq(req{url: "/", method: http.MethodGet}))
+	}()
+
+	// the second attempt is only sent once the delay elapsed on the clock
+	require.Eventually(t, func() bool { return c.pending() == 1 }, time.Second, time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("hedged before the delay")
+	default:
+	}
+	c.advance(time.Second)
+	<-done
+	assert.Equal(t, http.StatusOK, w.header)
+}
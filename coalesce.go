@@ -0,0 +1,117 @@
+package route
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+)
+
+// coalescing coalesces the concurrent identical requests of a route into a single handler call
+type coalescing struct {
+	key   func(*http.Request) string
+	group flightGroup
+}
+
+// SetCoalescing enables request coalescing for the route registered for the expression:
+// concurrent GET and HEAD requests with the same key are served by a single handler call
+// whose response is replayed to every caller, protecting backends from thundering herds on hot keys.
+// The key defaults to the method, host, request URI and content negotiation headers when key is nil,
+// requests with an Authorization or a Cookie header are then not coalesced so a response is never shared
+// between users. A custom key should include the credentials of the request and the headers the responses
+// vary on, an empty key serves the request without coalescing.
+// The shared call is not canceled when the caller executing it goes away, so the other callers still get
+// the response. Responses are buffered, so coalescing should not be enabled for streaming routes.
+func (m *Mux) SetCoalescing(expr string, key func(*http.Request) string) error {
+	if key == nil {
+		key = coalescingKey
+	}
+	return m.setOptions(expr, func(o *routeOptions) {
+		o.coalescing = &coalescing{key: key}
+	})
+}
+
+// DisableCoalescing disables request coalescing for the route registered for the expression
+func (m *Mux) DisableCoalescing(expr string) error {
+	return m.setOptions(expr, func(o *routeOptions) {
+		o.coalescing = nil
+	})
+}
+
+// negotiationHeaders are the request headers selecting the representation of the response,
+// requests coalesced by the default key have the same values for all of them
+var negotiationHeaders = []string{"Accept", "Accept-Encoding", "Accept-Language"}
+
+func coalescingKey(r *http.Request) string {
+	if r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "" {
+		return ""
+	}
+	key := r.Method + " " + r.Host + r.URL.RequestURI()
+	for _, name := range negotiationHeaders {
+		if values := r.Header.Values(name); len(values) != 0 {
+			key += "\n" + name + ": " + strings.Join(values, ", ")
+		}
+	}
+	return key
+}
+
+func (c *coalescing) serve(w http.ResponseWriter, r *http.Request, h http.Handler) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.ServeHTTP(w, r)
+		return
+	}
+	key := c.key(r)
+	if key == "" {
+		h.ServeHTTP(w, r)
+		return
+	}
+
+	res, err, _ := c.group.do(key, func() (interface{}, error) {
+		rec := &recorder{header: make(http.Header)}
+		// the call is shared, it should not fail for every caller when this one goes away
+		h.ServeHTTP(rec, r.WithContext(context.WithoutCancel(r.Context())))
+		return rec, nil
+	})
+	if err != nil {
+		// the handler panicked, the panic is raised in the caller which executed it
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	res.(*recorder).replay(w)
+}
+
+// recorder buffers a response so it can be replayed to several callers
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+// WriteHeader records the final status, informational statuses like 103 Early Hints are not replayed
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 && !informational(status) {
+		r.status = status
+	}
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(p)
+}
+
+func (r *recorder) replay(w http.ResponseWriter) {
+	header := w.Header()
+	for k, v := range r.header {
+		header[k] = append([]string(nil), v...)
+	}
+	if r.status != 0 {
+		w.WriteHeader(r.status)
+	}
+	_, _ = w.Write(r.body.Bytes())
+}
@@ -0,0 +1,219 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoalescing(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	m := NewMux()
+	require.NoError(t, m.HandleFunc(`Path("/hot")`, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Method == http.MethodGet {
+			<-release
+		}
+		w.Header().Set("X-Value", "hot")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("body"))
+	}))
+	require.NoError(t, m.SetCoalescing(`Path("/hot")`, nil))
+
+	const callers = 5
+	writers := make([]*testWriter, callers)
+	var wg sync.WaitGroup
+	for i := range writers {
+		writers[i] = newWriter()
+		wg.Add(1)
+		go func(w *testWriter) {
+			defer wg.Done()
+			m.ServeHTTP(w, makeReq(req{url: "/hot", method: http.MethodGet}))
+		}(writers[i])
+	}
+
+	// wait for all the callers to join the flight
+	c := m.keys[CanonicalExpr(`Path("/hot")`)].options.Load().coalescing
+	require.Eventually(t, func() bool {
+		c.group.mutex.Lock()
+		defer c.group.mutex.Unlock()
+		call, ok := c.group.calls["GET /hot"]
+		return ok && call.dups == callers-1
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, w := range writers {
+		assert.Equal(t, http.StatusAccepted, w.header)
+		assert.Equal(t, "hot", w.Header().Get("X-Value"))
+		assert.Equal(t, "body", w.buf.String())
+	}
+
+	// non idempotent requests are not coalesced
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/hot", method: http.MethodPost}))
+	assert.Equal(t, int32(2), calls.Load())
+
+	require.NoError(t, m.DisableCoalescing(`Path("/hot")`))
+	assert.Nil(t, m.keys[CanonicalExpr(`Path("/hot")`)].options.Load().coalescing)
+	assert.Error(t, m.SetCoalescing(`Path("/cold")`, nil))
+}
+
+func TestCoalescingCredentials(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleFunc(`Path("/me")`, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(r.Header.Get("Authorization") + r.Header.Get("Cookie")))
+	}))
+	require.NoError(t, m.SetCoalescing(`Path("/me")`, nil))
+
+	for _, header := range []http.Header{{"Authorization": {"Bearer alice"}}, {"Cookie": {"session=bob"}}} {
+		assert.Empty(t, coalescingKey(makeReq(req{url: "/me", method: http.MethodGet, headers: header})))
+
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/me", method: http.MethodGet, headers: header}))
+		assert.Equal(t, http.StatusOK, w.header)
+		assert.Equal(t, header.Get("Authorization")+header.Get("Cookie"), w.buf.String())
+	}
+	assert.Equal(t, "GET /me", coalescingKey(makeReq(req{url: "/me", method: http.MethodGet})))
+}
+
+func TestCoalescingPanic(t *testing.T) {
+	release := make(chan struct{})
+	m := NewMux()
+	require.NoError(t, m.HandleFunc(`Path("/hot")`, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		panic("upstream bug")
+	}))
+	require.NoError(t, m.SetCoalescing(`Path("/hot")`, nil))
+
+	leader := make(chan interface{})
+	go func() {
+		defer func() { leader <- recover() }()
+		m.ServeHTTP(newWriter(), makeReq(req{url: "/hot", method: http.MethodGet}))
+	}()
+	c := m.keys[CanonicalExpr(`Path("/hot")`)].options.Load().coalescing
+	require.Eventually(t, func() bool {
+		c.group.mutex.Lock()
+		defer c.group.mutex.Unlock()
+		_, ok := c.group.calls["GET /hot"]
+		return ok
+	}, time.Second, time.Millisecond)
+
+	w := newWriter()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.ServeHTTP(w, makeReq(req{url: "/hot", method: http.MethodGet}))
+	}()
+	require.Eventually(t, func() bool {
+		c.group.mutex.Lock()
+		defer c.group.mutex.Unlock()
+		call, ok := c.group.calls["GET /hot"]
+		return ok && call.dups == 1
+	}, time.Second, time.Millisecond)
+	close(release)
+
+	// the caller executing the handler panics like without coalescing, the others get an error
+	assert.Equal(t, "upstream bug", <-leader)
+	<-done
+	assert.Equal(t, http.StatusInternalServerError, w.header)
+}
+
+func TestRecorderInformational(t *testing.T) {
+	rec := &recorder{header: make(http.Header)}
+	rec.Header().Set("Link", "</style.css>; rel=preload")
+	rec.WriteHeader(http.StatusEarlyHints)
+	rec.WriteHeader(http.StatusCreated)
+	_, _ = rec.Write([]byte("body"))
+
+	w := newWriter()
+	rec.replay(w)
+	assert.Equal(t, http.StatusCreated, w.header)
+	assert.Equal(t, "body", w.buf.String())
+
+	rec = &recorder{header: make(http.Header)}
+	rec.WriteHeader(http.StatusProcessing)
+	_, _ = rec.Write([]byte("body"))
+	w = newWriter()
+	rec.replay(w)
+	assert.Equal(t, http.StatusOK, w.header)
+}
+
+func TestCoalescingNegotiation(t *testing.T) {
+	key := func(headers http.Header) string {
+		return coalescingKey(makeReq(req{url: "/hot", method: http.MethodGet, headers: headers}))
+	}
+
+	keys := map[string]bool{key(nil): true}
+	for _, headers := range []http.Header{
+		{"Accept": {"application/json"}},
+		{"Accept": {"text/html"}},
+		{"Accept-Encoding": {"gzip"}},
+		{"Accept-Language": {"fr"}},
+		{"Accept": {"application/json"}, "Accept-Language": {"fr"}},
+	} {
+		k := key(headers)
+		assert.False(t, keys[k], k)
+		keys[k] = true
+		assert.Equal(t, k, key(headers.Clone()))
+	}
+	assert.Equal(t, "GET /hot\nAccept: text/html, application/json", key(http.Header{"Accept": {"text/html", "application/json"}}))
+}
+
+func TestCoalescingDetached(t *testing.T) {
+	release := make(chan struct{})
+	m := NewMux()
+	require.NoError(t, m.HandleFunc(`Path("/hot")`, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		if r.Context().Err() != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	require.NoError(t, m.SetCoalescing(`Path("/hot")`, nil))
+
+	// the first caller executes the call and goes away
+	ctx, cancel := context.WithCancel(context.Background())
+	first := newWriter()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.ServeHTTP(first, makeReq(req{url: "/hot", method: http.MethodGet}).WithContext(ctx))
+	}()
+	c := m.keys[CanonicalExpr(`Path("/hot")`)].options.Load().coalescing
+	require.Eventually(t, func() bool {
+		c.group.mutex.Lock()
+		defer c.group.mutex.Unlock()
+		_, ok := c.group.calls["GET /hot"]
+		return ok
+	}, time.Second, time.Millisecond)
+
+	second := newWriter()
+	joined := make(chan struct{})
+	go func() {
+		defer close(joined)
+		m.ServeHTTP(second, makeReq(req{url: "/hot", method: http.MethodGet}))
+	}()
+	require.Eventually(t, func() bool {
+		c.group.mutex.Lock()
+		defer c.group.mutex.Unlock()
+		call, ok := c.group.calls["GET /hot"]
+		return ok && call.dups == 1
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	close(release)
+	<-done
+	<-joined
+	assert.Equal(t, http.StatusOK, second.header)
+}
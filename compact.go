@@ -0,0 +1,72 @@
+package route
+
+// Compact rebuilds the route table and the route bookkeeping of the mux, releasing the memory retained
+// by the routes removed or replaced since the mux was created or last compacted. The table is rebuilt
+// aside and swapped atomically, so the requests are served during the compaction without waiting for it.
+func (m *Mux) Compact() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	err := m.update(func(r *router) error {
+		return r.compact()
+	})
+	if err != nil {
+		return err
+	}
+	m.keys = compactMap(m.keys)
+	m.names = compactMap(m.names)
+	m.hosts = compactMap(m.hosts)
+	m.hostPatterns = compactMap(m.hostPatterns)
+	m.methods = compactMap(m.methods)
+	m.paths.paths = compactMap(m.paths.paths)
+	m.paths.buckets = compactMap(m.paths.buckets)
+	sorted := make([]*entry, len(m.sorted))
+	copy(sorted, m.sorted)
+	m.sorted = sorted
+	m.churn = 0
+	return nil
+}
+
+// SetAutoCompact enables the background compaction of the mux once threshold routes were removed
+// or replaced since the last compaction, see Compact. The compaction runs in Run, a compaction due
+// before Run is called runs once it starts. A zero threshold disables it.
+func (m *Mux) SetAutoCompact(threshold int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.autoCompact = max(threshold, 0)
+}
+
+// addChurn counts a removed or replaced route, signaling Run when the compaction is due.
+// The caller holds the lock.
+func (m *Mux) addChurn() {
+	m.churn++
+	if m.autoCompact == 0 || m.churn < m.autoCompact {
+		return
+	}
+	// the compaction waits for the current mutation to release the lock
+	m.churn = 0
+	select {
+	case m.background.compactions <- struct{}{}:
+	default:
+		// a compaction is already due
+	}
+}
+
+// compact recompiles the matchers of the routes in a map sized for the current routes
+func (r *router) compact() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.routes = compactMap(r.routes)
+	return r.compile()
+}
+
+// compactMap copies the map into a map sized for its current entries, maps never shrink once grown
+func compactMap[K comparable, V any](in map[K]V) map[K]V {
+	out := make(map[K]V, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
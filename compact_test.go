@@ -0,0 +1,99 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompact(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/stable")`, newStatusHandler(http.StatusOK)))
+	for i := 0; i < 100; i++ {
+		expr := fmt.Sprintf(`Host("tenant-%d.example.com") && Path("/")`, i)
+		require.NoError(t, m.Handle(expr, newStatusHandler(http.StatusAccepted)))
+		require.NoError(t, m.Remove(expr))
+	}
+	hash := m.Hash()
+
+	require.NoError(t, m.Compact())
+	assert.Equal(t, hash, m.Hash())
+	assert.Len(t, slices.Collect(m.Routes()), 1)
+	assert.Equal(t, 0, m.churn)
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/stable"}))
+	assert.Equal(t, http.StatusOK, w.header)
+
+	// routes can still be added and removed after the compaction
+	require.NoError(t, m.Handle(`Path("/new")`, newStatusHandler(http.StatusCreated)))
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/new"}))
+	assert.Equal(t, http.StatusCreated, w.header)
+	require.NoError(t, m.Remove(`Path("/stable")`))
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/stable"}))
+	assert.Equal(t, http.StatusNotFound, w.header)
+}
+
+func TestAutoCompact(t *testing.T) {
+	m := NewMux()
+	m.SetAutoCompact(10)
+
+	churn := func() int {
+		m.mutex.RLock()
+		defer m.mutex.RUnlock()
+		return m.churn
+	}
+
+	for i := 0; i < 9; i++ {
+		require.NoError(t, m.Handle(`Path("/replaced")`, newStatusHandler(http.StatusOK)))
+	}
+	// the first Handle adds the route, the others replace it
+	assert.Equal(t, 8, churn())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			w := newWriter()
+			m.ServeHTTP(w, makeReq(req{url: "/replaced"}))
+			assert.Equal(t, http.StatusOK, w.header)
+		}
+	}()
+	require.NoError(t, m.Handle(`Path("/replaced")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path("/replaced")`, newStatusHandler(http.StatusOK)))
+	<-done
+
+	// the counter is reset when the compaction is due, it runs once Run starts
+	assert.Equal(t, 0, churn())
+	m.mutex.RLock()
+	before := m.sorted
+	m.mutex.RUnlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = m.Run(ctx) }()
+	assert.Eventually(t, func() bool {
+		m.mutex.RLock()
+		defer m.mutex.RUnlock()
+		// Compact copies the sorted routes
+		return &m.sorted[0] != &before[0]
+	}, time.Second, time.Millisecond)
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/replaced"}))
+	assert.Equal(t, http.StatusOK, w.header)
+
+	// disabled by default
+	m = NewMux()
+	for i := 0; i < 20; i++ {
+		require.NoError(t, m.Handle(`Path("/replaced")`, newStatusHandler(http.StatusOK)))
+	}
+	assert.Equal(t, 19, churn())
+}
@@ -0,0 +1,17 @@
+package route
+
+import (
+	"context"
+)
+
+// ContextFunc decorates the context of the requests of a route
+type ContextFunc func(ctx context.Context) context.Context
+
+// SetContext sets the function decorating the request context before the handler of the route registered
+// for the expression is called, e.g. to inject the tenant configuration or a logger with the route fields
+// once per route instead of looking them up in every handler. A nil function removes the decorator.
+func (m *Mux) SetContext(expr string, fn ContextFunc) error {
+	return m.setOptions(expr, func(o *routeOptions) {
+		o.context = fn
+	})
+}
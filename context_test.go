@@ -0,0 +1,34 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tenantKey struct{}
+
+func TestSetContext(t *testing.T) {
+	var tenant interface{}
+	m := NewMux()
+	require.NoError(t, m.HandleFunc(`Host("acme.example.com")`, func(_ http.ResponseWriter, r *http.Request) {
+		tenant = r.Context().Value(tenantKey{})
+	}))
+	require.NoError(t, m.SetContext(`Host("acme.example.com")`, func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, tenantKey{}, "acme")
+	}))
+
+	r := makeReq(req{url: "/", host: "acme.example.com"}).WithContext(context.Background())
+	m.ServeHTTP(newWriter(), r)
+	assert.Equal(t, "acme", tenant)
+	assert.Nil(t, r.Context().Value(tenantKey{}))
+
+	require.NoError(t, m.SetContext(`Host("acme.example.com")`, nil))
+	m.ServeHTTP(newWriter(), r)
+	assert.Nil(t, tenant)
+
+	assert.Error(t, m.SetContext(`Host("other.example.com")`, nil))
+}
@@ -0,0 +1,238 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSPolicy is the cross-origin resource sharing policy of a route, see Mux.SetCORS
+type CORSPolicy struct {
+	// AllowedOrigins are the allowed origins, e.g. "https://example.com". "*" allows any origin
+	// and "https://*.example.com" allows the subdomains of example.com.
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed in preflight requests, GET, HEAD and POST when empty
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed in preflight requests, "*" allows any header
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers exposed to the scripts
+	ExposedHeaders []string
+	// AllowCredentials allows requests with cookies and authorization headers
+	AllowCredentials bool
+	// MaxAge is how long the preflight responses can be cached, not sent when zero
+	MaxAge time.Duration
+}
+
+// cors is the compiled CORS policy of a route
+type cors struct {
+	anyOrigin   bool
+	origins     []string
+	wildcards   []originWildcard
+	methods     []string
+	anyHeader   bool
+	headers     []string
+	exposed     string
+	credentials bool
+	maxAge      string
+}
+
+// originWildcard matches the subdomains of an origin, e.g. https://*.example.com
+type originWildcard struct {
+	scheme string
+	suffix string
+}
+
+// newCORS compiles the policy, a nil policy is compiled to nil
+func newCORS(p *CORSPolicy) (*cors, error) {
+	if p == nil {
+		return nil, nil
+	}
+	if len(p.AllowedOrigins) == 0 {
+		return nil, errors.New("CORS policy should allow at least one origin")
+	}
+	c := &cors{
+		methods:     []string{http.MethodGet, http.MethodHead, http.MethodPost},
+		exposed:     strings.Join(p.ExposedHeaders, ", "),
+		credentials: p.AllowCredentials,
+	}
+	for _, origin := range p.AllowedOrigins {
+		switch {
+		case origin == "*":
+			c.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			scheme, suffix, _ := strings.Cut(strings.ToLower(origin), "://*")
+			c.wildcards = append(c.wildcards, originWildcard{scheme: scheme + "://", suffix: suffix})
+		case origin == "":
+			return nil, errors.New("CORS origin cannot be empty")
+		default:
+			c.origins = append(c.origins, strings.ToLower(origin))
+		}
+	}
+	if c.anyOrigin && c.credentials {
+		return nil, errors.New("CORS policy can't allow credentials for any origin")
+	}
+	if len(p.AllowedMethods) != 0 {
+		c.methods = make([]string, len(p.AllowedMethods))
+		for i, method := range p.AllowedMethods {
+			c.methods[i] = strings.ToUpper(method)
+		}
+	}
+	for _, header := range p.AllowedHeaders {
+		if header == "*" {
+			c.anyHeader = true
+			continue
+		}
+		c.headers = append(c.headers, textproto.CanonicalMIMEHeaderKey(header))
+	}
+	if p.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(p.MaxAge.Seconds()))
+	}
+	return c, nil
+}
+
+// SetCORS sets the CORS policy of the route registered for the expression, the mux answers the preflight
+// requests of the route and adds the CORS headers to the responses of its cross-origin requests.
+// Preflight requests are matched with the method they announce, so the route doesn't need to match OPTIONS.
+// A nil policy removes it.
+func (m *Mux) SetCORS(expr string, p *CORSPolicy) error {
+	c, err := newCORS(p)
+	if err != nil {
+		return err
+	}
+	return m.setOptions(expr, func(o *routeOptions) {
+		o.cors = c
+	})
+}
+
+// SetCORSUnder sets the CORS policy of all the routes under the prefix, see RoutesUnder for the prefix semantics.
+// It returns the number of updated routes, routes added later are not affected.
+func (m *Mux) SetCORSUnder(prefix string, p *CORSPolicy) (int, error) {
+	c, err := newCORS(p)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	entries := m.entriesUnder(prefix)
+	for _, e := range entries {
+		e.updateOptions(func(o *routeOptions) {
+			o.cors = c
+		})
+	}
+	return len(entries), nil
+}
+
+// isPreflight returns true for CORS preflight requests
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get("Origin") != "" && r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// servePreflight answers the preflight requests for routes with a CORS policy not matching OPTIONS,
+// it returns false if the request is not a preflight request for such a route
+func (m *Mux) servePreflight(w http.ResponseWriter, r *http.Request) bool {
+	if !isPreflight(r) {
+		return false
+	}
+	// WithContext makes a shallow copy, matchers do not modify the request
+	announced := r.WithContext(r.Context())
+	announced.Method = r.Header.Get("Access-Control-Request-Method")
+	res := m.router.Load().route(announced)
+	if res == nil {
+		return false
+	}
+	c := res.(*entry).options.Load().cors
+	if c == nil {
+		return false
+	}
+	c.preflight(w, r)
+	return true
+}
+
+func (c *cors) allowOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if slices.Contains(c.origins, origin) {
+		return true
+	}
+	for _, w := range c.wildcards {
+		if host, ok := strings.CutPrefix(origin, w.scheme); ok && len(host) > len(w.suffix) && strings.HasSuffix(host, w.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cors) allowHeaders(requested string) bool {
+	if c.anyHeader {
+		return true
+	}
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !slices.Contains(c.headers, textproto.CanonicalMIMEHeaderKey(header)) {
+			return false
+		}
+	}
+	return true
+}
+
+// setOrigin sets the allowed origin of the response
+func (c *cors) setOrigin(header http.Header, origin string) {
+	if c.anyOrigin {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	if c.credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// preflight answers the preflight request, requests violating the policy are answered with 403 Forbidden
+func (c *cors) preflight(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	header.Add("Vary", "Origin, Access-Control-Request-Method, Access-Control-Request-Headers")
+
+	origin := r.Header.Get("Origin")
+	requested := r.Header.Get("Access-Control-Request-Headers")
+	if !c.allowOrigin(origin) || !slices.Contains(c.methods, r.Header.Get("Access-Control-Request-Method")) || !c.allowHeaders(requested) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	c.setOrigin(header, origin)
+	header.Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+	if requested != "" {
+		header.Set("Access-Control-Allow-Headers", requested)
+	}
+	if c.maxAge != "" {
+		header.Set("Access-Control-Max-Age", c.maxAge)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apply adds the CORS headers to the response of a cross-origin request,
+// they are left out if the origin is not allowed so the browser blocks the response
+func (c *cors) apply(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+	header := w.Header()
+	if !c.anyOrigin {
+		header.Add("Vary", "Origin")
+	}
+	if !c.allowOrigin(origin) {
+		return
+	}
+	c.setOrigin(header, origin)
+	if c.exposed != "" {
+		header.Set("Access-Control-Expose-Headers", c.exposed)
+	}
+}
@@ -0,0 +1,94 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetCORS(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Method("PUT") && Path("/items")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.SetCORS(`Method("PUT") && Path("/items")`, &CORSPolicy{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods:   []string{"put"},
+		AllowedHeaders:   []string{"Content-Type"},
+		ExposedHeaders:   []string{"X-Request-Id"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}))
+
+	preflight := func(origin, method, headers string) *testWriter {
+		w := newWriter()
+		h := http.Header{"Origin": {origin}, "Access-Control-Request-Method": {method}}
+		if headers != "" {
+			h.Set("Access-Control-Request-Headers", headers)
+		}
+		m.ServeHTTP(w, makeReq(req{url: "/items", method: http.MethodOptions, headers: h}))
+		return w
+	}
+
+	w := preflight("https://app.example.com", http.MethodPut, "content-type")
+	assert.Equal(t, http.StatusNoContent, w.header)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "PUT", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "content-type", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+
+	assert.Equal(t, http.StatusNoContent, preflight("https://api.example.org", http.MethodPut, "").header)
+	assert.Equal(t, http.StatusForbidden, preflight("https://example.org", http.MethodPut, "").header)
+	assert.Equal(t, http.StatusForbidden, preflight("https://evil.com", http.MethodPut, "").header)
+	assert.Equal(t, http.StatusForbidden, preflight("https://app.example.com", http.MethodPut, "X-Secret").header)
+	// no route for the announced method
+	assert.Equal(t, http.StatusNotFound, preflight("https://app.example.com", http.MethodDelete, "").header)
+
+	serve := func(origin string) *testWriter {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/items", method: http.MethodPut, headers: http.Header{"Origin": {origin}}}))
+		return w
+	}
+
+	w = serve("https://app.example.com")
+	assert.Equal(t, http.StatusOK, w.header)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Request-Id", w.Header().Get("Access-Control-Expose-Headers"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+
+	w = serve("https://evil.com")
+	assert.Equal(t, http.StatusOK, w.header)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	require.NoError(t, m.SetCORS(`Method("PUT") && Path("/items")`, nil))
+	assert.Empty(t, serve("https://app.example.com").Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestSetCORSUnder(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/api/a")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path("/api/b")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path("/internal")`, newStatusHandler(http.StatusOK)))
+
+	n, err := m.SetCORSUnder("/api/", &CORSPolicy{AllowedOrigins: []string{"*"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	for path, expected := range map[string]string{"/api/a": "*", "/api/b": "*", "/internal": ""} {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: path, headers: http.Header{"Origin": {"https://example.com"}}}))
+		assert.Equal(t, expected, w.Header().Get("Access-Control-Allow-Origin"), path)
+	}
+}
+
+func TestCORSPolicyValidation(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/")`, newStatusHandler(http.StatusOK)))
+
+	assert.Error(t, m.SetCORS(`Path("/")`, &CORSPolicy{}))
+	assert.Error(t, m.SetCORS(`Path("/")`, &CORSPolicy{AllowedOrigins: []string{""}}))
+	assert.Error(t, m.SetCORS(`Path("/")`, &CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true}))
+	assert.Error(t, m.SetCORS(`Path("/other")`, &CORSPolicy{AllowedOrigins: []string{"*"}}))
+}
@@ -0,0 +1,70 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// MatcherFactory creates the predicate of a custom matcher from the string arguments of the matcher
+// in the expression, e.g. "DE" for GeoCountry("DE"). It is called when the expression is parsed,
+// so arguments should be validated and compiled once there, not on every request.
+type MatcherFactory func(args ...string) (func(r *http.Request) bool, error)
+
+var customMatchers = struct {
+	mutex     sync.RWMutex
+	factories map[string]MatcherFactory
+}{
+	factories: make(map[string]MatcherFactory),
+}
+
+// RegisterMatcher registers a custom matcher usable in expressions under the name,
+// e.g. GeoCountry("DE") && Path("/checkout"). Custom matchers are matched like regexp matchers:
+// they are never joined into the tries. The names of the built-in matchers can't be used
+// and a name can't be registered twice. Matchers should be registered before the expressions
+// using them are parsed, typically in an init function.
+func RegisterMatcher(name string, factory MatcherFactory) error {
+	if !token.IsIdentifier(name) {
+		return fmt.Errorf("matcher name %q is not a valid identifier", name)
+	}
+	if factory == nil {
+		return errors.New("matcher factory cannot be nil: operation rejected")
+	}
+	if _, ok := matcherFuncs()[name]; ok {
+		return fmt.Errorf("matcher %s is already registered", name)
+	}
+
+	customMatchers.mutex.Lock()
+	defer customMatchers.mutex.Unlock()
+
+	if _, ok := customMatchers.factories[name]; ok {
+		return fmt.Errorf("matcher %s is already registered", name)
+	}
+	customMatchers.factories[name] = factory
+	return nil
+}
+
+// addCustomMatchers adds the functions of the custom matchers to the functions of the expression language
+func addCustomMatchers(funcs map[string]interface{}) {
+	customMatchers.mutex.RLock()
+	defer customMatchers.mutex.RUnlock()
+
+	for name, factory := range customMatchers.factories {
+		if _, ok := funcs[name]; ok {
+			continue
+		}
+		funcs[name] = func(args ...string) (matcher, error) {
+			fn, err := factory(args...)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if fn == nil {
+				return nil, fmt.Errorf("%s: matcher factory returned a nil predicate", name)
+			}
+			return newFuncMatcher(fmt.Sprintf("%s(%s)", name, strings.Join(args, ", ")), fn), nil
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterMatcher(t *testing.T) {
+	require.NoError(t, RegisterMatcher("TestCountry", func(args ...string) (func(*http.Request) bool, error) {
+		if len(args) == 0 {
+			return nil, errors.New("at least one country is required")
+		}
+		return func(r *http.Request) bool {
+			country := r.Header.Get("X-Country")
+			for _, arg := range args {
+				if strings.EqualFold(arg, country) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}))
+
+	m := NewMux()
+	require.NoError(t, m.Handle(`TestCountry("DE", "AT") && Path("/checkout")`, newStatusHandler(http.StatusOK)))
+
+	serve := func(country string) int {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/checkout", headers: http.Header{"X-Country": {country}}}))
+		return w.header
+	}
+	assert.Equal(t, http.StatusOK, serve("de"))
+	assert.Equal(t, http.StatusOK, serve("AT"))
+	assert.Equal(t, http.StatusNotFound, serve("FR"))
+
+	assert.False(t, IsValid(`TestCountry()`))
+	assert.False(t, IsValid(`TestCountry(1)`))
+
+	assert.Error(t, RegisterMatcher("TestCountry", func(...string) (func(*http.Request) bool, error) { return nil, nil }))
+	assert.Error(t, RegisterMatcher("Host", func(...string) (func(*http.Request) bool, error) { return nil, nil }))
+	assert.Error(t, RegisterMatcher("Test Country", func(...string) (func(*http.Request) bool, error) { return nil, nil }))
+	assert.Error(t, RegisterMatcher("TestNil", nil))
+}
+
+func TestRegisterMatcherNilPredicate(t *testing.T) {
+	require.NoError(t, RegisterMatcher("TestNilPredicate", func(...string) (func(*http.Request) bool, error) {
+		return nil, nil
+	}))
+	assert.False(t, IsValid(`TestNilPredicate("a")`))
+}
@@ -0,0 +1,79 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Deprecation describes the deprecation of a route, see Mux.SetDeprecation
+type Deprecation struct {
+	// Since is the deprecation date sent in the Deprecation header (RFC 9745), the header is "true" when zero
+	Since time.Time
+	// Sunset is the date the route stops being served, sent in the Sunset header (RFC 8594) when set
+	Sunset time.Time
+	// Link is the URL of the deprecation documentation, sent in a Link header when set
+	Link string
+	// Disable answers 410 Gone instead of calling the handler after the sunset date
+	Disable bool
+}
+
+// deprecation is the deprecation of a route with its usage counter
+type deprecation struct {
+	Deprecation
+	requests atomic.Int64
+}
+
+// SetDeprecation marks the route registered for the expression as deprecated: the Deprecation, Sunset
+// and Link headers are added to its responses and its requests are counted, see DeprecatedRequests.
+// A nil deprecation removes the mark.
+func (m *Mux) SetDeprecation(expr string, d *Deprecation) error {
+	if d != nil && d.Disable && d.Sunset.IsZero() {
+		return fmt.Errorf("disabling '%s' requires a sunset date", expr)
+	}
+	return m.setOptions(expr, func(o *routeOptions) {
+		if d == nil {
+			o.deprecation = nil
+			return
+		}
+		o.deprecation = &deprecation{Deprecation: *d}
+	})
+}
+
+// DeprecatedRequests returns the number of requests served by the deprecated route registered for the expression
+// since it was marked as deprecated, false if the route is not deprecated
+func (m *Mux) DeprecatedRequests(expr string) (int64, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	e, err := m.route(expr)
+	if err != nil {
+		return 0, false
+	}
+	d := e.options.Load().deprecation
+	if d == nil {
+		return 0, false
+	}
+	return d.requests.Load(), true
+}
+
+// apply adds the deprecation headers, it returns false if the route is past its sunset date and disabled
+func (d *deprecation) apply(w http.ResponseWriter, clock Clock) bool {
+	d.requests.Add(1)
+
+	header := w.Header()
+	if d.Since.IsZero() {
+		header.Set("Deprecation", "true")
+	} else {
+		header.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+	}
+	if !d.Sunset.IsZero() {
+		header.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Link != "" {
+		header.Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, d.Link))
+	}
+	return !d.Disable || clock.Now().Before(d.Sunset)
+}
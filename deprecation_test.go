@@ -0,0 +1,57 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecation(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/v1")`, newStatusHandler(http.StatusOK)))
+
+	serve := func() *testWriter {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/v1"}))
+		return w
+	}
+
+	_, ok := m.DeprecatedRequests(`Path("/v1")`)
+	assert.False(t, ok)
+
+	since := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Now().Add(time.Hour)
+	require.NoError(t, m.SetDeprecation(`Path("/v1")`, &Deprecation{
+		Since:   since,
+		Sunset:  sunset,
+		Link:    "https://example.com/deprecations/v1",
+		Disable: true,
+	}))
+
+	w := serve()
+	assert.Equal(t, http.StatusOK, w.header)
+	assert.Equal(t, "@1704067200", w.Header().Get("Deprecation"))
+	assert.Equal(t, sunset.UTC().Format(http.TimeFormat), w.Header().Get("Sunset"))
+	assert.Equal(t, `<https://example.com/deprecations/v1>; rel="deprecation"`, w.Header().Get("Link"))
+
+	requests, ok := m.DeprecatedRequests(`Path("/v1")`)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), requests)
+
+	// disabled after the sunset date
+	require.NoError(t, m.SetDeprecation(`Path("/v1")`, &Deprecation{Sunset: time.Now().Add(-time.Hour), Disable: true}))
+	w = serve()
+	assert.Equal(t, http.StatusGone, w.header)
+	assert.Equal(t, "true", w.Header().Get("Deprecation"))
+
+	require.NoError(t, m.SetDeprecation(`Path("/v1")`, nil))
+	w = serve()
+	assert.Equal(t, http.StatusOK, w.header)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	assert.Error(t, m.SetDeprecation(`Path("/v1")`, &Deprecation{Disable: true}))
+	assert.Error(t, m.SetDeprecation(`Path("/v2")`, &Deprecation{}))
+}
@@ -0,0 +1,70 @@
+package route
+
+import (
+	"sort"
+)
+
+// Diff describes the changes between two route tables
+type Diff struct {
+	// Added are the expressions of the new routes
+	Added []string
+	// Removed are the expressions of the removed routes
+	Removed []string
+	// Changed are the new expressions of the routes whose key stays the same but whose expression changes,
+	// e.g. after whitespace changes with the default key function
+	Changed []string
+	// Updated are the expressions of the routes whose owner, priority or metadata changes, see DiffSnapshots.
+	// DryRunInitHandlers does not report them as InitHandlers does not take these settings.
+	Updated []string
+}
+
+// Empty returns true if there are no changes
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && len(d.Updated) == 0
+}
+
+// DryRunInitHandlers validates the handlers exactly like InitHandlers does: the route table is checked
+// for owned routes, expressions are parsed, expanded with the aliases, checked for conflicts and compiled. It returns the changes InitHandlers
+// would make to the route table, without applying them, so rule sets can be checked in CI pipelines.
+// Like InitHandlers, it returns a *ValidationError listing the offending expressions.
+func (m *Mux) DryRunInitHandlers(handlers map[string]interface{}) (Diff, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if err := m.checkOwnersLocked(); err != nil {
+		return Diff{}, err
+	}
+	keys, err := m.entriesFor(handlers)
+	if err != nil {
+		return Diff{}, err
+	}
+
+	routes := m.routesFor(keys)
+	if err := New().InitRoutes(routes); err != nil {
+		return Diff{}, m.compileError(routes, err)
+	}
+
+	return diffEntries(m.keys, keys), nil
+}
+
+func diffEntries(old, updated map[string]*entry) Diff {
+	var d Diff
+	for key, e := range updated {
+		prev, ok := old[key]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, e.expr)
+		case prev.expr != e.expr:
+			d.Changed = append(d.Changed, e.expr)
+		}
+	}
+	for key, e := range old {
+		if _, ok := updated[key]; !ok {
+			d.Removed = append(d.Removed, e.expr)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Changed)
+	return d
+}
@@ -0,0 +1,66 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunInitHandlers(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/a")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path("/b")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path("/c")`, newStatusHandler(http.StatusOK)))
+
+	handlers := map[string]interface{}{
+		`Path("/a")`:   newStatusHandler(http.StatusOK),
+		`Path( "/b" )`: newStatusHandler(http.StatusOK),
+		`Path("/d")`:   newStatusHandler(http.StatusOK),
+	}
+	diff, err := m.DryRunInitHandlers(handlers)
+	require.NoError(t, err)
+	assert.Equal(t, Diff{
+		Added:   []string{`Path("/d")`},
+		Removed: []string{`Path("/c")`},
+		Changed: []string{`Path( "/b" )`},
+	}, diff)
+	assert.False(t, diff.Empty())
+
+	// nothing was applied
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/d"}))
+	assert.Equal(t, http.StatusNotFound, w.header)
+
+	require.NoError(t, m.InitHandlers(handlers))
+	diff, err = m.DryRunInitHandlers(handlers)
+	require.NoError(t, err)
+	assert.True(t, diff.Empty())
+}
+
+func TestDryRunInitHandlersErrors(t *testing.T) {
+	m := NewMux()
+	m.AddAlias(`Path("/a")`, `Path(`)
+
+	testCases := []map[string]interface{}{
+		{`Path("/b"`: newStatusHandler(http.StatusOK)},
+		{`Path("/b")`: "not a handler"},
+		{`Path("/b")`: newStatusHandler(http.StatusOK), `Path( "/b")`: newStatusHandler(http.StatusOK)},
+		{`Path("/a")`: newStatusHandler(http.StatusOK)},
+	}
+	for _, handlers := range testCases {
+		_, err := m.DryRunInitHandlers(handlers)
+		assert.Error(t, err, handlers)
+	}
+}
+
+func TestDryRunInitHandlersOwned(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleOwned("infra", `Path("/healthz")`, newStatusHandler(http.StatusOK)))
+
+	handlers := map[string]interface{}{`Path("/a")`: newStatusHandler(http.StatusOK)}
+	_, err := m.DryRunInitHandlers(handlers)
+	require.ErrorIs(t, err, ErrRouteOwned)
+	require.ErrorIs(t, m.InitHandlers(handlers), ErrRouteOwned)
+}
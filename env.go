@@ -0,0 +1,44 @@
+package route
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// reEnvVar matches ${NAME} environment variable references
+var reEnvVar = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandEnv replaces ${NAME} references in s with the values of the environment variables,
+// it returns an error if any referenced variable is not set. Other uses of '$' are left untouched,
+// so regular expressions like PathRegexp("/v1$") are safe to expand.
+func ExpandEnv(s string) (string, error) {
+	var missing []string
+	out := reEnvVar.ReplaceAllStringFunc(s, func(ref string) string {
+		name := reEnvVar.FindStringSubmatch(ref)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) != 0 {
+		return "", fmt.Errorf("environment variables not set: %v", missing)
+	}
+	return out, nil
+}
+
+// AddAliasEnv is like AddAlias, but expands ${NAME} environment variable references
+// in match and replace first, see ExpandEnv
+func (m *Mux) AddAliasEnv(match, replace string) error {
+	match, err := ExpandEnv(match)
+	if err != nil {
+		return err
+	}
+	replace, err = ExpandEnv(replace)
+	if err != nil {
+		return err
+	}
+	m.AddAlias(match, replace)
+	return nil
+}
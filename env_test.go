@@ -0,0 +1,38 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("ROUTE_HOST", "staging.example.com")
+	t.Setenv("ROUTE_EMPTY", "")
+
+	out, err := ExpandEnv(`Host("${ROUTE_HOST}") && PathRegexp("/v1$")`)
+	require.NoError(t, err)
+	assert.Equal(t, `Host("staging.example.com") && PathRegexp("/v1$")`, out)
+
+	out, err = ExpandEnv(`Path("/${ROUTE_EMPTY}")`)
+	require.NoError(t, err)
+	assert.Equal(t, `Path("/")`, out)
+
+	_, err = ExpandEnv(`Host("${ROUTE_MISSING}")`)
+	assert.ErrorContains(t, err, "ROUTE_MISSING")
+}
+
+func TestAddAliasEnv(t *testing.T) {
+	t.Setenv("ROUTE_HOST", "staging.example.com")
+
+	m := NewMux()
+	require.NoError(t, m.AddAliasEnv(`Host("localhost")`, `Host("${ROUTE_HOST}")`))
+	require.Error(t, m.AddAliasEnv(`Host("localhost")`, `Host("${ROUTE_MISSING}")`))
+	require.NoError(t, m.Handle(`Host("localhost") && Path("/p")`, newStatusHandler(http.StatusCreated)))
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/p", host: "staging.example.com"}))
+	assert.Equal(t, http.StatusCreated, w.header)
+}
@@ -0,0 +1,101 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+)
+
+// ExportedRoute is the machine-readable description of a route, see Mux.Export
+type ExportedRoute struct {
+	// Expr is the expression the route was registered with
+	Expr string `json:"expr"`
+	// Alias is the expression rewritten by the aliases of the mux, empty if no alias applies
+	Alias string `json:"alias,omitempty"`
+	// Host, Path and Method are the arguments of the Host, Path and Method matchers of the expression
+	Host   string `json:"host,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Method string `json:"method,omitempty"`
+	// Name is the name of the route, see HandleNamed
+	Name string `json:"name,omitempty"`
+	// Owner is the owner of the route, see HandleOwned
+	Owner string `json:"owner,omitempty"`
+	// Priority is the explicit priority of the route, see HandleWithPriority
+	Priority int `json:"priority,omitempty"`
+	// Meta is the metadata of the route, see HandleMeta
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+// RouteTable is the machine-readable route table produced by Mux.Export
+type RouteTable struct {
+	// Routes are the registered routes in trie order
+	Routes []ExportedRoute `json:"routes"`
+	// Hash is the hexadecimal hash of the route table, see Mux.Hash
+	Hash string `json:"hash"`
+}
+
+// Export returns the route table as indented JSON, e.g. to generate documentation or to diff the route
+// tables of two deployments. The handlers are not exported, see Import to load the table back.
+func (m *Mux) Export() ([]byte, error) {
+	m.mutex.RLock()
+	table := RouteTable{Routes: make([]ExportedRoute, 0, len(m.sorted)), Hash: strconv.FormatUint(m.hash, 16)}
+	for _, e := range m.sorted {
+		table.Routes = append(table.Routes, ExportedRoute{
+			Expr:     e.expr,
+			Alias:    e.alias,
+			Host:     e.host,
+			Path:     e.path,
+			Method:   e.method,
+			Name:     e.name,
+			Owner:    e.owner,
+			Priority: e.priority,
+			Meta:     maps.Clone(e.meta),
+		})
+	}
+	m.mutex.RUnlock()
+
+	return json.MarshalIndent(table, "", "  ")
+}
+
+// Import replaces the route table with the routes exported by Export, like InitHandlers. The resolver
+// returns the handler of each route, e.g. by its name or its metadata. The names, the priorities
+// and the metadata of the routes are restored, the owners are not.
+func (m *Mux) Import(data []byte, resolver func(ExportedRoute) (http.Handler, error)) error {
+	var table RouteTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return fmt.Errorf("invalid route table: %w", err)
+	}
+	handlers := make(map[string]interface{}, len(table.Routes))
+	registrations := make(map[string]registration, len(table.Routes))
+	names := make(map[string]string)
+	for _, r := range table.Routes {
+		h, err := resolver(r)
+		if err != nil {
+			return fmt.Errorf("route '%s': %w", r.Expr, err)
+		}
+		handlers[r.Expr] = h
+		registrations[r.Expr] = registration{priority: r.Priority, meta: r.Meta}
+		if r.Name == "" {
+			continue
+		}
+		if expr, ok := names[r.Name]; ok {
+			return fmt.Errorf("route name %s is used by '%s' and '%s'", r.Name, expr, r.Expr)
+		}
+		names[r.Name] = r.Expr
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.initHandlers(handlers, registrations, false); err != nil {
+		return err
+	}
+	for name, expr := range names {
+		if err := m.nameLocked(m.keyFunc(expr), name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
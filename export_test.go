@@ -0,0 +1,86 @@
+package route
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	m := NewMux()
+	m.AddAlias(`Host("localhost")`, `Host("example.com")`)
+	require.NoError(t, m.HandleNamed("user", `Host("localhost") && Method("GET") && Path("/users/<id>")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.HandleMeta(`Path("/health")`, newStatusHandler(http.StatusNoContent), map[string]string{"team": "sre"}))
+	require.NoError(t, m.HandleWithPriority(`Path("/admin")`, newStatusHandler(http.StatusOK), 10))
+
+	data, err := m.Export()
+	require.NoError(t, err)
+
+	var table RouteTable
+	require.NoError(t, json.Unmarshal(data, &table))
+	assert.Equal(t, strconv.FormatUint(m.Hash(), 16), table.Hash)
+	assert.Equal(t, []ExportedRoute{
+		{Expr: `Path("/admin")`, Path: "/admin", Priority: 10},
+		{Expr: `Path("/health")`, Path: "/health", Meta: map[string]string{"team": "sre"}},
+		{
+			Expr:   `Host("localhost") && Method("GET") && Path("/users/<id>")`,
+			Alias:  `Host("example.com") && Method("GET") && Path("/users/<id>")`,
+			Host:   "localhost",
+			Path:   "/users/<id>",
+			Method: "GET",
+			Name:   "user",
+		},
+	}, table.Routes)
+	assert.Contains(t, string(data), `"meta": {`)
+}
+
+func TestImport(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleNamed("user", `Path("/users/<id>")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.HandleMeta(`Path("/health")`, newStatusHandler(http.StatusNoContent), map[string]string{"status": "204"}))
+	require.NoError(t, m.HandleWithPriority(`Path("/admin")`, newStatusHandler(http.StatusOK), 10))
+	data, err := m.Export()
+	require.NoError(t, err)
+
+	imported := NewMux()
+	err = imported.Import(data, func(r ExportedRoute) (http.Handler, error) {
+		status := http.StatusOK
+		if s, ok := r.Meta["status"]; ok {
+			status, _ = strconv.Atoi(s)
+		}
+		return newStatusHandler(status), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, m.Hash(), imported.Hash())
+
+	again, err := imported.Export()
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(again))
+
+	u, err := imported.URL("user", "id", "42")
+	require.NoError(t, err)
+	assert.Equal(t, "/users/42", u.String())
+
+	w := newWriter()
+	imported.ServeHTTP(w, makeReq(req{url: "/health"}))
+	assert.Equal(t, http.StatusNoContent, w.header)
+}
+
+func TestImportErrors(t *testing.T) {
+	m := NewMux()
+	resolver := func(ExportedRoute) (http.Handler, error) { return newStatusHandler(http.StatusOK), nil }
+
+	assert.Error(t, m.Import([]byte(`{`), resolver))
+	assert.Error(t, m.Import([]byte(`{"routes": [{"expr": "Path(\"/a\")"}]}`), func(ExportedRoute) (http.Handler, error) {
+		return nil, errors.New("unknown backend")
+	}))
+	assert.Error(t, m.Import([]byte(`{"routes": [{"expr": "Path(\"/a\")", "name": "a"}, {"expr": "Path(\"/b\")", "name": "a"}]}`), resolver))
+
+	var validation *ValidationError
+	assert.ErrorAs(t, m.Import([]byte(`{"routes": [{"expr": "Pth(\"/a\")"}]}`), resolver), &validation)
+}
@@ -0,0 +1,368 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// Expr is a node of a parsed route expression, either an operator or a matcher call
+type Expr struct {
+	// Op is the operator of the node: "&&", "||" or "!", empty for a matcher call
+	Op string
+	// Operands are the operands of the operator, two for && and ||, one for !
+	Operands []Expr
+	// Matcher is the name of the matcher called, e.g. Path, empty for an operator
+	Matcher string
+	// Args are the arguments of the matcher call: unquoted strings and ints
+	Args []interface{}
+	// Offset is the byte offset of the node in the expression
+	Offset int
+}
+
+// String returns the canonical form of the expression: string arguments are double-quoted, operators
+// are surrounded by spaces, and parentheses are only kept where the precedence requires them,
+// e.g. Host("localhost") && (Path("/v1") || Path("/v2"))
+func (e Expr) String() string {
+	var b strings.Builder
+	e.write(&b)
+	return b.String()
+}
+
+func (e Expr) write(b *strings.Builder) {
+	switch e.Op {
+	case "":
+		b.WriteString(e.Matcher)
+		b.WriteByte('(')
+		for i, arg := range e.Args {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			switch v := arg.(type) {
+			case string:
+				b.WriteString(strconv.Quote(v))
+			default:
+				fmt.Fprint(b, v)
+			}
+		}
+		b.WriteByte(')')
+	case "!":
+		b.WriteByte('!')
+		e.Operands[0].writeOperand(b, e.Op)
+	default:
+		e.Operands[0].writeOperand(b, e.Op)
+		b.WriteString(" " + e.Op + " ")
+		e.Operands[1].writeOperand(b, e.Op)
+	}
+}
+
+// writeOperand writes the operand of the operator, in parentheses if it binds less tightly than the operator
+func (e Expr) writeOperand(b *strings.Builder, op string) {
+	if precedence(e.Op) < precedence(op) {
+		b.WriteByte('(')
+		e.write(b)
+		b.WriteByte(')')
+		return
+	}
+	e.write(b)
+}
+
+// precedence returns how tightly the operator binds, matcher calls bind the most
+func precedence(op string) int {
+	switch op {
+	case "||":
+		return 1
+	case "&&":
+		return 2
+	case "!":
+		return 3
+	}
+	return 4
+}
+
+// ParseError describes why an expression can't be parsed
+type ParseError struct {
+	// Expr is the expression
+	Expr string
+	// Offset is the byte offset of the offending token in the expression
+	Offset int
+	// Token is the offending token, empty at the end of the expression
+	Token string
+	// Err is the cause of the error
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("parse error at end of expression (offset %d): %v", e.Offset, e.Err)
+	}
+	return fmt.Sprintf("parse error at offset %d near %s: %v", e.Offset, e.Token, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Parse parses the expression like the router does, checking the matchers and their arguments.
+// The error is a *ParseError locating the offending token in the expression.
+func Parse(expr string) (Expr, error) {
+	src := blankComments(expr)
+	fset := token.NewFileSet()
+	node, err := parser.ParseExprFrom(fset, "", src, 0)
+	if err != nil {
+		var list scanner.ErrorList
+		if errors.As(err, &list) && len(list) != 0 {
+			return Expr{}, newParseError(expr, src, list[0].Pos.Offset, errors.New(list[0].Msg))
+		}
+		return Expr{}, newParseError(expr, src, 0, err)
+	}
+	p := exprParser{expr: expr, src: src, fset: fset, funcs: matcherFuncs()}
+	return p.parse(node)
+}
+
+// explainError returns the *ParseError locating the error in the expression, err if the expression parses
+func explainError(expr string, err error) error {
+	if _, parseErr := Parse(expr); parseErr != nil {
+		return fmt.Errorf("invalid expression: %w", parseErr)
+	}
+	return err
+}
+
+// newParseError returns the error at the offset of the expression, src is the expression without comments
+func newParseError(expr, src string, offset int, err error) *ParseError {
+	return &ParseError{Expr: expr, Offset: offset, Token: tokenAt(src, offset), Err: err}
+}
+
+// tokenAt returns the token starting at the offset: an identifier, a string literal or an operator
+func tokenAt(src string, offset int) string {
+	if offset >= len(src) {
+		return ""
+	}
+	c := src[offset]
+	switch {
+	case c == '"' || c == '`':
+		return src[offset:literalEnd(src, offset)]
+	case c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9'):
+		end := offset + 1
+		for end < len(src) && (src[end] == '_' || ('a' <= src[end] && src[end] <= 'z') ||
+			('A' <= src[end] && src[end] <= 'Z') || ('0' <= src[end] && src[end] <= '9')) {
+			end++
+		}
+		return src[offset:end]
+	}
+	for _, op := range []string{"&&", "||", "==", "!=", "<=", ">="} {
+		if strings.HasPrefix(src[offset:], op) {
+			return op
+		}
+	}
+	return src[offset : offset+1]
+}
+
+// exprParser checks the nodes of the expression and converts them
+type exprParser struct {
+	expr  string
+	src   string
+	fset  *token.FileSet
+	funcs map[string]interface{}
+}
+
+func (p exprParser) offset(pos token.Pos) int {
+	return p.fset.Position(pos).Offset
+}
+
+func (p exprParser) errorf(pos token.Pos, format string, args ...interface{}) error {
+	return newParseError(p.expr, p.src, p.offset(pos), fmt.Errorf(format, args...))
+}
+
+func (p exprParser) parse(node ast.Expr) (Expr, error) {
+	switch n := node.(type) {
+	case *ast.ParenExpr:
+		return p.parse(n.X)
+	case *ast.UnaryExpr:
+		if n.Op != token.NOT {
+			return Expr{}, p.errorf(n.OpPos, "unsupported operator %v", n.Op)
+		}
+		x, err := p.parse(n.X)
+		if err != nil {
+			return Expr{}, err
+		}
+		return Expr{Op: "!", Operands: []Expr{x}, Offset: p.offset(n.OpPos)}, nil
+	case *ast.BinaryExpr:
+		if n.Op != token.LAND && n.Op != token.LOR {
+			return Expr{}, p.errorf(n.OpPos, "unsupported operator %v", n.Op)
+		}
+		x, err := p.parse(n.X)
+		if err != nil {
+			return Expr{}, err
+		}
+		y, err := p.parse(n.Y)
+		if err != nil {
+			return Expr{}, err
+		}
+		return Expr{Op: n.Op.String(), Operands: []Expr{x, y}, Offset: p.offset(n.Pos())}, nil
+	case *ast.CallExpr:
+		return p.parseCall(n)
+	}
+	return Expr{}, p.errorf(node.Pos(), "expected a matcher call")
+}
+
+// parseCall checks the matcher call by building the matcher
+func (p exprParser) parseCall(n *ast.CallExpr) (Expr, error) {
+	name, ok := n.Fun.(*ast.Ident)
+	if !ok {
+		return Expr{}, p.errorf(n.Fun.Pos(), "expected a matcher name")
+	}
+	if _, ok := p.funcs[name.Name]; !ok {
+		return Expr{}, p.errorf(name.Pos(), "unknown matcher %s", name.Name)
+	}
+	out := Expr{Matcher: name.Name, Offset: p.offset(n.Pos())}
+	for _, a := range n.Args {
+		lit, ok := a.(*ast.BasicLit)
+		if !ok {
+			return Expr{}, p.errorf(a.Pos(), "matcher arguments should be string or int literals")
+		}
+		switch lit.Kind {
+		case token.STRING:
+			v, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return Expr{}, p.errorf(lit.Pos(), "malformed string literal")
+			}
+			out.Args = append(out.Args, v)
+		case token.INT:
+			v, err := strconv.Atoi(lit.Value)
+			if err != nil {
+				return Expr{}, p.errorf(lit.Pos(), "malformed int literal")
+			}
+			out.Args = append(out.Args, v)
+		default:
+			return Expr{}, p.errorf(lit.Pos(), "matcher arguments should be string or int literals")
+		}
+	}
+	if _, err := parse(p.src[p.offset(n.Pos()):p.offset(n.End())], &match{}); err != nil {
+		return Expr{}, p.errorf(name.Pos(), "%s: %v", name.Name, err)
+	}
+	return out, nil
+}
+
+// blankComments replaces the comments and the line breaks of the expression with spaces,
+// like normalizeExpr but keeping the offsets of the expression
+func blankComments(expr string) string {
+	if !strings.ContainsAny(expr, "#\r\n") {
+		return expr
+	}
+	b := []byte(expr)
+	for i := 0; i < len(b); i++ {
+		switch b[i] {
+		case '"', '`':
+			i = literalEnd(expr, i) - 1
+		case '#':
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				b[i] = ' '
+			}
+			i--
+		case '\r', '\n':
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
+
+// term is a single matcher call of an expression, e.g. Path("/v1")
+type term struct {
+	name string
+	args []string
+}
+
+// terms returns the matcher calls required by the expression, the calls joined with &&.
+// The calls under ! and || are left out as a request can match the expression without matching them.
+func terms(expr string) ([]term, error) {
+	node, err := parser.ParseExpr(normalizeExpr(expr))
+	if err != nil {
+		return nil, err
+	}
+	return collectTerms(node, nil)
+}
+
+func collectTerms(node ast.Expr, out []term) ([]term, error) {
+	switch n := node.(type) {
+	case *ast.ParenExpr:
+		return collectTerms(n.X, out)
+	case *ast.UnaryExpr:
+		if n.Op != token.NOT {
+			return nil, fmt.Errorf("%v is not supported", n.Op)
+		}
+		return out, nil
+	case *ast.BinaryExpr:
+		switch n.Op {
+		case token.LOR:
+			return out, nil
+		case token.LAND:
+		default:
+			return nil, fmt.Errorf("%v is not supported", n.Op)
+		}
+		out, err := collectTerms(n.X, out)
+		if err != nil {
+			return nil, err
+		}
+		return collectTerms(n.Y, out)
+	case *ast.CallExpr:
+		name, ok := n.Fun.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("expected identifier, got: %T", n.Fun)
+		}
+		t := term{name: name.Name}
+		for _, a := range n.Args {
+			lit, ok := a.(*ast.BasicLit)
+			if !ok {
+				return nil, fmt.Errorf("unsupported function argument: %T", a)
+			}
+			value := lit.Value
+			if lit.Kind == token.STRING {
+				v, err := strconv.Unquote(lit.Value)
+				if err != nil {
+					return nil, err
+				}
+				value = v
+			}
+			t.args = append(t.args, value)
+		}
+		return append(out, t), nil
+	}
+	return nil, fmt.Errorf("%T is not supported", node)
+}
+
+// conjunction returns true if the expression only joins matcher calls with &&, so its terms are the whole expression
+func conjunction(expr string) bool {
+	node, err := parser.ParseExpr(normalizeExpr(expr))
+	if err != nil {
+		return false
+	}
+	var walk func(ast.Expr) bool
+	walk = func(node ast.Expr) bool {
+		switch n := node.(type) {
+		case *ast.ParenExpr:
+			return walk(n.X)
+		case *ast.BinaryExpr:
+			return n.Op == token.LAND && walk(n.X) && walk(n.Y)
+		case *ast.CallExpr:
+			return true
+		}
+		return false
+	}
+	return walk(node)
+}
+
+// termArg returns the first argument of the first matcher with the given name
+func termArg(ts []term, name string) string {
+	for _, t := range ts {
+		if t.name == name && len(t.args) != 0 {
+			return t.args[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTerms(t *testing.T) {
+	ts, err := terms(`Host("localhost") && (Method("GET") && Header("X-A", "b"))`)
+	require.NoError(t, err)
+	assert.Equal(t, []term{
+		{name: "Host", args: []string{"localhost"}},
+		{name: "Method", args: []string{"GET"}},
+		{name: "Header", args: []string{"X-A", "b"}},
+	}, ts)
+
+	assert.Equal(t, "localhost", termArg(ts, "Host"))
+	assert.Empty(t, termArg(ts, "Path"))
+
+	// matchers under ! and || are not required
+	ts, err = terms(`Host("localhost") && !Method("GET") && (Path("/a") || Path("/b"))`)
+	require.NoError(t, err)
+	assert.Equal(t, []term{{name: "Host", args: []string{"localhost"}}}, ts)
+
+	_, err = terms(`Path("/a") == Path("/b")`)
+	assert.Error(t, err)
+}
+
+func TestParse(t *testing.T) {
+	e, err := Parse(`Host("localhost") && !(Method("GET") || PathSegment(1, "v2"))`)
+	require.NoError(t, err)
+	assert.Equal(t, Expr{Op: "&&", Offset: 0, Operands: []Expr{
+		{Matcher: "Host", Args: []interface{}{"localhost"}, Offset: 0},
+		{Op: "!", Offset: 21, Operands: []Expr{
+			{Op: "||", Offset: 23, Operands: []Expr{
+				{Matcher: "Method", Args: []interface{}{"GET"}, Offset: 23},
+				{Matcher: "PathSegment", Args: []interface{}{1, "v2"}, Offset: 40},
+			}},
+		}},
+	}}, e)
+
+	// comments keep the offsets of the expression
+	e, err = Parse("Host(`localhost`) # internal\n  && Path(\"/v1\")")
+	require.NoError(t, err)
+	assert.Equal(t, 34, e.Operands[1].Offset)
+}
+
+func TestParseErrors(t *testing.T) {
+	tcs := []struct {
+		expr   string
+		offset int
+		token  string
+	}{
+		{expr: `Host("localhost") && Pth("/v1")`, offset: 21, token: "Pth"},
+		{expr: `Host("localhost") && Path("/v1"`, offset: 31},
+		{expr: `Host("localhost") &&& Path("/v1")`, offset: 20, token: "&"},
+		{expr: `Host("localhost") == Path("/v1")`, offset: 18, token: "=="},
+		{expr: `Host("localhost") && PathRegexp("/v1/(")`, offset: 21, token: "PathRegexp"},
+		{expr: `Host("localhost") && Path(version)`, offset: 26, token: "version"},
+		{expr: `Host("localhost") && "/v1"`, offset: 21, token: `"/v1"`},
+		{expr: "Host(\"localhost\") # comment\n && Methd(\"GET\")", offset: 32, token: "Methd"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Parse(tc.expr)
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tc.expr, parseErr.Expr)
+			assert.Equal(t, tc.offset, parseErr.Offset)
+			assert.Equal(t, tc.token, parseErr.Token)
+			assert.False(t, IsValid(tc.expr))
+		})
+	}
+}
+
+func TestHandleParseError(t *testing.T) {
+	m := NewMux()
+	err := m.Handle(`Host("localhost") && Pth("/v1")`, newStatusHandler(200))
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 21, parseErr.Offset)
+	assert.EqualError(t, err, `invalid expression: parse error at offset 21 near Pth: unknown matcher Pth`)
+}
+
+func TestExprString(t *testing.T) {
+	tcs := []struct {
+		expr     string
+		expected string
+	}{
+		{expr: "Host(`localhost`)&&Path( \"/v1\" )", expected: `Host("localhost") && Path("/v1")`},
+		{expr: `Host("localhost") && (Path("/v1") || Path("/v2"))`, expected: `Host("localhost") && (Path("/v1") || Path("/v2"))`},
+		{expr: `(Host("localhost") && Path("/v1")) || Path("/v2")`, expected: `Host("localhost") && Path("/v1") || Path("/v2")`},
+		{expr: `!(Method("GET") || Method("HEAD")) && !Header("X-A", "b")`, expected: `!(Method("GET") || Method("HEAD")) && !Header("X-A", "b")`},
+		{expr: `((PathSegment(2, "admin")))`, expected: `PathSegment(2, "admin")`},
+		{expr: "Path(\"/v1\") # legacy\n && Query(\"q\", \"a\\\"b\")", expected: `Path("/v1") && Query("q", "a\"b")`},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.expr, func(t *testing.T) {
+			e, err := Parse(tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, e.String())
+
+			// the canonical form parses to the same tree
+			again, err := Parse(e.String())
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, again.String())
+		})
+	}
+}
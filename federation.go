@@ -0,0 +1,117 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// FederationMember is a router of a federation with the class of hosts it routes
+type FederationMember struct {
+	// Name identifies the member, e.g. "infra" or "tenants"
+	Name string
+	// Router routes the requests of the hosts of the member
+	Router Router
+	// Hosts are the hosts routed by the member, e.g. "api.example.com" or "*.tenants.example.com"
+	// for the subdomains of tenants.example.com. A member without hosts routes the requests of any host.
+	Hosts []string
+}
+
+// Federation partitions the traffic across independent routers, e.g. a small router for the static
+// infrastructure routes and a huge router for the dynamic tenant routes. The members are tried in order
+// and only for the requests of their hosts, so the size and the updates of a member don't affect
+// the latency of the requests resolved by the members before it.
+type Federation struct {
+	members []federationMember
+}
+
+type federationMember struct {
+	name   string
+	router Router
+	all    bool
+	hosts  map[string]bool
+	// wildcards are the domains whose subdomains are routed by the member, e.g. .tenants.example.com
+	wildcards []string
+}
+
+// NewFederation creates a federation trying the members in the given order
+func NewFederation(members ...FederationMember) (*Federation, error) {
+	if len(members) == 0 {
+		return nil, errors.New("federation requires at least one member")
+	}
+	f := &Federation{members: make([]federationMember, len(members))}
+	seen := make(map[string]bool, len(members))
+	for i, m := range members {
+		if m.Name == "" || m.Router == nil {
+			return nil, errors.New("federation member requires a name and a router")
+		}
+		if seen[m.Name] {
+			return nil, fmt.Errorf("duplicate federation member: %s", m.Name)
+		}
+		seen[m.Name] = true
+
+		fm := federationMember{name: m.Name, router: m.Router, all: len(m.Hosts) == 0, hosts: make(map[string]bool)}
+		for _, host := range m.Hosts {
+			host = hostPattern(host)
+			switch {
+			case host == "" || strings.ContainsAny(host, "<*") && !strings.HasPrefix(host, "*."):
+				return nil, fmt.Errorf("bad host of federation member %s: %q", m.Name, host)
+			case strings.HasPrefix(host, "*."):
+				fm.wildcards = append(fm.wildcards, host[1:])
+			default:
+				fm.hosts[host] = true
+			}
+		}
+		f.members[i] = fm
+	}
+	return f, nil
+}
+
+// Member returns the router of the member, nil if there is no member with this name
+func (f *Federation) Member(name string) Router {
+	for _, m := range f.members {
+		if m.name == name {
+			return m.router
+		}
+	}
+	return nil
+}
+
+// Route routes the request with the first member routing its host and matching it.
+// It returns nil if no member matches the request.
+func (f *Federation) Route(req *http.Request) (interface{}, error) {
+	_, val, err := f.RouteMember(req)
+	return val, err
+}
+
+// RouteMember is like Route and also returns the name of the matching member
+func (f *Federation) RouteMember(req *http.Request) (string, interface{}, error) {
+	host := hostname(req.Host)
+	for _, m := range f.members {
+		if !m.routes(host) {
+			continue
+		}
+		val, err := m.router.Route(req)
+		if err != nil {
+			return "", nil, fmt.Errorf("federation member %s: %w", m.name, err)
+		}
+		if val != nil {
+			return m.name, val, nil
+		}
+	}
+	return "", nil, nil
+}
+
+// routes returns true if the member routes the requests of the host
+func (m *federationMember) routes(host string) bool {
+	if m.all || m.hosts[host] {
+		return true
+	}
+	for _, w := range m.wildcards {
+		if len(host) > len(w) && strings.HasSuffix(host, w) {
+			return true
+		}
+	}
+	return false
+}
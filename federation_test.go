@@ -0,0 +1,87 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFederation(t *testing.T) {
+	infra := New()
+	require.NoError(t, infra.AddRoute(`Path("/healthz")`, "health"))
+	require.NoError(t, infra.AddRoute(`Host("api.example.com") && Path("/<path:rest>")`, "api"))
+
+	tenants := New()
+	require.NoError(t, tenants.AddRoute(`Host("<tenant>.tenants.example.com") && Path("/<path:rest>")`, "tenant"))
+	require.NoError(t, tenants.AddRoute(`Path("/healthz")`, "tenant health"))
+
+	fallback := New()
+	require.NoError(t, fallback.AddRoute(`Path("/<path:rest>")`, "fallback"))
+
+	f, err := NewFederation(
+		FederationMember{Name: "infra", Router: infra},
+		FederationMember{Name: "tenants", Router: tenants, Hosts: []string{"*.tenants.example.com"}},
+		FederationMember{Name: "fallback", Router: fallback, Hosts: []string{"www.example.com", "Example.com"}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, tenants, f.Member("tenants"))
+	assert.Nil(t, f.Member("missing"))
+
+	tcs := []struct {
+		host   string
+		path   string
+		member string
+		val    interface{}
+	}{
+		// the members are tried in order
+		{host: "acme.tenants.example.com", path: "/healthz", member: "infra", val: "health"},
+		{host: "api.example.com:443", path: "/users", member: "infra", val: "api"},
+		{host: "acme.tenants.example.com", path: "/users", member: "tenants", val: "tenant"},
+		// members only route their hosts
+		{host: "tenants.example.com", path: "/users"},
+		{host: "www.example.com", path: "/users", member: "fallback", val: "fallback"},
+		{host: "example.com", path: "/home", member: "fallback", val: "fallback"},
+		{host: "other.com", path: "/"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.host+tc.path, func(t *testing.T) {
+			member, val, err := f.RouteMember(makeReq(req{host: tc.host, url: tc.path}))
+			require.NoError(t, err)
+			assert.Equal(t, tc.member, member)
+			assert.Equal(t, tc.val, val)
+
+			val, err = f.Route(makeReq(req{host: tc.host, url: tc.path}))
+			require.NoError(t, err)
+			assert.Equal(t, tc.val, val)
+		})
+	}
+}
+
+func TestFederationErrors(t *testing.T) {
+	_, err := NewFederation()
+	assert.Error(t, err)
+
+	_, err = NewFederation(FederationMember{Name: "infra"})
+	assert.Error(t, err)
+
+	_, err = NewFederation(FederationMember{Name: "infra", Router: New()}, FederationMember{Name: "infra", Router: New()})
+	assert.Error(t, err)
+
+	for _, host := range []string{"", "<tenant>.example.com", "api.*.example.com"} {
+		_, err = NewFederation(FederationMember{Name: "infra", Router: New(), Hosts: []string{host}})
+		assert.Error(t, err, host)
+	}
+}
+
+func TestFederationRequest(t *testing.T) {
+	r := New()
+	require.NoError(t, r.AddRoute(`Method("POST") && Path("/")`, "post"))
+	f, err := NewFederation(FederationMember{Name: "all", Router: r})
+	require.NoError(t, err)
+
+	val, err := f.Route(makeReq(req{url: "/", method: http.MethodPost}))
+	require.NoError(t, err)
+	assert.Equal(t, "post", val)
+}
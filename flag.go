@@ -0,0 +1,56 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// FlagContext is the evaluation context of the feature flags for a request, like the OpenFeature
+// evaluation context: the targeting key identifies the subject of the evaluation, e.g. the user,
+// and the attributes describe it, e.g. its plan or its country.
+type FlagContext struct {
+	TargetingKey string
+	Attributes   map[string]interface{}
+}
+
+// FlagProvider returns the value of the boolean feature flag in the evaluation context, the default value
+// if the flag can't be evaluated. It has the shape of the boolean evaluation of the OpenFeature clients, e.g.
+//
+//	func(ctx context.Context, flag string, defaultValue bool, fc route.FlagContext) bool {
+//		return client.Boolean(ctx, flag, defaultValue, openfeature.NewEvaluationContext(fc.TargetingKey, fc.Attributes))
+//	}
+type FlagProvider func(ctx context.Context, flag string, defaultValue bool, fc FlagContext) bool
+
+// FlagContextFunc returns the evaluation context of the feature flags for the request,
+// e.g. from the user set in the request context by an authentication middleware
+type FlagContextFunc func(r *http.Request) FlagContext
+
+// SetFlagProvider sets the provider and the evaluation context used by the Flag matchers of the mux.
+// By default the flags are off and Flag matchers never match. A nil context function evaluates the flags
+// with the host, the path and the method of the request as attributes and without targeting key.
+func (m *Mux) SetFlagProvider(provider FlagProvider, contextFunc FlagContextFunc) {
+	m.settings.flags = provider
+	m.settings.flagContext = contextFunc
+}
+
+// requestFlagContext is the default evaluation context of the flags
+func requestFlagContext(r *http.Request) FlagContext {
+	return FlagContext{Attributes: map[string]interface{}{
+		"host":   hostname(r.Host),
+		"path":   r.URL.Path,
+		"method": r.Method,
+	}}
+}
+
+// flagMatcher matches requests for which the feature flag is on, as evaluated by the flag provider.
+// Flags that can't be evaluated are off.
+func flagMatcher(name string) (matcher, error) {
+	if name == "" {
+		return nil, errors.New("flag cannot be empty")
+	}
+	return newSettingsMatcher(fmt.Sprintf("Flag(%s)", name), func(req *http.Request, s *settings) bool {
+		return s.flag(req, name)
+	}), nil
+}
@@ -0,0 +1,71 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagMatcher(t *testing.T) {
+	m, err := flagMatcher("new-checkout")
+	require.NoError(t, err)
+
+	r := makeReq(req{url: "/checkout", host: "shop.example.com:443", method: http.MethodPost})
+	assert.Nil(t, m.match(r))
+
+	var evaluated FlagContext
+	s := &settings{flags: func(_ context.Context, flag string, defaultValue bool, fc FlagContext) bool {
+		assert.False(t, defaultValue)
+		evaluated = fc
+		return flag == "new-checkout"
+	}}
+	m.setSettings(s)
+	assert.NotNil(t, m.match(r))
+	assert.Equal(t, FlagContext{Attributes: map[string]interface{}{
+		"host":   "shop.example.com",
+		"path":   "/checkout",
+		"method": http.MethodPost,
+	}}, evaluated)
+
+	m, err = flagMatcher("other")
+	require.NoError(t, err)
+	m.setSettings(s)
+	assert.Nil(t, m.match(r))
+
+	_, err = flagMatcher("")
+	assert.Error(t, err)
+}
+
+type userKey struct{}
+
+func TestFlagRoutes(t *testing.T) {
+	mux := NewMux()
+	require.NoError(t, mux.Handle(`Flag("new-checkout") && Path("/checkout")`, newStatusHandler(http.StatusAccepted)))
+	mux.SetFlagProvider(func(_ context.Context, flag string, defaultValue bool, fc FlagContext) bool {
+		if flag != "new-checkout" {
+			return defaultValue
+		}
+		return fc.TargetingKey == "beta-user"
+	}, func(r *http.Request) FlagContext {
+		user, _ := r.Context().Value(userKey{}).(string)
+		return FlagContext{TargetingKey: user}
+	})
+
+	// the provider is set per mux
+	other := NewMux()
+	require.NoError(t, other.Handle(`Flag("new-checkout") && Path("/checkout")`, newStatusHandler(http.StatusAccepted)))
+
+	serve := func(m *Mux, user string) int {
+		r := makeReq(req{url: "/checkout"})
+		r = r.WithContext(context.WithValue(r.Context(), userKey{}, user))
+		w := newWriter()
+		m.ServeHTTP(w, r)
+		return w.header
+	}
+	assert.Equal(t, http.StatusAccepted, serve(mux, "beta-user"))
+	assert.Equal(t, http.StatusNotFound, serve(mux, "user"))
+	assert.Equal(t, http.StatusNotFound, serve(other, "beta-user"))
+}
@@ -0,0 +1,130 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+)
+
+// Route is a reference to a route registered with HandleRoute, so the route can be updated or removed
+// without keeping its expression around. It refers to the route registered for the key of the expression,
+// it keeps working when the route is replaced by Handle.
+type Route struct {
+	mux *Mux
+	key string
+}
+
+// HandleRoute adds http handler for route expression like Handle and returns a reference to the route
+func (m *Mux) HandleRoute(expr string, handler http.Handler) (*Route, error) {
+	if err := m.Handle(expr, handler); err != nil {
+		return nil, err
+	}
+	return &Route{mux: m, key: m.keyFunc(expr)}, nil
+}
+
+// Expr returns the expression of the route, empty if the route was removed
+func (r *Route) Expr() string {
+	r.mux.mutex.RLock()
+	defer r.mux.mutex.RUnlock()
+
+	if e, ok := r.mux.keys[r.key]; ok {
+		return e.expr
+	}
+	return ""
+}
+
+// entry returns the entry of the route, the caller holds the lock
+func (r *Route) entry() (*entry, error) {
+	e, ok := r.mux.keys[r.key]
+	if !ok {
+		return nil, fmt.Errorf("route %s was removed", r.key)
+	}
+	return e, nil
+}
+
+// Name registers the route under the name, see HandleNamed
+func (r *Route) Name(name string) error {
+	if name == "" {
+		return errors.New("route name cannot be empty: operation rejected")
+	}
+	r.mux.mutex.Lock()
+	defer r.mux.mutex.Unlock()
+
+	if _, err := r.entry(); err != nil {
+		return err
+	}
+	if registered, ok := r.mux.names[name]; ok && registered != r.key {
+		return fmt.Errorf("route name %s is already used by '%s'", name, r.mux.keys[registered].expr)
+	}
+	return r.mux.nameLocked(r.key, name)
+}
+
+// Meta replaces the metadata of the route, see HandleMeta
+func (r *Route) Meta(meta map[string]string) error {
+	return r.reregister(func(reg *registration) {
+		reg.meta = maps.Clone(meta)
+	})
+}
+
+// Priority sets the explicit priority of the route, see HandleWithPriority
+func (r *Route) Priority(priority int) error {
+	return r.reregister(func(reg *registration) {
+		reg.priority = priority
+	})
+}
+
+// reregister replaces the route with a route updated by fn, keeping its handler and its middleware
+func (r *Route) reregister(fn func(reg *registration)) error {
+	r.mux.mutex.Lock()
+	defer r.mux.mutex.Unlock()
+
+	prev, err := r.entry()
+	if err != nil {
+		return err
+	}
+	reg := registration{priority: prev.priority, meta: prev.meta}
+	fn(&reg)
+
+	e := newEntry(prev.expr, prev.handler)
+	e.middleware, e.wrapped = prev.middleware, prev.wrapped
+	e.owner = prev.owner
+	e.register(reg)
+	return r.mux.upsertLocked(r.key, e)
+}
+
+// Disable answers the requests of the route as not found until Enable is called,
+// the route still matches its requests so they are not served by another route
+func (r *Route) Disable() error {
+	return r.setDisabled(true)
+}
+
+// Enable serves the requests of the route disabled by Disable
+func (r *Route) Enable() error {
+	return r.setDisabled(false)
+}
+
+func (r *Route) setDisabled(disabled bool) error {
+	r.mux.mutex.Lock()
+	defer r.mux.mutex.Unlock()
+
+	e, err := r.entry()
+	if err != nil {
+		return err
+	}
+	e.updateOptions(func(o *routeOptions) {
+		o.disabled = disabled
+	})
+	return nil
+}
+
+// Remove removes the route, see Mux.Remove
+func (r *Route) Remove() error {
+	r.mux.mutex.RLock()
+	e, err := r.entry()
+	r.mux.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+	return r.mux.Remove(e.expr)
+}
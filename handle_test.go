@@ -0,0 +1,86 @@
+package route
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleRoute(t *testing.T) {
+	m := NewMux()
+	r, err := m.HandleRoute(`Path("/users/<id>")`, newStatusHandler(http.StatusOK))
+	require.NoError(t, err)
+	assert.Equal(t, `Path("/users/<id>")`, r.Expr())
+
+	require.NoError(t, r.Name("user"))
+	u, err := m.URL("user", "id", "42")
+	require.NoError(t, err)
+	assert.Equal(t, "/users/42", u.String())
+
+	require.NoError(t, r.Meta(map[string]string{"team": "identity"}))
+	require.NoError(t, r.Priority(5))
+	routes := slices.Collect(m.Routes())
+	require.Len(t, routes, 1)
+	assert.Equal(t, map[string]string{"team": "identity"}, routes[0].Meta)
+	assert.Equal(t, 5, routes[0].Priority)
+
+	// the name survives the updates
+	_, err = m.URL("user", "id", "42")
+	require.NoError(t, err)
+
+	require.NoError(t, r.Disable())
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/users/42"}))
+	assert.Equal(t, http.StatusNotFound, w.header)
+
+	require.NoError(t, r.Enable())
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/users/42"}))
+	assert.Equal(t, http.StatusOK, w.header)
+
+	require.NoError(t, r.Remove())
+	assert.Empty(t, r.Expr())
+	assert.Error(t, r.Remove())
+	assert.Error(t, r.Meta(nil))
+	assert.Error(t, r.Disable())
+	assert.Error(t, r.Name("user"))
+}
+
+func TestHandleRouteKeepsMiddleware(t *testing.T) {
+	m := NewMux()
+	calls := 0
+	m.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			next.ServeHTTP(w, r)
+		})
+	})
+	r, err := m.HandleRoute(`Path("/")`, newStatusHandler(http.StatusOK))
+	require.NoError(t, err)
+	require.NoError(t, r.Meta(map[string]string{"team": "platform"}))
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/"}))
+	assert.Equal(t, 1, calls)
+
+	// the reference follows the route replaced by Handle
+	require.NoError(t, m.Handle(`Path("/")`, newStatusHandler(http.StatusAccepted)))
+	require.NoError(t, r.Disable())
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/"}))
+	assert.Equal(t, http.StatusNotFound, w.header)
+}
+
+func TestHandleRouteErrors(t *testing.T) {
+	m := NewMux()
+	_, err := m.HandleRoute(`Path("/"`, newStatusHandler(http.StatusOK))
+	assert.Error(t, err)
+
+	r, err := m.HandleRoute(`Path("/a")`, newStatusHandler(http.StatusOK))
+	require.NoError(t, err)
+	require.NoError(t, m.HandleNamed("taken", `Path("/b")`, newStatusHandler(http.StatusOK)))
+	assert.Error(t, r.Name("taken"))
+	assert.Error(t, r.Name(""))
+}
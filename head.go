@@ -0,0 +1,27 @@
+package route
+
+import (
+	"net/http"
+)
+
+// SetHeadFallback enables HEAD requests with no matching route to be served by the route
+// matching the same request with the GET method. The handler receives the HEAD request
+// and the body it writes is discarded, like the standard library file server does.
+func (m *Mux) SetHeadFallback(enabled bool) {
+	m.headFallback = enabled
+}
+
+// routeHead looks up the GET route for a HEAD request
+func (m *Mux) routeHead(r *http.Request) (interface{}, bool) {
+	if !m.headFallback || r.Method != http.MethodHead {
+		return nil, false
+	}
+	// WithContext makes a shallow copy, matchers do not modify the request
+	get := r.WithContext(r.Context())
+	get.Method = http.MethodGet
+	res := m.router.Load().route(get)
+	if res == nil {
+		return nil, false
+	}
+	return res, true
+}
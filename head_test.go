@@ -0,0 +1,41 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadFallback(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleFunc(`Method("GET") && Path("/file")`, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("content"))
+	}))
+	require.NoError(t, m.Handle(`Method("HEAD") && Path("/explicit")`, newStatusHandler(http.StatusNoContent)))
+	require.NoError(t, m.Handle(`Method("GET") && Path("/explicit")`, newStatusHandler(http.StatusOK)))
+
+	head := func(url string) *testWriter {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: url, method: http.MethodHead}))
+		return w
+	}
+
+	assert.Equal(t, http.StatusNotFound, head("/file").header)
+
+	m.SetHeadFallback(true)
+	w := head("/file")
+	assert.Equal(t, http.StatusOK, w.header)
+	assert.Equal(t, http.MethodHead, w.Header().Get("X-Method"))
+	assert.Empty(t, w.buf.String())
+
+	// explicit HEAD routes take precedence
+	assert.Equal(t, http.StatusNoContent, head("/explicit").header)
+
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/file", method: http.MethodPost}))
+	assert.Equal(t, http.StatusNotFound, w.header)
+}
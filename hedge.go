@@ -0,0 +1,122 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// HedgeOptions configures the Hedge handler
+type HedgeOptions struct {
+	// Delay is the time to wait for an attempt before sending the next one to another upstream
+	Delay time.Duration
+	// MaxAttempts is the maximum number of attempts per request, 2 by default
+	MaxAttempts int
+}
+
+// HedgeHandler is a http.Handler sending hedged requests to upstreams
+type HedgeHandler struct {
+	upstreams   []http.Handler
+	delay       time.Duration
+	maxAttempts int
+	next        atomic.Uint64
+}
+
+// Hedge returns a handler sending the requests to an upstream and, if the upstream did not respond
+// after the delay, sending another attempt to the next upstream. The first response is used and the
+// context of the other attempts is canceled. An attempt whose upstream panics fails and the next attempt
+// is sent right away, the request is answered with 502 Bad Gateway if all the attempts fail. Only GET, HEAD and OPTIONS requests without body are hedged,
+// the other requests are sent to a single upstream. Responses are buffered, so it is meant for read-heavy routes.
+func Hedge(upstreams []http.Handler, options HedgeOptions) (*HedgeHandler, error) {
+	if len(upstreams) == 0 {
+		return nil, errors.New("at least one upstream is required")
+	}
+	for _, h := range upstreams {
+		if h == nil {
+			return nil, errors.New("upstream cannot be nil")
+		}
+	}
+	if options.Delay <= 0 {
+		return nil, errors.New("delay should be positive")
+	}
+	if options.MaxAttempts < 0 {
+		return nil, errors.New("max attempts cannot be negative")
+	}
+	if options.MaxAttempts == 0 {
+		options.MaxAttempts = 2
+	}
+	return &HedgeHandler{
+		upstreams:   upstreams,
+		delay:       options.Delay,
+		maxAttempts: min(options.MaxAttempts, len(upstreams)),
+	}, nil
+}
+
+func (h *HedgeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	first := int(h.next.Add(1) % uint64(len(h.upstreams)))
+	if !hedgeable(r) || h.maxAttempts == 1 {
+		h.upstreams[first].ServeHTTP(w, r)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// done receives the responses of the attempts, nil for the attempts that panicked
+	done := make(chan *recorder, h.maxAttempts)
+	attempt := func(i int) {
+		var rec *recorder
+		defer func() {
+			// a panic fails the attempt, it is not propagated as the attempt runs in its own goroutine
+			_ = recover()
+			done <- rec
+		}()
+		upstream := h.upstreams[(first+i)%len(h.upstreams)]
+		res := &recorder{header: make(http.Header)}
+		upstream.ServeHTTP(res, r.Clone(ctx))
+		rec = res
+	}
+
+	clock := clockFor(r)
+	delay := clock.After(h.delay)
+
+	go attempt(0)
+	for sent, failed := 1, 0; ; {
+		select {
+		case rec := <-done:
+			if rec != nil {
+				rec.replay(w)
+				return
+			}
+			// the next attempt is sent without waiting for the delay, the request fails once all attempts failed
+			failed++
+			if sent < h.maxAttempts {
+				go attempt(sent)
+				sent++
+				delay = clock.After(h.delay)
+			} else if failed == sent {
+				http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+				return
+			}
+		case <-delay:
+			if sent < h.maxAttempts {
+				go attempt(sent)
+				sent++
+				delay = clock.After(h.delay)
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// hedgeable returns true if the request can be sent several times
+func hedgeable(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return r.Body == nil || r.Body == http.NoBody
+	}
+	return false
+}
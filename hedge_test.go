@@ -0,0 +1,90 @@
+package route
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedge(t *testing.T) {
+	var canceled atomic.Bool
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			canceled.Store(true)
+		case <-time.After(5 * time.Second):
+		}
+		w.WriteHeader(http.StatusGatewayTimeout)
+	})
+	fast := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Upstream", "fast")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("fast"))
+	})
+
+	h, err := Hedge([]http.Handler{slow, fast}, HedgeOptions{Delay: 10 * time.Millisecond})
+	require.NoError(t, err)
+	// the next request starts with the slow upstream
+	h.next.Store(1)
+
+	w := newWriter()
+	h.ServeHTTP(w, makeReq(req{url: "/", method: http.MethodGet}))
+	assert.Equal(t, http.StatusOK, w.header)
+	assert.Equal(t, "fast", w.Header().Get("X-Upstream"))
+	assert.Equal(t, "fast", w.buf.String())
+	assert.Eventually(t, canceled.Load, time.Second, time.Millisecond)
+
+	// non idempotent requests are not hedged
+	var calls atomic.Int32
+	counting := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusCreated)
+	})
+	h, err = Hedge([]http.Handler{counting, counting}, HedgeOptions{Delay: time.Nanosecond})
+	require.NoError(t, err)
+	w = newWriter()
+	h.ServeHTTP(w, makeReq(req{url: "/", method: http.MethodPost}))
+	assert.Equal(t, http.StatusCreated, w.header)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestHedgePanic(t *testing.T) {
+	panicking := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("upstream bug")
+	})
+	fast := newStatusHandler(http.StatusOK)
+
+	// the panicking attempt fails and the next one is sent without waiting for the delay
+	h, err := Hedge([]http.Handler{panicking, fast}, HedgeOptions{Delay: time.Hour})
+	require.NoError(t, err)
+	h.next.Store(1)
+
+	w := newWriter()
+	h.ServeHTTP(w, makeReq(req{url: "/", method: http.MethodGet}))
+	assert.Equal(t, http.StatusOK, w.header)
+
+	// the request fails once all the attempts failed
+	h, err = Hedge([]http.Handler{panicking, panicking}, HedgeOptions{Delay: time.Hour})
+	require.NoError(t, err)
+
+	w = newWriter()
+	h.ServeHTTP(w, makeReq(req{url: "/", method: http.MethodGet}))
+	assert.Equal(t, http.StatusBadGateway, w.header)
+}
+
+func TestHedgeErrors(t *testing.T) {
+	up := newStatusHandler(http.StatusOK)
+
+	_, err := Hedge(nil, HedgeOptions{Delay: time.Second})
+	assert.Error(t, err)
+	_, err = Hedge([]http.Handler{nil}, HedgeOptions{Delay: time.Second})
+	assert.Error(t, err)
+	_, err = Hedge([]http.Handler{up}, HedgeOptions{})
+	assert.Error(t, err)
+	_, err = Hedge([]http.Handler{up}, HedgeOptions{Delay: time.Second, MaxAttempts: -1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+)
+
+// SetEarlyHints sets the Link header values sent in a 103 Early Hints response before calling the handler
+// of the route registered for the expression, e.g. `</style.css>; rel=preload; as=style`, so clients can
+// preload resources while the handler prepares the response. Calling it without links disables the hints.
+func (m *Mux) SetEarlyHints(expr string, links ...string) error {
+	for _, link := range links {
+		if link == "" {
+			return errors.New("early hint link cannot be empty: operation rejected")
+		}
+	}
+	return m.setOptions(expr, func(o *routeOptions) {
+		o.earlyHints = links
+	})
+}
+
+// sendEarlyHints sends the 103 Early Hints response,
+// informational responses are not defined before HTTP/1.1
+func sendEarlyHints(w http.ResponseWriter, r *http.Request, links []string) {
+	if !r.ProtoAtLeast(1, 1) {
+		return
+	}
+	header := w.Header()
+	for _, link := range links {
+		header.Add("Link", link)
+	}
+	w.WriteHeader(http.StatusEarlyHints)
+}
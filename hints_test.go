@@ -0,0 +1,46 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusesWriter records all the status codes written, including informational ones
+type statusesWriter struct {
+	*testWriter
+	statuses []int
+}
+
+func (w *statusesWriter) WriteHeader(status int) {
+	w.statuses = append(w.statuses, status)
+	w.testWriter.WriteHeader(status)
+}
+
+func TestSetEarlyHints(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/page")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.SetEarlyHints(`Path("/page")`, "</style.css>; rel=preload; as=style", "</app.js>; rel=preload; as=script"))
+
+	serve := func(major, minor int) *statusesWriter {
+		w := &statusesWriter{testWriter: newWriter()}
+		r := makeReq(req{url: "/page"})
+		r.ProtoMajor, r.ProtoMinor = major, minor
+		m.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(1, 1)
+	assert.Equal(t, []int{http.StatusEarlyHints, http.StatusOK}, w.statuses)
+	assert.Equal(t, []string{"</style.css>; rel=preload; as=style", "</app.js>; rel=preload; as=script"}, w.Header().Values("Link"))
+
+	assert.Equal(t, []int{http.StatusOK}, serve(1, 0).statuses)
+
+	require.NoError(t, m.SetEarlyHints(`Path("/page")`))
+	assert.Equal(t, []int{http.StatusOK}, serve(2, 0).statuses)
+
+	assert.Error(t, m.SetEarlyHints(`Path("/page")`, ""))
+	assert.Error(t, m.SetEarlyHints(`Path("/other")`, "</a.js>; rel=preload"))
+}
@@ -0,0 +1,179 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// minIndexedHosts is the number of distinct hosts from which consecutive routes with a literal Host matcher
+// are dispatched by a host index instead of being matched one after the other
+const minIndexedHosts = 32
+
+// hostIndex dispatches the requests to the matchers of the routes with a literal or wildcard Host matcher
+// using a trie of the host labels in reverse order, e.g. com, example, api for api.example.com,
+// so a request is only matched against the routes of its host and of the wildcards covering it
+// whatever the number of hosts. The routes keep their order, so the index matches like the matchers it replaces.
+type hostIndex struct {
+	root  hostNode
+	hosts int
+	// seq is the position of the next added matcher
+	seq int
+	// lastWildcard is the position of the last added wildcard matcher, -1 if there is none
+	lastWildcard int
+}
+
+type hostNode struct {
+	children map[string]*hostNode
+	// matchers are the matchers of the routes of the host ending at this node
+	matchers []indexedMatcher
+	// wildcards are the matchers of the routes of the subdomains of the host ending at this node
+	wildcards []indexedMatcher
+}
+
+// indexedMatcher is a matcher with its position in the routes of the index
+type indexedMatcher struct {
+	seq int
+	matcher
+}
+
+func newHostIndex() *hostIndex {
+	return &hostIndex{lastWildcard: -1}
+}
+
+// literalHost returns the host of the Host matcher of the expression if it has no pattern,
+// wildcard hosts like *.example.com are returned as is
+func literalHost(expr string) (string, bool) {
+	ts, err := terms(expr)
+	if err != nil {
+		return "", false
+	}
+	host := termArg(ts, "Host")
+	if host == "" || strings.ContainsRune(host, '<') {
+		return "", false
+	}
+	return hostPattern(host), true
+}
+
+// add adds the matcher of a route of the host, merging it with the previous matcher of the host when possible
+func (x *hostIndex) add(host string, m matcher) error {
+	seq := x.seq
+	x.seq++
+
+	wildcard := strings.HasPrefix(host, "*.")
+	if wildcard {
+		host = host[2:]
+	}
+
+	n := &x.root
+	for rest := host; ; {
+		i := strings.LastIndexByte(rest, domainSep)
+		label := rest[i+1:]
+		child, ok := n.children[label]
+		if !ok {
+			if n.children == nil {
+				n.children = make(map[string]*hostNode)
+			}
+			child = &hostNode{}
+			n.children[label] = child
+		}
+		n = child
+		if i == -1 {
+			break
+		}
+		rest = rest[:i]
+	}
+
+	matchers := &n.matchers
+	if wildcard {
+		matchers = &n.wildcards
+	}
+	if len(*matchers) == 0 {
+		x.hosts++
+	}
+	// wildcards match the requests of other hosts, so matchers added after a wildcard must not be merged
+	// into matchers added before it, wildcard matchers are func matchers so they never merge
+	if last := len(*matchers) - 1; last >= 0 && x.lastWildcard < (*matchers)[last].seq && (*matchers)[last].canMerge(m) {
+		merged, err := (*matchers)[last].merge(m)
+		if err != nil {
+			return err
+		}
+		(*matchers)[last].matcher = merged
+		return nil
+	}
+	*matchers = append(*matchers, indexedMatcher{seq: seq, matcher: m})
+	if wildcard {
+		x.lastWildcard = seq
+	}
+	return nil
+}
+
+func (x *hostIndex) match(req *http.Request) *match {
+	// the matchers of the host and of the wildcards covering it, at most one list per label
+	var buf [4][]indexedMatcher
+	candidates := buf[:0]
+
+	n := &x.root
+	for rest := hostname(req.Host); ; {
+		i := strings.LastIndexByte(rest, domainSep)
+		if n = n.children[rest[i+1:]]; n == nil {
+			break
+		}
+		if i == -1 {
+			candidates = append(candidates, n.matchers)
+			break
+		}
+		if len(n.wildcards) != 0 {
+			candidates = append(candidates, n.wildcards)
+		}
+		rest = rest[:i]
+	}
+
+	// try the candidates in route order
+	for {
+		next := -1
+		for c := range candidates {
+			if len(candidates[c]) != 0 && (next == -1 || candidates[c][0].seq < candidates[next][0].seq) {
+				next = c
+			}
+		}
+		if next == -1 {
+			return nil
+		}
+		if l := candidates[next][0].match(req); l != nil {
+			return l
+		}
+		candidates[next] = candidates[next][1:]
+	}
+}
+
+func (x *hostIndex) String() string {
+	return fmt.Sprintf("hostIndex(%d hosts)", x.hosts)
+}
+
+// setMatch is not supported, the indexed matchers hold the results of their routes
+func (x *hostIndex) setMatch(*match) {}
+
+func (x *hostIndex) setSettings(*settings) {}
+
+// clone is not supported, the index is built by the router from copies of the matchers and never cached
+func (x *hostIndex) clone() matcher {
+	return x
+}
+
+func (x *hostIndex) canMerge(matcher) bool {
+	return false
+}
+
+func (x *hostIndex) merge(matcher) (matcher, error) {
+	return nil, errors.New("method not supported")
+}
+
+func (x *hostIndex) canChain(matcher) bool {
+	return false
+}
+
+func (x *hostIndex) chain(matcher) (matcher, error) {
+	return nil, fmt.Errorf("not supported")
+}
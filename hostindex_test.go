@@ -0,0 +1,139 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostIndex(t *testing.T) {
+	routes := map[string]interface{}{
+		`Path("/healthz")`: "health",
+		`Host("tenant7.example.com") && Method("GET") && Path("/special")`: "special",
+		`PathRegexp("/static/.*")`: "static",
+	}
+	for i := 0; i < 2*minIndexedHosts; i++ {
+		routes[fmt.Sprintf(`Host("tenant%d.example.com") && PathRegexp("/api/.*")`, i)] = fmt.Sprintf("api%d", i)
+		routes[fmt.Sprintf(`Host("tenant%d.example.com") && Method("POST") && Path("/upload")`, i)] = fmt.Sprintf("upload%d", i)
+	}
+	routes[`Host("[::1]") && Path("/local")`] = "local"
+
+	r := New().(*router)
+	require.NoError(t, r.InitRoutes(routes))
+
+	var indexes int
+	for _, m := range r.matchers {
+		if _, ok := m.(*hostIndex); ok {
+			indexes++
+		}
+	}
+	assert.Equal(t, 1, indexes)
+
+	testCases := []struct {
+		host     string
+		method   string
+		path     string
+		expected interface{}
+	}{
+		{host: "tenant3.example.com", path: "/api/users", expected: "api3"},
+		{host: "Tenant42.Example.com:8080", path: "/api/users", expected: "api42"},
+		{host: "tenant3.example.com", method: http.MethodPost, path: "/upload", expected: "upload3"},
+		{host: "tenant3.example.com", path: "/upload", expected: nil},
+		{host: "tenant7.example.com", method: http.MethodGet, path: "/special", expected: "special"},
+		{host: "tenant3.example.com", method: http.MethodGet, path: "/special", expected: nil},
+		{host: "unknown.example.com", path: "/api/users", expected: nil},
+		{host: "example.com", path: "/api/users", expected: nil},
+		{host: "a.tenant3.example.com", path: "/api/users", expected: nil},
+		{host: "[::1]:8080", path: "/local", expected: "local"},
+		{host: "tenant3.example.com", path: "/healthz", expected: "health"},
+		{host: "tenant3.example.com", path: "/static/app.js", expected: "static"},
+	}
+	for _, test := range testCases {
+		rq := makeReq(req{url: test.path, host: test.host, method: test.method})
+		out, err := r.Route(rq)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out, "%s %s", test.host, test.path)
+	}
+}
+
+func TestHostIndexWildcards(t *testing.T) {
+	routes := map[string]interface{}{
+		`Host("*.example.com") && Path("/wild")`:    "wild",
+		`Host("*.eu.example.com") && Path("/wild")`: "eu-wild",
+		`Host("*.example.com") && Path("/any")`:     "any",
+		`Host("*.example.org") && Path("/own")`:     "org",
+	}
+	for i := 0; i < minIndexedHosts; i++ {
+		routes[fmt.Sprintf(`Host("tenant%d.example.com") && Path("/own")`, i)] = fmt.Sprintf("own%d", i)
+		routes[fmt.Sprintf(`Host("tenant%d.example.com") && PathRegexp("/wild")`, i)] = fmt.Sprintf("tenant-wild%d", i)
+	}
+
+	r := New().(*router)
+	require.NoError(t, r.InitRoutes(routes))
+
+	var indexes int
+	for _, m := range r.matchers {
+		if _, ok := m.(*hostIndex); ok {
+			indexes++
+		}
+	}
+	assert.Equal(t, 1, indexes)
+
+	// the expressions are tried in reverse order, see router.compile
+	testCases := []struct {
+		host     string
+		path     string
+		expected interface{}
+	}{
+		{host: "tenant3.example.com", path: "/own", expected: "own3"},
+		{host: "tenant3.example.com", path: "/wild", expected: "tenant-wild3"},
+		{host: "tenant3.example.com", path: "/any", expected: "any"},
+		{host: "other.example.com", path: "/wild", expected: "wild"},
+		{host: "a.b.example.com", path: "/wild", expected: "wild"},
+		{host: "fr.eu.example.com", path: "/wild", expected: "wild"},
+		{host: "fr.eu.example.com", path: "/any", expected: "any"},
+		{host: "a.example.org", path: "/own", expected: "org"},
+		{host: "example.com", path: "/wild", expected: nil},
+		{host: "other.example.net", path: "/wild", expected: nil},
+	}
+	for _, test := range testCases {
+		out, err := r.Route(makeReq(req{url: test.path, host: test.host}))
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out, "%s %s", test.host, test.path)
+	}
+}
+
+func TestHostIndexSmallTables(t *testing.T) {
+	r := New().(*router)
+	require.NoError(t, r.AddRoute(`Host("a") && PathRegexp("/.*")`, "a"))
+	require.NoError(t, r.AddRoute(`Host("b") && PathRegexp("/.*")`, "b"))
+
+	for _, m := range r.matchers {
+		_, ok := m.(*hostIndex)
+		assert.False(t, ok)
+	}
+}
+
+func BenchmarkRouteManyHosts(b *testing.B) {
+	for _, size := range []int{1000, 20000} {
+		b.Run(fmt.Sprintf("%d hosts", size), func(b *testing.B) {
+			routes := make(map[string]interface{}, size)
+			for i := 0; i < size; i++ {
+				routes[fmt.Sprintf(`Host("tenant%d.example.com") && PathRegexp("/api/.*")`, i)] = i
+			}
+			r := New()
+			require.NoError(b, r.InitRoutes(routes))
+
+			rq := makeReq(req{url: "/api/users", host: fmt.Sprintf("tenant%d.example.com", size/2)})
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if v, _ := r.Route(rq); v != size/2 {
+					b.Fatalf("expected %d, got %v", size/2, v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// prefixSet is a compiled set of IP prefixes: the prefixes are merged into sorted disjoint ranges,
+// so an address is looked up with a binary search whatever the number of prefixes
+type prefixSet struct {
+	ranges []ipRange
+}
+
+// ipRange is an inclusive range of addresses of the same family
+type ipRange struct {
+	from netip.Addr
+	to   netip.Addr
+}
+
+// newPrefixSet compiles the prefixes, e.g. 10.0.0.0/8 or 2001:db8::/32, addresses are single address prefixes
+func newPrefixSet(prefixes ...string) (*prefixSet, error) {
+	ranges := make([]ipRange, 0, len(prefixes))
+	for _, s := range prefixes {
+		p, err := parsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, ipRange{from: p.Addr(), to: lastAddr(p)})
+	}
+	slices.SortFunc(ranges, func(a, b ipRange) int {
+		return a.from.Compare(b.from)
+	})
+
+	merged := ranges[:0]
+	for _, r := range ranges {
+		if n := len(merged); n != 0 && adjacent(merged[n-1], r) {
+			if r.to.Compare(merged[n-1].to) > 0 {
+				merged[n-1].to = r.to
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return &prefixSet{ranges: slices.Clip(merged)}, nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("bad IP address: %s %w", s, err)
+		}
+		addr = addr.Unmap().WithZone("")
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("bad CIDR: %s %w", s, err)
+	}
+	if p.Addr().Is4In6() {
+		return netip.Prefix{}, fmt.Errorf("bad CIDR: %s IPv4-mapped prefixes are not supported, use the IPv4 prefix", s)
+	}
+	return p.Masked(), nil
+}
+
+// lastAddr returns the last address of the prefix
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Addr().AsSlice()
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// adjacent returns true if the range b, starting after a, overlaps or follows a
+func adjacent(a, b ipRange) bool {
+	if a.to.BitLen() != b.from.BitLen() {
+		return false
+	}
+	next := a.to.Next()
+	return !next.IsValid() || b.from.Compare(next) <= 0
+}
+
+// contains returns true if the address is in one of the prefixes
+func (s *prefixSet) contains(addr netip.Addr) bool {
+	addr = addr.Unmap().WithZone("")
+	i := sort.Search(len(s.ranges), func(i int) bool {
+		return s.ranges[i].from.Compare(addr) > 0
+	})
+	return i > 0 && s.ranges[i-1].to.Compare(addr) >= 0
+}
+
+// clientIPMatcher matches requests whose client address, the address of the remote end of the connection,
+// is in one of the prefixes, e.g. ClientIP("10.0.0.0/8", "2001:db8::/32", "192.0.2.1").
+// Addresses forwarded by proxies in headers are not considered.
+func clientIPMatcher(prefixes ...string) (matcher, error) {
+	if len(prefixes) == 0 {
+		return nil, errors.New("at least one prefix is required")
+	}
+	set, err := newPrefixSet(prefixes...)
+	if err != nil {
+		return nil, err
+	}
+	// large prefix lists, e.g. cloud provider ranges, are summarized in the debugging name
+	name := fmt.Sprintf("ClientIP(%s)", strings.Join(prefixes, ", "))
+	if len(prefixes) > 3 {
+		name = fmt.Sprintf("ClientIP(%s, ...)", strings.Join(prefixes[:3], ", "))
+	}
+	return newFuncMatcher(name, func(req *http.Request) bool {
+		addr, ok := remoteAddr(req)
+		return ok && set.contains(addr)
+	}), nil
+}
+
+// remoteAddr parses the address of the remote end of the connection
+func remoteAddr(req *http.Request) (netip.Addr, bool) {
+	host := req.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr, true
+}
@@ -0,0 +1,94 @@
+package route
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixSet(t *testing.T) {
+	set, err := newPrefixSet("10.0.0.0/8", "10.1.0.0/16", "192.168.1.1", "192.168.1.2", "2001:db8::/32", "::ffff:172.16.0.1")
+	require.NoError(t, err)
+	// nested and adjacent prefixes are merged
+	assert.Len(t, set.ranges, 4)
+
+	testCases := []struct {
+		addr     string
+		expected bool
+	}{
+		{addr: "10.0.0.0", expected: true},
+		{addr: "10.255.255.255", expected: true},
+		{addr: "11.0.0.0", expected: false},
+		{addr: "9.255.255.255", expected: false},
+		{addr: "192.168.1.1", expected: true},
+		{addr: "192.168.1.2", expected: true},
+		{addr: "192.168.1.3", expected: false},
+		{addr: "172.16.0.1", expected: true},
+		{addr: "::ffff:10.1.2.3", expected: true},
+		{addr: "2001:db8::1", expected: true},
+		{addr: "2001:db8:ffff:ffff::1", expected: true},
+		{addr: "2001:db9::", expected: false},
+		{addr: "fe80::1%eth0", expected: false},
+		{addr: "::", expected: false},
+	}
+	for _, test := range testCases {
+		assert.Equal(t, test.expected, set.contains(netip.MustParseAddr(test.addr)), test.addr)
+	}
+
+	all, err := newPrefixSet("0.0.0.0/0", "1.2.3.4", "::/0")
+	require.NoError(t, err)
+	assert.Len(t, all.ranges, 2)
+	assert.True(t, all.contains(netip.MustParseAddr("255.255.255.255")))
+	assert.True(t, all.contains(netip.MustParseAddr("ffff::1")))
+
+	for _, bad := range []string{"10.0.0.0/33", "not an ip", "::ffff:10.0.0.0/104"} {
+		_, err := newPrefixSet(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestClientIPMatcher(t *testing.T) {
+	m, err := clientIPMatcher("10.0.0.0/8", "2001:db8::/32")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		remoteAddr string
+		expected   bool
+	}{
+		{remoteAddr: "10.1.2.3:4567", expected: true},
+		{remoteAddr: "10.1.2.3", expected: true},
+		{remoteAddr: "[2001:db8::1]:443", expected: true},
+		{remoteAddr: "[2001:db8::1%eth0]:443", expected: true},
+		{remoteAddr: "192.0.2.1:4567", expected: false},
+		{remoteAddr: "", expected: false},
+		{remoteAddr: "pipe", expected: false},
+	}
+	for _, test := range testCases {
+		r := makeReq(req{url: "/"})
+		r.RemoteAddr = test.remoteAddr
+		assert.Equal(t, test.expected, m.match(r) != nil, test.remoteAddr)
+	}
+
+	_, err = clientIPMatcher()
+	assert.Error(t, err)
+	assert.True(t, IsValid(`ClientIP("10.0.0.0/8") && Path("/admin")`))
+	assert.False(t, IsValid(`ClientIP("10.0.0.0/abc")`))
+}
+
+func BenchmarkPrefixSet(b *testing.B) {
+	prefixes := make([]string, 0, 4096)
+	for i := 0; i < 4096; i++ {
+		prefixes = append(prefixes, fmt.Sprintf("%d.%d.%d.0/24", 10+i/65536, (i/256)%256, i%256))
+	}
+	set, err := newPrefixSet(prefixes...)
+	require.NoError(b, err)
+	addr := netip.MustParseAddr("10.15.200.7")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		set.contains(addr)
+	}
+}
@@ -0,0 +1,71 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// limitMatcher matches requests whose structural property, as measured by size, is over the limit,
+// e.g. to route abusive requests to a rejection handler
+func limitMatcher(name string, limit int, size func(*http.Request) int) (matcher, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("%s limit should be positive, got: %d", name, limit)
+	}
+	return newFuncMatcher(fmt.Sprintf("%s(%d)", name, limit), func(req *http.Request) bool {
+		return size(req) > limit
+	}), nil
+}
+
+// queryParamsOverMatcher matches requests with more than limit query parameters, repeated parameters
+// are counted once per value. The query is not parsed, so malformed queries are counted too.
+func queryParamsOverMatcher(limit int) (matcher, error) {
+	return limitMatcher("QueryParamsOver", limit, queryParamCount)
+}
+
+// urlLengthOverMatcher matches requests whose request URI is longer than limit bytes
+func urlLengthOverMatcher(limit int) (matcher, error) {
+	return limitMatcher("URLLengthOver", limit, func(req *http.Request) int {
+		if req.RequestURI != "" {
+			return len(req.RequestURI)
+		}
+		return len(req.URL.RequestURI())
+	})
+}
+
+// headerCountOverMatcher matches requests with more than limit header fields, a header is counted once per value
+func headerCountOverMatcher(limit int) (matcher, error) {
+	return limitMatcher("HeaderCountOver", limit, func(req *http.Request) int {
+		count := 0
+		for _, values := range req.Header {
+			count += len(values)
+		}
+		return count
+	})
+}
+
+// headerBytesOverMatcher matches requests whose header fields, names and values, are larger than limit bytes
+func headerBytesOverMatcher(limit int) (matcher, error) {
+	return limitMatcher("HeaderBytesOver", limit, func(req *http.Request) int {
+		size := 0
+		for name, values := range req.Header {
+			for _, value := range values {
+				size += len(name) + len(value)
+			}
+		}
+		return size
+	})
+}
+
+func queryParamCount(req *http.Request) int {
+	query := req.URL.RawQuery
+	count := 0
+	for query != "" {
+		var param string
+		param, query, _ = strings.Cut(query, "&")
+		if param != "" {
+			count++
+		}
+	}
+	return count
+}
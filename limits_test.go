@@ -0,0 +1,58 @@
+package route
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitMatchers(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		matcher  func(int) (matcher, error)
+		limit    int
+		url      string
+		headers  http.Header
+		expected bool
+	}{
+		{desc: "query params under", matcher: queryParamsOverMatcher, limit: 2, url: "/?a=1&b=2", expected: false},
+		{desc: "query params over", matcher: queryParamsOverMatcher, limit: 2, url: "/?a=1&b=2&a=3", expected: true},
+		{desc: "empty query params ignored", matcher: queryParamsOverMatcher, limit: 2, url: "/?a=1&&&b=2&", expected: false},
+		{desc: "no query", matcher: queryParamsOverMatcher, limit: 0, url: "/", expected: false},
+		{desc: "url under", matcher: urlLengthOverMatcher, limit: 10, url: "/abc?d=e", expected: false},
+		{desc: "url over", matcher: urlLengthOverMatcher, limit: 10, url: "/" + strings.Repeat("a", 10), expected: true},
+		{desc: "header count under", matcher: headerCountOverMatcher, limit: 2, headers: http.Header{"A": {"1"}, "B": {"2"}}, expected: false},
+		{desc: "header count over", matcher: headerCountOverMatcher, limit: 2, headers: http.Header{"A": {"1", "2"}, "B": {"3"}}, expected: true},
+		{desc: "header bytes under", matcher: headerBytesOverMatcher, limit: 4, headers: http.Header{"A": {"123"}}, expected: false},
+		{desc: "header bytes over", matcher: headerBytesOverMatcher, limit: 4, headers: http.Header{"A": {"1234"}}, expected: true},
+	}
+	for _, test := range testCases {
+		m, err := test.matcher(test.limit)
+		require.NoError(t, err, test.desc)
+		if test.url == "" {
+			test.url = "/"
+		}
+		r := makeReq(req{url: test.url, headers: test.headers})
+		assert.Equal(t, test.expected, m.match(r) != nil, test.desc)
+	}
+
+	_, err := queryParamsOverMatcher(-1)
+	assert.Error(t, err)
+}
+
+func TestLimitRejection(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/search")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`QueryParamsOver(3)`, newStatusHandler(http.StatusBadRequest)))
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/search?a=1&b=2&c=3&d=4"}))
+	assert.Equal(t, http.StatusBadRequest, w.header)
+
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/search?a=1"}))
+	assert.Equal(t, http.StatusOK, w.header)
+}
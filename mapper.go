@@ -2,6 +2,8 @@ package route
 
 import (
 	"net/http"
+	"net/netip"
+	"net/url"
 	"strings"
 )
 
@@ -9,6 +11,7 @@ const (
 	pathSep   = '/'
 	domainSep = '.'
 	headerSep = '/'
+	querySep  = '/'
 	methodSep = ' '
 )
 
@@ -84,13 +87,46 @@ func (h *hostMapper) separator() byte {
 }
 
 func (h *hostMapper) mapRequest(r *http.Request) string {
-	return strings.Split(strings.ToLower(r.Host), ":")[0]
+	return hostname(r.Host)
+}
+
+// hostname returns the lower case host of the Host header without the port. IPv6 literals are returned
+// without brackets in their canonical form with their zone, e.g. [FE80:0::1%25eth0]:8080 is fe80::1%eth0
+func hostname(host string) string {
+	host = strings.ToLower(host)
+	if strings.HasPrefix(host, "[") {
+		end := strings.IndexByte(host, ']')
+		if end == -1 {
+			return host
+		}
+		// zones are percent-encoded in the Host header, see RFC 6874
+		host = strings.Replace(host[1:end], "%25", "%", 1)
+	} else if strings.Count(host, ":") == 1 {
+		host, _, _ = strings.Cut(host, ":")
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && addr.Is6() {
+		return addr.String()
+	}
+	return host
+}
+
+// hostPattern normalizes the IPv6 literals of Host patterns like the hosts of the requests, see hostname
+func hostPattern(pattern string) string {
+	if _, err := netip.ParseAddr(strings.Trim(pattern, "[]")); err == nil {
+		return hostname(pattern)
+	}
+	return strings.ToLower(pattern)
 }
 
 func (h *hostMapper) newIter(r *http.Request) *charIter {
 	return newIter([]string{h.mapRequest(r)}, []byte{h.separator()})
 }
 
+// valuesMapper maps the request to several strings, e.g. to the values of a multi-valued header
+type valuesMapper interface {
+	mapValues(r *http.Request) []string
+}
+
 type headerMapper struct {
 	header string
 }
@@ -111,10 +147,72 @@ func (h *headerMapper) mapRequest(r *http.Request) string {
 	return r.Header.Get(h.header)
 }
 
+// mapValues returns all the values of the header, an empty value if the header is missing
+func (h *headerMapper) mapValues(r *http.Request) []string {
+	if values := r.Header.Values(h.header); len(values) != 0 {
+		return values
+	}
+	return []string{""}
+}
+
 func (h *headerMapper) newIter(r *http.Request) *charIter {
 	return newIter([]string{h.mapRequest(r)}, []byte{h.separator()})
 }
 
+// queryMapper maps the request to the values of a query parameter
+type queryMapper struct {
+	param string
+}
+
+func (q *queryMapper) equivalent(o requestMapper) requestMapper {
+	qm, ok := o.(*queryMapper)
+	if ok && qm.param == q.param {
+		return q
+	}
+	return nil
+}
+
+func (q *queryMapper) separator() byte {
+	return querySep
+}
+
+func (q *queryMapper) mapRequest(r *http.Request) string {
+	if values := queryValues(r.URL.RawQuery, q.param); len(values) != 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// mapValues returns all the values of the query parameter, an empty value if the parameter is missing
+func (q *queryMapper) mapValues(r *http.Request) []string {
+	if values := queryValues(r.URL.RawQuery, q.param); len(values) != 0 {
+		return values
+	}
+	return []string{""}
+}
+
+func (q *queryMapper) newIter(r *http.Request) *charIter {
+	return newIter([]string{q.mapRequest(r)}, []byte{q.separator()})
+}
+
+// queryValues returns the unescaped values of the query parameter without parsing the whole query,
+// malformed parameters are skipped like url.ParseQuery does
+func queryValues(rawQuery, name string) []string {
+	var values []string
+	for rawQuery != "" {
+		var param string
+		param, rawQuery, _ = strings.Cut(rawQuery, "&")
+		key, value, _ := strings.Cut(param, "=")
+		if key, err := url.QueryUnescape(key); err != nil || key != name {
+			continue
+		}
+		if value, err := url.QueryUnescape(value); err == nil {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 type seqMapper struct {
 	seq []requestMapper
 }
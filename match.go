@@ -0,0 +1,48 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrNoMatch is returned by Match when no route matches the request
+var ErrNoMatch = errors.New("no route matches the request")
+
+// MatchResult describes the route the Mux would serve a request with
+type MatchResult struct {
+	// Expr is the expression the route was registered with
+	Expr string
+	// Handler is the handler the route was registered with, without the middleware
+	Handler http.Handler
+	// Params are the values captured by the patterns of the route, nil if there are none
+	Params Params
+	// Meta is the metadata of the route, see HandleMeta
+	Meta map[string]string
+	// HeadFallback is true if the HEAD request is matched by the GET route, see SetHeadFallback
+	HeadFallback bool
+}
+
+// Match returns the route the request would be served with, without calling its handler, so route tables
+// can be tested and tools can tell where a request goes. It returns ErrNoMatch if no route matches the request.
+// Unlike ServeHTTP, it does not call the miss resolver set with SetMissResolver.
+func (m *Mux) Match(r *http.Request) (MatchResult, error) {
+	var result MatchResult
+	res := m.router.Load().route(r)
+	if res == nil {
+		var ok bool
+		if res, ok = m.routeHead(r); !ok {
+			return MatchResult{}, ErrNoMatch
+		}
+		result.HeadFallback = true
+	}
+	e := res.(*entry)
+	result.Expr = e.expr
+	result.Handler = e.handler
+	result.Meta = e.meta
+	if e.params {
+		if params := e.capture(r); len(params) != 0 {
+			result.Params = params
+		}
+	}
+	return result, nil
+}
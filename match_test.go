@@ -0,0 +1,76 @@
+package route
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	m := NewMux()
+	called := false
+	users := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true })
+	require.NoError(t, m.HandleMeta(`Host("<tenant>.example.com") && Path("/users/<id>")`, users, map[string]string{"team": "identity"}))
+	require.NoError(t, m.Handle(`Method("GET") && Path("/health")`, newStatusHandler(http.StatusNoContent)))
+
+	result, err := m.Match(makeReq(req{host: "acme.example.com", url: "/users/42"}))
+	require.NoError(t, err)
+	assert.Equal(t, `Host("<tenant>.example.com") && Path("/users/<id>")`, result.Expr)
+	assert.Equal(t, Params{"tenant": "acme", "id": "42"}, result.Params)
+	assert.Equal(t, map[string]string{"team": "identity"}, result.Meta)
+	assert.False(t, result.HeadFallback)
+	require.NotNil(t, result.Handler)
+	assert.False(t, called)
+
+	result, err = m.Match(makeReq(req{url: "/health", method: http.MethodGet}))
+	require.NoError(t, err)
+	assert.Equal(t, `Method("GET") && Path("/health")`, result.Expr)
+	w := newWriter()
+	result.Handler.ServeHTTP(w, makeReq(req{url: "/health"}))
+	assert.Equal(t, http.StatusNoContent, w.header)
+	assert.Nil(t, result.Params)
+
+	_, err = m.Match(makeReq(req{url: "/missing"}))
+	assert.ErrorIs(t, err, ErrNoMatch)
+
+	_, err = m.Match(makeReq(req{url: "/health", method: http.MethodHead}))
+	assert.ErrorIs(t, err, ErrNoMatch)
+	m.SetHeadFallback(true)
+	result, err = m.Match(makeReq(req{url: "/health", method: http.MethodHead}))
+	require.NoError(t, err)
+	assert.Equal(t, `Method("GET") && Path("/health")`, result.Expr)
+	assert.True(t, result.HeadFallback)
+}
+
+func TestMatchAlias(t *testing.T) {
+	m := NewMux()
+	m.AddAlias(`Path("/v1/`, `Path("/api/v1/`)
+	m.AddAlias(`Path("/me")`, `Path("/users/<id>")`)
+	require.NoError(t, m.Handle(`Path("/v1/users/<id>")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path("/me")`, newStatusHandler(http.StatusOK)))
+
+	for url, expected := range map[string]Params{
+		"/v1/users/42":     {"id": "42"},
+		"/api/v1/users/42": {"id": "42"},
+		"/users/7":         {"id": "7"},
+		"/me":              nil,
+	} {
+		result, err := m.Match(makeReq(req{url: url}))
+		require.NoError(t, err, url)
+		assert.Equal(t, expected, result.Params, url)
+	}
+}
+
+func TestMatchMissResolver(t *testing.T) {
+	m := NewMux()
+	m.SetMissResolver(func(m *Mux, host string) error {
+		return m.Handle(`Host("`+host+`") && Path("/")`, newStatusHandler(http.StatusOK))
+	})
+
+	_, err := m.Match(makeReq(req{host: "new.example.com", url: "/"}))
+	assert.ErrorIs(t, err, ErrNoMatch)
+	assert.Empty(t, slices.Collect(m.Routes()))
+}
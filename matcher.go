@@ -4,13 +4,21 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	pathpkg "path"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type matcher interface {
 	match(*http.Request) *match
 	setMatch(match *match)
+	// setSettings sets the settings of the Mux read by the matcher while matching, e.g. its clock
+	setSettings(s *settings)
+	// clone returns a copy of the matcher that can be merged and bound to another route,
+	// sharing only its immutable parts, e.g. its compiled regular expressions, see CompilePool
+	clone() matcher
 
 	canMerge(matcher) bool
 	merge(matcher) (matcher, error)
@@ -24,7 +32,23 @@ type match struct {
 }
 
 func hostTrieMatcher(hostname string) (matcher, error) {
-	return newTrieMatcher(strings.ToLower(hostname), &hostMapper{}, &match{})
+	if strings.HasPrefix(hostname, "*.") {
+		return hostWildcardMatcher(hostname)
+	}
+	return newTrieMatcher(hostPattern(hostname), &hostMapper{}, &match{})
+}
+
+// hostWildcardMatcher matches the subdomains of the domain at any depth, e.g. *.example.com
+// matches api.example.com and eu.api.example.com but not example.com
+func hostWildcardMatcher(pattern string) (matcher, error) {
+	suffix := strings.ToLower(pattern[1:])
+	if len(suffix) < 2 || strings.ContainsAny(suffix, "*<") {
+		return nil, fmt.Errorf("bad wildcard host, expected *.<domain>, got: %s", pattern)
+	}
+	return newFuncMatcher(fmt.Sprintf("Host(%s)", pattern), func(req *http.Request) bool {
+		host := hostname(req.Host)
+		return len(host) > len(suffix) && strings.HasSuffix(host, suffix)
+	}), nil
 }
 
 func hostRegexpMatcher(hostname string) (matcher, error) {
@@ -55,6 +79,14 @@ func headerRegexpMatcher(name, value string) (matcher, error) {
 	return newRegexpMatcher(value, &headerMapper{header: name}, &match{})
 }
 
+func queryTrieMatcher(name, value string) (matcher, error) {
+	return newTrieMatcher(value, &queryMapper{param: name}, &match{})
+}
+
+func queryRegexpMatcher(name, value string) (matcher, error) {
+	return newRegexpMatcher(value, &queryMapper{param: name}, &match{})
+}
+
 type andMatcher struct {
 	a matcher
 	b matcher
@@ -89,6 +121,15 @@ func (a *andMatcher) setMatch(m *match) {
 	a.b.setMatch(m)
 }
 
+func (a *andMatcher) setSettings(s *settings) {
+	a.a.setSettings(s)
+	a.b.setSettings(s)
+}
+
+func (a *andMatcher) clone() matcher {
+	return &andMatcher{a: a.a.clone(), b: a.b.clone()}
+}
+
 func (a *andMatcher) canMerge(_ matcher) bool {
 	return false
 }
@@ -105,6 +146,111 @@ func (a *andMatcher) match(req *http.Request) *match {
 	return a.b.match(req)
 }
 
+// orMatcher matches requests matched by any of the two matchers, e.g. Path("/a") || Path("/b")
+type orMatcher struct {
+	a matcher
+	b matcher
+}
+
+func newOrMatcher(a, b matcher) matcher {
+	return &orMatcher{
+		a: a, b: b,
+	}
+}
+
+func (o *orMatcher) canChain(matcher) bool {
+	return false
+}
+
+func (o *orMatcher) chain(matcher) (matcher, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (o *orMatcher) String() string {
+	return fmt.Sprintf("orMatcher(%v, %v)", o.a, o.b)
+}
+
+func (o *orMatcher) setMatch(m *match) {
+	o.a.setMatch(m)
+	o.b.setMatch(m)
+}
+
+func (o *orMatcher) setSettings(s *settings) {
+	o.a.setSettings(s)
+	o.b.setSettings(s)
+}
+
+func (o *orMatcher) clone() matcher {
+	return &orMatcher{a: o.a.clone(), b: o.b.clone()}
+}
+
+func (o *orMatcher) canMerge(_ matcher) bool {
+	return false
+}
+
+func (o *orMatcher) merge(_ matcher) (matcher, error) {
+	return nil, errors.New("method not supported")
+}
+
+func (o *orMatcher) match(req *http.Request) *match {
+	if result := o.a.match(req); result != nil {
+		return result
+	}
+	return o.b.match(req)
+}
+
+// notMatcher matches requests not matched by the matcher, e.g. !Header("X-Internal", "true")
+type notMatcher struct {
+	m matcher
+	// match result
+	result *match
+}
+
+func newNotMatcher(m matcher) matcher {
+	// the negated matcher only needs to report a match, not the result of the route
+	m.setMatch(&match{})
+	return &notMatcher{m: m, result: &match{}}
+}
+
+func (n *notMatcher) canChain(matcher) bool {
+	return false
+}
+
+func (n *notMatcher) chain(matcher) (matcher, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (n *notMatcher) String() string {
+	return fmt.Sprintf("notMatcher(%v)", n.m)
+}
+
+func (n *notMatcher) setMatch(result *match) {
+	n.result = result
+}
+
+func (n *notMatcher) setSettings(s *settings) {
+	n.m.setSettings(s)
+}
+
+func (n *notMatcher) clone() matcher {
+	return &notMatcher{m: n.m.clone(), result: n.result}
+}
+
+func (n *notMatcher) canMerge(_ matcher) bool {
+	return false
+}
+
+func (n *notMatcher) merge(_ matcher) (matcher, error) {
+	return nil, errors.New("method not supported")
+}
+
+func (n *notMatcher) match(req *http.Request) *match {
+	if n.m.match(req) != nil {
+		return nil
+	}
+	return n.result
+}
+
 // Regular expression matcher, takes a regular expression and requestMapper
 type regexpMatcher struct {
 	// Uses this mapper to extract a string from a request to match against
@@ -140,6 +286,13 @@ func (r *regexpMatcher) setMatch(result *match) {
 	r.result = result
 }
 
+func (r *regexpMatcher) setSettings(*settings) {}
+
+func (r *regexpMatcher) clone() matcher {
+	c := *r
+	return &c
+}
+
 func (r *regexpMatcher) canMerge(matcher) bool {
 	return false
 }
@@ -148,9 +301,254 @@ func (r *regexpMatcher) merge(matcher) (matcher, error) {
 	return nil, errors.New("method not supported")
 }
 
+// match matches the mapped request, requests mapped to several values match if any of the values matches
 func (r *regexpMatcher) match(req *http.Request) *match {
+	if m, ok := r.mapper.(valuesMapper); ok {
+		for _, value := range m.mapValues(req) {
+			if r.expr.MatchString(value) {
+				return r.result
+			}
+		}
+		return nil
+	}
 	if r.expr.MatchString(r.mapper.mapRequest(req)) {
 		return r.result
 	}
 	return nil
 }
+
+// funcMatcher matches requests using a predicate function,
+// it can't be merged or chained with other matchers
+type funcMatcher struct {
+	// name is used for debugging, e.g. Stale(Date)
+	name string
+	fn   func(*http.Request, *settings) bool
+	// settings are the settings of the Mux passed to the predicate
+	settings *settings
+	// match result
+	result *match
+}
+
+func newFuncMatcher(name string, fn func(*http.Request) bool) *funcMatcher {
+	return newSettingsMatcher(name, func(req *http.Request, _ *settings) bool {
+		return fn(req)
+	})
+}
+
+// newSettingsMatcher returns a matcher whose predicate reads the settings of the Mux, e.g. its clock
+func newSettingsMatcher(name string, fn func(*http.Request, *settings) bool) *funcMatcher {
+	return &funcMatcher{name: name, fn: fn, result: &match{}}
+}
+
+func (f *funcMatcher) canChain(matcher) bool {
+	return false
+}
+
+func (f *funcMatcher) chain(matcher) (matcher, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (f *funcMatcher) String() string {
+	return fmt.Sprintf("funcMatcher(%s)", f.name)
+}
+
+func (f *funcMatcher) setMatch(result *match) {
+	f.result = result
+}
+
+func (f *funcMatcher) setSettings(s *settings) {
+	f.settings = s
+}
+
+func (f *funcMatcher) clone() matcher {
+	c := *f
+	return &c
+}
+
+func (f *funcMatcher) canMerge(matcher) bool {
+	return false
+}
+
+func (f *funcMatcher) merge(matcher) (matcher, error) {
+	return nil, errors.New("method not supported")
+}
+
+func (f *funcMatcher) match(req *http.Request) *match {
+	if f.fn(req, f.settings) {
+		return f.result
+	}
+	return nil
+}
+
+// staleMatcher matches requests with a timestamp header that is missing, malformed,
+// or further than skew from the current time. The header can either be a HTTP date or unix seconds.
+func staleMatcher(header, skew string) (matcher, error) {
+	maxSkew, err := time.ParseDuration(skew)
+	if err != nil {
+		return nil, fmt.Errorf("bad skew duration: %s %w", skew, err)
+	}
+	if maxSkew <= 0 {
+		return nil, fmt.Errorf("skew should be positive, got: %s", skew)
+	}
+
+	return newSettingsMatcher(fmt.Sprintf("Stale(%s, %s)", header, skew), func(req *http.Request, s *settings) bool {
+		ts, ok := parseTimestamp(req.Header.Get(header))
+		if !ok {
+			return true
+		}
+		age := s.getClock().Now().Sub(ts)
+		return age > maxSkew || age < -maxSkew
+	}), nil
+}
+
+func parseTimestamp(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), true
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// headerTokenMatcher matches requests with a header holding the token in its comma-separated list
+// of elements as defined by RFC 7230, section 7. The comparison is case-insensitive,
+// quoted elements are unquoted and element parameters after ';' are ignored.
+func headerTokenMatcher(name, token string) (matcher, error) {
+	if token == "" {
+		return nil, fmt.Errorf("empty token for header %s", name)
+	}
+	return newFuncMatcher(fmt.Sprintf("HeaderContainsToken(%s, %s)", name, token), func(req *http.Request) bool {
+		for _, value := range req.Header.Values(name) {
+			for _, element := range splitList(value) {
+				element, _, _ = strings.Cut(element, ";")
+				element = strings.TrimSpace(element)
+				if unquoted, err := strconv.Unquote(element); err == nil && strings.HasPrefix(element, `"`) {
+					element = unquoted
+				}
+				if strings.EqualFold(element, token) {
+					return true
+				}
+			}
+		}
+		return false
+	}), nil
+}
+
+// splitList splits a header value into its comma-separated elements,
+// commas inside quoted strings do not separate elements
+func splitList(value string) []string {
+	var out []string
+	quoted, escaped := false, false
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			out = append(out, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, value[start:])
+}
+
+// pathSegmentMatcher matches requests with the path segment at the 1-based index equal to the value,
+// e.g. PathSegment(2, "admin") matches /v1/admin and /v1/admin/users
+func pathSegmentMatcher(index int, value string) (matcher, error) {
+	if index < 1 {
+		return nil, fmt.Errorf("path segment index starts at 1, got: %d", index)
+	}
+	if strings.ContainsRune(value, '/') {
+		return nil, fmt.Errorf("path segment can't contain '/', got: %s", value)
+	}
+	return newFuncMatcher(fmt.Sprintf("PathSegment(%d, %s)", index, value), func(req *http.Request) bool {
+		segment, ok := pathSegment(rawPath(req), index)
+		return ok && segment == value
+	}), nil
+}
+
+// pathSegment returns the path segment at the 1-based index
+func pathSegment(path string, index int) (string, bool) {
+	path = strings.TrimPrefix(path, "/")
+	for i := 1; i < index; i++ {
+		next := strings.IndexByte(path, '/')
+		if next == -1 {
+			return "", false
+		}
+		path = path[next+1:]
+	}
+	if end := strings.IndexByte(path, '/'); end != -1 {
+		path = path[:end]
+	}
+	return path, true
+}
+
+// pathGlobMatcher matches the raw request path against a shell-style glob:
+// '*' matches any sequence of characters within a segment, '?' matches a single character,
+// '[...]' matches a character class and a '**' segment matches any number of segments.
+// Globs without wildcards are compiled into trie matchers.
+func pathGlobMatcher(glob string) (matcher, error) {
+	if !strings.ContainsAny(glob, "*?[\\") {
+		return pathTrieMatcher(glob)
+	}
+
+	segments := strings.Split(glob, "/")
+	for _, s := range segments {
+		if s == "**" {
+			continue
+		}
+		// validate the pattern syntax once, so match never fails
+		if _, err := pathpkg.Match(s, ""); err != nil {
+			return nil, fmt.Errorf("bad glob: %s %w", glob, err)
+		}
+	}
+	return newFuncMatcher(fmt.Sprintf("PathGlob(%s)", glob), func(req *http.Request) bool {
+		return matchSegments(segments, strings.Split(rawPath(req), "/"))
+	}), nil
+}
+
+// matchSegments matches path segments against glob segments, backtracking on '**'
+func matchSegments(glob, path []string) bool {
+	for len(glob) != 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(glob[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if ok, _ := pathpkg.Match(glob[0], path[0]); !ok {
+			return false
+		}
+		glob, path = glob[1:], path[1:]
+	}
+	return len(path) == 0
+}
+
+// protoMatcher matches requests by protocol version, e.g. Proto("HTTP/3") matches the requests
+// served over QUIC, reported as HTTP/3.0, while Proto("HTTP/1.0") does not match HTTP/1.1 requests
+func protoMatcher(proto string) (matcher, error) {
+	version, ok := strings.CutPrefix(proto, "HTTP/")
+	if !ok || version == "" {
+		return nil, fmt.Errorf("bad protocol, expected HTTP/<major>[.<minor>], got: %s", proto)
+	}
+	return newFuncMatcher(fmt.Sprintf("Proto(%s)", proto), func(req *http.Request) bool {
+		if strings.Contains(version, ".") {
+			return req.Proto == proto
+		}
+		return strconv.Itoa(req.ProtoMajor) == version
+	}), nil
+}
@@ -2,7 +2,10 @@ package route
 
 import (
 	"net/http"
+	"slices"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -32,3 +35,323 @@ func TestHostnameCase(t *testing.T) {
 	assert.NotNil(t, matcher1.match(req))
 	assert.NotNil(t, matcher2.match(req))
 }
+
+func TestHostname(t *testing.T) {
+	testCases := []struct {
+		host     string
+		expected string
+	}{
+		{host: "Example.com", expected: "example.com"},
+		{host: "example.com:8080", expected: "example.com"},
+		{host: "127.0.0.1:8080", expected: "127.0.0.1"},
+		{host: "[::1]", expected: "::1"},
+		{host: "[::1]:8080", expected: "::1"},
+		{host: "[0:0:0:0:0:0:0:1]:8080", expected: "::1"},
+		{host: "[2001:DB8::1]:443", expected: "2001:db8::1"},
+		{host: "[fe80::1%25eth0]:8080", expected: "fe80::1%eth0"},
+		{host: "::1", expected: "::1"},
+		{host: "[::1", expected: "[::1"},
+		{host: "", expected: ""},
+	}
+	for _, test := range testCases {
+		assert.Equal(t, test.expected, hostname(test.host), test.host)
+	}
+}
+
+func TestHostIPv6(t *testing.T) {
+	for _, pattern := range []string{"::1", "[::1]", "0:0::1"} {
+		m, err := hostTrieMatcher(pattern)
+		require.NoError(t, err)
+
+		for _, host := range []string{"[::1]:8080", "[::1]", "[0::1]:80"} {
+			r := makeReq(req{url: "/"})
+			r.Host = host
+			assert.NotNil(t, m.match(r), "%s on %s", pattern, host)
+		}
+		r := makeReq(req{url: "/"})
+		r.Host = "[::2]:8080"
+		assert.Nil(t, m.match(r), pattern)
+	}
+
+	m, err := hostTrieMatcher("[fe80::1%eth0]")
+	require.NoError(t, err)
+	r := makeReq(req{url: "/"})
+	r.Host = "[fe80::1%25eth0]:8080"
+	assert.NotNil(t, m.match(r))
+}
+
+func TestStaleMatcher(t *testing.T) {
+	m, err := staleMatcher("Date", "5m")
+	require.NoError(t, err)
+
+	now := time.Now()
+	testCases := []struct {
+		desc  string
+		value string
+		stale bool
+	}{
+		{desc: "missing", value: "", stale: true},
+		{desc: "malformed", value: "yesterday", stale: true},
+		{desc: "fresh http date", value: now.UTC().Format(http.TimeFormat)},
+		{desc: "fresh unix seconds", value: strconv.FormatInt(now.Unix(), 10)},
+		{desc: "old", value: now.Add(-10 * time.Minute).UTC().Format(http.TimeFormat), stale: true},
+		{desc: "in the future", value: strconv.FormatInt(now.Add(10*time.Minute).Unix(), 10), stale: true},
+	}
+
+	for _, test := range testCases {
+		r := makeReq(req{url: "/", headers: http.Header{}})
+		if test.value != "" {
+			r.Header.Set("Date", test.value)
+		}
+		if test.stale {
+			assert.NotNil(t, m.match(r), test.desc)
+		} else {
+			assert.Nil(t, m.match(r), test.desc)
+		}
+	}
+
+	_, err = staleMatcher("Date", "soon")
+	assert.Error(t, err)
+	_, err = staleMatcher("Date", "-1m")
+	assert.Error(t, err)
+}
+
+func TestHeaderTokenMatcher(t *testing.T) {
+	m, err := headerTokenMatcher("X-Features", "beta")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		values   []string
+		expected bool
+	}{
+		{values: []string{"beta"}, expected: true},
+		{values: []string{"alpha, BETA ,gamma"}, expected: true},
+		{values: []string{"alpha", "beta;q=0.5"}, expected: true},
+		{values: []string{`"beta"`}, expected: true},
+		{values: []string{`"alpha, beta"`}, expected: false},
+		{values: []string{"betamax", "alphabeta"}, expected: false},
+		{values: []string{""}, expected: false},
+		{values: nil, expected: false},
+	}
+
+	for _, test := range testCases {
+		r := makeReq(req{url: "/", headers: http.Header{"X-Features": test.values}})
+		assert.Equal(t, test.expected, m.match(r) != nil, test.values)
+	}
+
+	_, err = headerTokenMatcher("X-Features", "")
+	assert.Error(t, err)
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{"a", ` "b,\"c"`, " d"}, splitList(`a, "b,\"c", d`))
+	assert.Equal(t, []string{""}, splitList(""))
+}
+
+func TestPathSegmentMatcher(t *testing.T) {
+	m, err := pathSegmentMatcher(2, "admin")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		path     string
+		expected bool
+	}{
+		{path: "/v1/admin", expected: true},
+		{path: "/v1/admin/", expected: true},
+		{path: "/v1/admin/users", expected: true},
+		{path: "/admin", expected: false},
+		{path: "/v1/administrator", expected: false},
+		{path: "/v1/v2/admin", expected: false},
+		{path: "/", expected: false},
+	}
+	for _, test := range testCases {
+		assert.Equal(t, test.expected, m.match(makeReq(req{url: test.path})) != nil, test.path)
+	}
+
+	empty, err := pathSegmentMatcher(2, "")
+	require.NoError(t, err)
+	assert.NotNil(t, empty.match(makeReq(req{url: "/v1/"})))
+	assert.Nil(t, empty.match(makeReq(req{url: "/v1"})))
+
+	_, err = pathSegmentMatcher(0, "admin")
+	assert.Error(t, err)
+	_, err = pathSegmentMatcher(1, "a/b")
+	assert.Error(t, err)
+}
+
+func TestPathGlobMatcher(t *testing.T) {
+	testCases := []struct {
+		glob     string
+		path     string
+		expected bool
+	}{
+		{glob: "/api/*/users/**", path: "/api/v1/users", expected: true},
+		{glob: "/api/*/users/**", path: "/api/v1/users/42/groups", expected: true},
+		{glob: "/api/*/users/**", path: "/api/v1/v2/users", expected: false},
+		{glob: "/api/*/users/**", path: "/api/v1/groups", expected: false},
+		{glob: "/**/*.png", path: "/static/img/logo.png", expected: true},
+		{glob: "/**/*.png", path: "/logo.png", expected: true},
+		{glob: "/**/*.png", path: "/logo.jpg", expected: false},
+		{glob: "/v?/status", path: "/v2/status", expected: true},
+		{glob: "/v[0-9]/status", path: "/vx/status", expected: false},
+		{glob: "/api/*", path: "/api/v1/users", expected: false},
+		{glob: "/api/literal", path: "/api/literal", expected: true},
+	}
+
+	for _, test := range testCases {
+		m, err := pathGlobMatcher(test.glob)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, m.match(makeReq(req{url: test.path})) != nil, "%s %s", test.glob, test.path)
+	}
+
+	m, err := pathGlobMatcher("/api/literal")
+	require.NoError(t, err)
+	assert.IsType(t, &trie{}, m)
+
+	_, err = pathGlobMatcher("/api/[")
+	assert.Error(t, err)
+}
+
+func TestProtoMatcher(t *testing.T) {
+	testCases := []struct {
+		proto    string
+		major    int
+		minor    int
+		expected []string
+	}{
+		{proto: "HTTP/1.0", major: 1, minor: 0, expected: []string{"HTTP/1", "HTTP/1.0"}},
+		{proto: "HTTP/1.1", major: 1, minor: 1, expected: []string{"HTTP/1", "HTTP/1.1"}},
+		{proto: "HTTP/2.0", major: 2, minor: 0, expected: []string{"HTTP/2", "HTTP/2.0"}},
+		{proto: "HTTP/3.0", major: 3, minor: 0, expected: []string{"HTTP/3", "HTTP/3.0"}},
+	}
+	for _, test := range testCases {
+		r := makeReq(req{url: "/"})
+		r.Proto, r.ProtoMajor, r.ProtoMinor = test.proto, test.major, test.minor
+
+		for _, proto := range []string{"HTTP/1", "HTTP/1.0", "HTTP/1.1", "HTTP/2", "HTTP/2.0", "HTTP/3", "HTTP/3.0"} {
+			m, err := protoMatcher(proto)
+			require.NoError(t, err)
+			assert.Equal(t, slices.Contains(test.expected, proto), m.match(r) != nil, "%s on %s", proto, test.proto)
+		}
+	}
+
+	_, err := protoMatcher("HTTP/")
+	assert.Error(t, err)
+	_, err = protoMatcher("3")
+	assert.Error(t, err)
+}
+
+func TestHostWildcardMatcher(t *testing.T) {
+	m, err := hostTrieMatcher("*.Example.com")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		host     string
+		expected bool
+	}{
+		{host: "api.example.com", expected: true},
+		{host: "eu.api.example.com:8080", expected: true},
+		{host: "API.EXAMPLE.COM", expected: true},
+		{host: "example.com", expected: false},
+		{host: ".example.com", expected: false},
+		{host: "apiexample.com", expected: false},
+		{host: "example.com.evil.org", expected: false},
+	}
+	for _, test := range testCases {
+		r := makeReq(req{url: "/"})
+		r.Host = test.host
+		assert.Equal(t, test.expected, m.match(r) != nil, test.host)
+	}
+
+	for _, bad := range []string{"*.", "*.*.example.com", "*.<sub>.example.com"} {
+		_, err := hostTrieMatcher(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestHeaderRegexpMatcherValues(t *testing.T) {
+	m, err := headerRegexpMatcher("X-Request-Source", "^mobile-.*")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		values   []string
+		expected bool
+	}{
+		{values: []string{"mobile-ios"}, expected: true},
+		{values: []string{"web", "mobile-android"}, expected: true},
+		{values: []string{"web", "desktop"}, expected: false},
+		{values: []string{"web, mobile-ios"}, expected: false},
+		{values: nil, expected: false},
+	}
+	for _, test := range testCases {
+		r := makeReq(req{url: "/", headers: http.Header{"X-Request-Source": test.values}})
+		assert.Equal(t, test.expected, m.match(r) != nil, test.values)
+	}
+
+	// missing headers are matched as empty values
+	empty, err := headerRegexpMatcher("X-Request-Source", "^$")
+	require.NoError(t, err)
+	assert.NotNil(t, empty.match(makeReq(req{url: "/", headers: http.Header{}})))
+}
+
+func TestQueryMatchers(t *testing.T) {
+	m, err := queryTrieMatcher("version", "2")
+	require.NoError(t, err)
+
+	re, err := queryRegexpMatcher("version", "^(2|3)$")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		url      string
+		trie     bool
+		expected bool
+	}{
+		{url: "/?version=2", trie: true, expected: true},
+		{url: "/?a=b&version=2&c", trie: true, expected: true},
+		{url: "/?version=1&version=3", trie: false, expected: true},
+		{url: "/?vers%69on=2", trie: true, expected: true},
+		{url: "/?version=22", trie: false, expected: false},
+		{url: "/?version", trie: false, expected: false},
+		{url: "/", trie: false, expected: false},
+	}
+	for _, test := range testCases {
+		r := makeReq(req{url: test.url})
+		assert.Equal(t, test.trie, m.match(r) != nil, test.url)
+		assert.Equal(t, test.expected, re.match(r) != nil, test.url)
+	}
+}
+
+func TestQueryValues(t *testing.T) {
+	assert.Equal(t, []string{"1", "a b", ""}, queryValues("a=1&b=2&a=a+b&a", "a"))
+	assert.Equal(t, []string{"x"}, queryValues("bad=%zz&a%20b=x", "a b"))
+	assert.Nil(t, queryValues("", "a"))
+}
+
+func TestNotOrMatchers(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/api/<resource>") && !Header("X-Internal", "true")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Method("GET") && (Path("/v1/status") || Path("/v2/status"))`, newStatusHandler(http.StatusAccepted)))
+
+	serve := func(method, url string, headers http.Header) int {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{method: method, url: url, headers: headers}))
+		return w.header
+	}
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/users", nil))
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/users", http.Header{"X-Internal": {"false"}}))
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/api/users", http.Header{"X-Internal": {"true"}}))
+
+	assert.Equal(t, http.StatusAccepted, serve(http.MethodGet, "/v1/status", nil))
+	assert.Equal(t, http.StatusAccepted, serve(http.MethodGet, "/v2/status", nil))
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/v3/status", nil))
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/v1/status", nil))
+
+	// the params of the required matchers are captured
+	require.NoError(t, m.HandleFunc(`Path("/users/<id>") && !Method("DELETE")`, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(Param(r, "id")))
+	}))
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{method: http.MethodGet, url: "/users/42"}))
+	assert.Equal(t, "42", w.buf.String())
+}
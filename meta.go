@@ -0,0 +1,39 @@
+package route
+
+import (
+	"context"
+	"net/http"
+)
+
+// MatchedRoute describes the route the Mux matched for the request
+type MatchedRoute struct {
+	// Expr is the expression the route was registered with
+	Expr string
+	// Meta is the metadata the route was registered with, see HandleMeta. It is shared by the requests
+	// of the route and must not be modified.
+	Meta map[string]string
+}
+
+type routeKey struct{}
+
+// RouteFromContext returns the route matched by the Mux for the request, false outside of a route handler
+// or its middleware
+func RouteFromContext(ctx context.Context) (*MatchedRoute, bool) {
+	route, ok := ctx.Value(routeKey{}).(*MatchedRoute)
+	return route, ok
+}
+
+// HandleMeta adds http handler for route expression with metadata, e.g. the owning team or the rate limit
+// tier of the route. The metadata is available to the handler and its middleware, see RouteFromContext,
+// and is listed by Routes. Replacing the route replaces its metadata.
+func (m *Mux) HandleMeta(expr string, handler http.Handler, meta map[string]string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.handleLocked("", expr, registration{meta: meta}, handler)
+}
+
+// withRoute adds the matched route to the request context
+func (e *entry) withRoute(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, e.matched))
+}
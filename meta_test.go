@@ -0,0 +1,75 @@
+package route
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleMeta(t *testing.T) {
+	m := NewMux()
+	var matched *MatchedRoute
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, ok := RouteFromContext(r.Context())
+		require.True(t, ok)
+		matched = route
+	})
+	meta := map[string]string{"team": "billing", "tier": "gold"}
+	require.NoError(t, m.HandleMeta(`Path("/invoices/<id>")`, handler, meta))
+	require.NoError(t, m.Handle(`Path("/health")`, handler))
+	// the metadata is copied at registration
+	meta["tier"] = "silver"
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/invoices/42"}))
+	require.NotNil(t, matched)
+	assert.Equal(t, `Path("/invoices/<id>")`, matched.Expr)
+	assert.Equal(t, map[string]string{"team": "billing", "tier": "gold"}, matched.Meta)
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/health"}))
+	assert.Equal(t, `Path("/health")`, matched.Expr)
+	assert.Nil(t, matched.Meta)
+
+	routes := slices.Collect(m.Routes())
+	require.Len(t, routes, 2)
+	assert.Equal(t, map[string]string{"team": "billing", "tier": "gold"}, routes[1].Meta)
+
+	// replacing the route replaces its metadata
+	require.NoError(t, m.Handle(`Path("/invoices/<id>")`, handler))
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/invoices/42"}))
+	assert.Nil(t, matched.Meta)
+}
+
+func TestRouteFromContextMiddleware(t *testing.T) {
+	m := NewMux()
+	var team string
+	m.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route, ok := RouteFromContext(r.Context()); ok {
+				team = route.Meta["team"]
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	require.NoError(t, m.HandleMeta(`Path("/")`, newStatusHandler(http.StatusOK), map[string]string{"team": "platform"}))
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/"}))
+	assert.Equal(t, "platform", team)
+
+	_, ok := RouteFromContext(makeReq(req{url: "/"}).Context())
+	assert.False(t, ok)
+}
+
+func TestRestoreMeta(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleMeta(`Path("/")`, newStatusHandler(http.StatusOK), map[string]string{"team": "platform"}))
+	s := m.Snapshot()
+	require.NoError(t, m.Handle(`Path("/")`, newStatusHandler(http.StatusOK)))
+
+	require.NoError(t, m.Restore(s, nil))
+	routes := slices.Collect(m.Routes())
+	require.Len(t, routes, 1)
+	assert.Equal(t, map[string]string{"team": "platform"}, routes[0].Meta)
+}
@@ -0,0 +1,208 @@
+package route
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// standardMethods are the methods always considered by MethodsFor
+var standardMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// pathIndex indexes the methods of the routes by the patterns of their Host and Path matchers,
+// so the methods allowed for a path are found without matching the request against the router
+type pathIndex struct {
+	// paths are the indexed patterns by host and path pattern
+	paths map[[2]string]*indexedPath
+	// buckets are the indexed patterns by the first segment of their path pattern, "<" for the
+	// patterns whose first segment has a param, so a lookup only matches the patterns it can match
+	buckets map[string][]*indexedPath
+}
+
+// indexedPath counts the methods of the routes of a host and path pattern
+type indexedPath struct {
+	key  [2]string
+	host matcher
+	path matcher
+	// methods counts the routes per method of their Method matcher, regexps the routes per pattern of their
+	// MethodRegexp matcher and any the routes without method matcher
+	methods map[string]int
+	regexps map[string]*methodRegexp
+	any     int
+}
+
+type methodRegexp struct {
+	re    *regexp.Regexp
+	count int
+}
+
+func newPathIndex() *pathIndex {
+	return &pathIndex{paths: make(map[[2]string]*indexedPath), buckets: make(map[string][]*indexedPath)}
+}
+
+// bucket returns the first segment of the path, "<" if the segment of the pattern has a param
+func bucket(path string) string {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if strings.ContainsRune(segment, '<') {
+		return "<"
+	}
+	return segment
+}
+
+// add counts the route in the index, routes without a Path matcher are not indexed
+func (x *pathIndex) add(e *entry, delta int) {
+	if e.path == "" {
+		return
+	}
+	key := [2]string{e.host, e.path}
+	p, ok := x.paths[key]
+	if !ok {
+		if delta < 0 {
+			return
+		}
+		var err error
+		p = &indexedPath{key: key, methods: make(map[string]int), regexps: make(map[string]*methodRegexp)}
+		if p.path, err = pathTrieMatcher(e.path); err != nil {
+			return
+		}
+		if e.host != "" {
+			if p.host, err = hostTrieMatcher(e.host); err != nil {
+				return
+			}
+		}
+		x.paths[key] = p
+		b := bucket(e.path)
+		x.buckets[b] = append(x.buckets[b], p)
+	}
+
+	switch {
+	case e.method != "":
+		p.methods[e.method] += delta
+		if p.methods[e.method] <= 0 {
+			delete(p.methods, e.method)
+		}
+	case e.methodRegexp != nil:
+		r, ok := p.regexps[e.methodRegexp.String()]
+		if !ok {
+			r = &methodRegexp{re: e.methodRegexp}
+			p.regexps[e.methodRegexp.String()] = r
+		}
+		r.count += delta
+		if r.count <= 0 {
+			delete(p.regexps, e.methodRegexp.String())
+		}
+	default:
+		p.any += delta
+	}
+
+	if len(p.methods) == 0 && len(p.regexps) == 0 && p.any <= 0 {
+		delete(x.paths, key)
+		b := bucket(e.path)
+		x.buckets[b] = removeIndexed(x.buckets[b], p)
+		if len(x.buckets[b]) == 0 {
+			delete(x.buckets, b)
+		}
+	}
+}
+
+func removeIndexed(paths []*indexedPath, p *indexedPath) []*indexedPath {
+	for i, other := range paths {
+		if other == p {
+			return append(paths[:i:i], paths[i+1:]...)
+		}
+	}
+	return paths
+}
+
+// lookup adds the methods of the routes matching the host and the path of the request to allowed,
+// candidates are the methods allowed by the routes without Method matcher
+func (x *pathIndex) lookup(r *http.Request, candidates []string, allowed map[string]bool) {
+	segment := bucket(rawPath(r))
+	x.lookupBucket(x.buckets[segment], r, candidates, allowed)
+	if segment != "<" {
+		x.lookupBucket(x.buckets["<"], r, candidates, allowed)
+	}
+}
+
+func (x *pathIndex) lookupBucket(paths []*indexedPath, r *http.Request, candidates []string, allowed map[string]bool) {
+	for _, p := range paths {
+		if p.path.match(r) == nil || (p.host != nil && p.host.match(r) == nil) {
+			continue
+		}
+		for method := range p.methods {
+			allowed[method] = true
+		}
+		for _, method := range candidates {
+			if p.any > 0 {
+				allowed[method] = true
+				continue
+			}
+			for _, re := range p.regexps {
+				if re.re.MatchString(method) {
+					allowed[method] = true
+				}
+			}
+		}
+	}
+}
+
+// trackMethod counts the routes with a Method matcher for the method, so custom methods are considered
+// by MethodsFor as well, and indexes the methods of the route by its Host and Path matchers
+func (m *Mux) trackMethod(e *entry, delta int) {
+	m.paths.add(e, delta)
+	if e.method == "" {
+		return
+	}
+	m.methods[e.method] += delta
+	if m.methods[e.method] <= 0 {
+		delete(m.methods, e.method)
+	}
+}
+
+// MethodsFor returns the sorted methods of the routes matching the host and the path by their Host and Path
+// matchers. It is the method index used to answer with the Allow header, maintained as routes are added
+// and removed: the other matchers of the routes are not evaluated and the routes without a Path matcher
+// are not indexed. The methods are taken from the Method and MethodRegexp matchers, the routes without them
+// allow the standard methods and the methods of the Method matchers. HEAD is included for GET routes when
+// the HEAD fallback is enabled and OPTIONS is included when the automatic OPTIONS responses are enabled,
+// see SetAutoOptions.
+func (m *Mux) MethodsFor(host, path string) []string {
+	u, err := url.ParseRequestURI(path)
+	if err != nil {
+		return nil
+	}
+	return m.methodsFor(&http.Request{Host: host, URL: u, RequestURI: path})
+}
+
+// methodsFor returns the methods of the routes matching the host and the path of the request, see MethodsFor
+func (m *Mux) methodsFor(r *http.Request) []string {
+	allowed := make(map[string]bool)
+
+	m.mutex.RLock()
+	candidates := make([]string, 0, len(standardMethods)+len(m.methods))
+	candidates = append(candidates, standardMethods...)
+	for method := range m.methods {
+		candidates = append(candidates, method)
+	}
+	m.paths.lookup(r, candidates, allowed)
+	m.mutex.RUnlock()
+
+	if m.headFallback && allowed[http.MethodGet] {
+		allowed[http.MethodHead] = true
+	}
+	if m.autoOptions && len(allowed) != 0 {
+		allowed[http.MethodOptions] = true
+	}
+
+	methods := make([]string, 0, len(allowed))
+	for method := range allowed {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
@@ -0,0 +1,70 @@
+package route
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodsFor(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Method("GET") && Path("/users/<id>")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Method("DELETE") && Path("/users/<id>")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Method("PURGE") && Path("/users/<id>")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`MethodRegexp("POST|PUT") && Path("/users")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Host("admin") && Path("/any")`, newStatusHandler(http.StatusOK)))
+
+	assert.Equal(t, []string{"DELETE", "GET", "PURGE"}, m.MethodsFor("", "/users/42"))
+	assert.Equal(t, []string{"POST", "PUT"}, m.MethodsFor("", "/users"))
+	// routes without Method matchers allow the standard and the custom methods
+	assert.Contains(t, m.MethodsFor("admin", "/any"), "PURGE")
+	assert.Len(t, m.MethodsFor("admin", "/any"), len(standardMethods)+1)
+	assert.Empty(t, m.MethodsFor("", "/any"))
+	assert.Empty(t, m.MethodsFor("", "not a path"))
+
+	m.SetHeadFallback(true)
+	assert.Equal(t, []string{"DELETE", "GET", "HEAD", "PURGE"}, m.MethodsFor("", "/users/42"))
+
+	require.NoError(t, m.Remove(`Method("PURGE") && Path("/users/<id>")`))
+	assert.Empty(t, m.methods["PURGE"])
+	assert.Equal(t, []string{"DELETE", "GET", "HEAD"}, m.MethodsFor("", "/users/42"))
+}
+
+func TestMethodsForIndex(t *testing.T) {
+	var calls atomic.Int32
+	require.NoError(t, RegisterMatcher("TestMethodsProbe", func(args ...string) (func(*http.Request) bool, error) {
+		return func(r *http.Request) bool {
+			calls.Add(1)
+			return true
+		}, nil
+	}))
+
+	m := NewMux()
+	require.NoError(t, m.Handle(`Method("GET") && Path("/users/<id>")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Method("DELETE") && Path("/users/me")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Method("PATCH") && Path("/users/<id>") && TestMethodsProbe()`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Method("PUT") && Host("<tenant>.example.com") && Path("/<kind>/<id>")`, newStatusHandler(http.StatusOK)))
+
+	// the methods of the overlapping patterns are merged
+	assert.Equal(t, []string{"DELETE", "GET", "PATCH"}, m.MethodsFor("", "/users/me"))
+	assert.Equal(t, []string{"GET", "PATCH"}, m.MethodsFor("", "/users/42"))
+	assert.Equal(t, []string{"GET", "PATCH", "PUT"}, m.MethodsFor("acme.example.com", "/users/42"))
+	assert.Equal(t, []string{"PUT"}, m.MethodsFor("acme.example.com", "/posts/42"))
+	// the other matchers of the routes are not evaluated
+	assert.Zero(t, calls.Load())
+
+	// the index follows the route table
+	require.NoError(t, m.Remove(`Method("GET") && Path("/users/<id>")`))
+	assert.Equal(t, []string{"DELETE", "PATCH"}, m.MethodsFor("", "/users/me"))
+	require.NoError(t, m.Compact())
+	require.NoError(t, m.InitHandlers(map[string]interface{}{
+		`Method("POST") && Path("/users")`: newStatusHandler(http.StatusOK),
+	}))
+	assert.Empty(t, m.MethodsFor("", "/users/me"))
+	assert.Equal(t, []string{"POST"}, m.MethodsFor("", "/users"))
+	assert.Len(t, m.paths.paths, 1)
+	assert.Len(t, m.paths.buckets, 1)
+}
@@ -0,0 +1,43 @@
+package route
+
+import (
+	"net/http"
+	"slices"
+)
+
+// Middleware wraps a route handler
+type Middleware func(http.Handler) http.Handler
+
+// Use appends middleware applied to the handlers of the routes added after the call, by Handle
+// and its variants or by InitHandlers. Routes already registered are not affected.
+// The first middleware is the outermost one.
+func (m *Mux) Use(middleware ...Middleware) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.middleware = append(slices.Clip(m.middleware), middleware...)
+}
+
+// HandleWith adds http handler for route expression like Handle, wrapped with the middleware of the route
+// inside the middleware set with Use
+func (m *Mux) HandleWith(expr string, handler http.Handler, middleware ...Middleware) error {
+	return m.handle("", expr, handler, middleware...)
+}
+
+// setMiddleware sets the middleware of the route, the middleware set with Use first
+func (e *entry) setMiddleware(global, route []Middleware) {
+	if len(global) == 0 && len(route) == 0 {
+		e.middleware = nil
+		e.wrapped = e.handler
+		return
+	}
+	e.middleware = append(slices.Clip(global), route...)
+	e.wrapped = wrapMiddleware(e.handler, e.middleware)
+}
+
+func wrapMiddleware(h http.Handler, middleware []Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
@@ -0,0 +1,59 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tag appends the name to the X-Chain header of the response
+func tag(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Chain", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/before")`, newStatusHandler(http.StatusOK)))
+
+	m.Use(tag("a"), tag("b"))
+	require.NoError(t, m.Handle(`Path("/after")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.HandleWith(`Path("/route")`, newStatusHandler(http.StatusOK), tag("c")))
+
+	m.Use(tag("d"))
+	require.NoError(t, m.Handle(`Path("/later")`, newStatusHandler(http.StatusOK)))
+
+	chain := func(url string) []string {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: url}))
+		assert.Equal(t, http.StatusOK, w.header, url)
+		return w.Header().Values("X-Chain")
+	}
+
+	assert.Empty(t, chain("/before"))
+	assert.Equal(t, []string{"a", "b"}, chain("/after"))
+	assert.Equal(t, []string{"a", "b", "c"}, chain("/route"))
+	assert.Equal(t, []string{"a", "b", "d"}, chain("/later"))
+
+	// the route info holds the handler without middleware
+	for r := range m.Routes() {
+		w := newWriter()
+		r.Handler.ServeHTTP(w, makeReq(req{url: "/"}))
+		assert.Empty(t, w.Header().Values("X-Chain"), r.Expr)
+	}
+
+	// middleware wraps the mocks too
+	registry := NewMockRegistry()
+	require.NoError(t, registry.Register(`Path("/route")`, MockResponse{}))
+	m.SetMocks(registry)
+	assert.Equal(t, []string{"a", "b", "c"}, chain("/route"))
+
+	require.NoError(t, m.InitHandlers(map[string]interface{}{`Path("/init")`: newStatusHandler(http.StatusOK)}))
+	assert.Equal(t, []string{"a", "b", "d"}, chain("/init"))
+}
@@ -0,0 +1,104 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MockResponse is a canned response served by a MockRegistry
+type MockResponse struct {
+	// Status is the status code, 200 by default
+	Status int
+	Header http.Header
+	Body   string
+	// Delay is the time to wait before answering, e.g. to simulate a slow backend
+	Delay time.Duration
+}
+
+// MockRegistry holds canned response scripts for route expressions, so environments like staging
+// can stub out missing backends while still exercising the real routing rules, see Mux.SetMocks
+type MockRegistry struct {
+	mutex   sync.Mutex
+	scripts map[string]*mockScript
+}
+
+// mockScript serves its responses in turn, the last response is repeated
+type mockScript struct {
+	responses []MockResponse
+	next      int
+}
+
+// NewMockRegistry returns an empty mock registry
+func NewMockRegistry() *MockRegistry {
+	return &MockRegistry{scripts: make(map[string]*mockScript)}
+}
+
+// Register sets the script of the responses served for the routes with the expression,
+// expressions are compared in their canonical form, see CanonicalExpr
+func (r *MockRegistry) Register(expr string, responses ...MockResponse) error {
+	if len(responses) == 0 {
+		return errors.New("at least one response is required")
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.scripts[CanonicalExpr(expr)] = &mockScript{responses: responses}
+	return nil
+}
+
+// Remove removes the script of the expression
+func (r *MockRegistry) Remove(expr string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.scripts, CanonicalExpr(expr))
+}
+
+// handler returns the handler serving the script of the expression
+func (r *MockRegistry) handler(expr string) (http.Handler, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, ok := r.scripts[CanonicalExpr(expr)]
+	if !ok {
+		return nil, false
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mutex.Lock()
+		res := s.responses[s.next]
+		if s.next < len(s.responses)-1 {
+			s.next++
+		}
+		r.mutex.Unlock()
+
+		res.serve(w, req)
+	}), true
+}
+
+func (res MockResponse) serve(w http.ResponseWriter, r *http.Request) {
+	if res.Delay > 0 {
+		select {
+		case <-clockFor(r).After(res.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	header := w.Header()
+	for k, v := range res.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	status := res.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(res.Body))
+}
+
+// SetMocks sets the mock registry: the routes whose expression has a script in the registry
+// are served by the script instead of their handler. A nil registry disables the mocks.
+func (m *Mux) SetMocks(registry *MockRegistry) {
+	m.mocks = registry
+}
@@ -0,0 +1,47 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMocks(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Host("payments") && Path("/charge")`, newStatusHandler(http.StatusBadGateway)))
+	require.NoError(t, m.Handle(`Path("/real")`, newStatusHandler(http.StatusOK)))
+
+	registry := NewMockRegistry()
+	require.NoError(t, registry.Register(`Host("payments")  &&  Path("/charge")`,
+		MockResponse{Status: http.StatusServiceUnavailable},
+		MockResponse{Header: http.Header{"Content-Type": {"application/json"}}, Body: `{"status":"ok"}`},
+	))
+	assert.Error(t, registry.Register(`Path("/real")`))
+
+	serve := func(host, url string) *testWriter {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: url, host: host}))
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadGateway, serve("payments", "/charge").header)
+
+	m.SetMocks(registry)
+	assert.Equal(t, http.StatusServiceUnavailable, serve("payments", "/charge").header)
+	for i := 0; i < 2; i++ {
+		w := serve("payments", "/charge")
+		assert.Equal(t, http.StatusOK, w.header)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.Equal(t, `{"status":"ok"}`, w.buf.String())
+	}
+	assert.Equal(t, http.StatusOK, serve("", "/real").header)
+
+	registry.Remove(`Host("payments") && Path("/charge")`)
+	assert.Equal(t, http.StatusBadGateway, serve("payments", "/charge").header)
+
+	require.NoError(t, registry.Register(`Host("payments") && Path("/charge")`, MockResponse{Status: http.StatusCreated}))
+	m.SetMocks(nil)
+	assert.Equal(t, http.StatusBadGateway, serve("payments", "/charge").header)
+}
@@ -0,0 +1,60 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Mount adds a route for the prefix expression dispatching the requests to the sub mux, so routing tables
+// can be composed from independently built modules. The prefix expression usually ends the path with a
+// <path:name> pattern, e.g. `Host("api.example.com") && Path("/admin/<path:rest>")`. When strip is true,
+// the static path before the <path:name> pattern is removed from the request path before dispatch,
+// so the sub mux routes /admin/users as /users.
+func (m *Mux) Mount(prefixExpr string, sub *Mux, strip bool) error {
+	if sub == nil || sub == m {
+		return errors.New("sub mux cannot be nil or the mux itself: operation rejected")
+	}
+	if !strip {
+		return m.Handle(prefixExpr, sub)
+	}
+
+	ts, err := terms(prefixExpr)
+	if err != nil {
+		return err
+	}
+	path := termArg(ts, "Path")
+	i := strings.Index(path, "<path:")
+	if i == -1 {
+		return fmt.Errorf("stripping the prefix of '%s' requires a Path matcher ending with a <path:name> pattern", prefixExpr)
+	}
+	prefix := strings.TrimSuffix(path[:i], "/")
+	if strings.Contains(prefix, "<") {
+		return fmt.Errorf("the prefix of '%s' to strip should be static, got: %s", prefixExpr, prefix)
+	}
+	return m.Handle(prefixExpr, stripPrefix(prefix, sub))
+}
+
+// stripPrefix removes the prefix from the path of the requests before calling the handler
+func stripPrefix(prefix string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u := *r.URL
+		u.Path = ensureLeadingSlash(strings.TrimPrefix(u.Path, prefix))
+		if u.RawPath != "" {
+			u.RawPath = ensureLeadingSlash(strings.TrimPrefix(u.RawPath, prefix))
+		}
+
+		stripped := r.WithContext(r.Context())
+		stripped.URL = &u
+		stripped.RequestURI = u.RequestURI()
+		h.ServeHTTP(w, stripped)
+	})
+}
+
+func ensureLeadingSlash(path string) string {
+	if strings.HasPrefix(path, "/") {
+		return path
+	}
+	return "/" + path
+}
@@ -0,0 +1,51 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMount(t *testing.T) {
+	var path, uri string
+	record := func(w http.ResponseWriter, r *http.Request) {
+		path, uri = r.URL.Path, r.RequestURI
+		w.WriteHeader(http.StatusOK)
+	}
+
+	admin := NewMux()
+	require.NoError(t, admin.HandleFunc(`Path("/users/<name>")`, record))
+
+	m := NewMux()
+	require.NoError(t, m.Mount(`Host("api") && Path("/admin/<path:rest>")`, admin, true))
+
+	serve := func(host, url string) int {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: url, host: host}))
+		return w.header
+	}
+
+	assert.Equal(t, http.StatusOK, serve("api", "/admin/users/bob?x=1"))
+	assert.Equal(t, "/users/bob", path)
+	assert.Equal(t, "/users/bob?x=1", uri)
+
+	assert.Equal(t, http.StatusOK, serve("api", "/admin/users/a%2Fb"))
+	assert.Equal(t, "/users/a%2Fb", uri)
+
+	assert.Equal(t, http.StatusNotFound, serve("api", "/admin/orders"))
+	assert.Equal(t, http.StatusNotFound, serve("other", "/admin/users/bob"))
+
+	// without stripping, the sub mux sees the full path
+	full := NewMux()
+	require.NoError(t, full.HandleFunc(`Path("/legacy/<name>")`, record))
+	require.NoError(t, m.Mount(`Path("/legacy/<path:rest>")`, full, false))
+	assert.Equal(t, http.StatusOK, serve("", "/legacy/x"))
+	assert.Equal(t, "/legacy/x", path)
+
+	assert.Error(t, m.Mount(`Path("/a")`, admin, true))
+	assert.Error(t, m.Mount(`Path("/<v>/<path:rest>")`, admin, true))
+	assert.Error(t, m.Mount(`Path("/a/<path:rest>")`, nil, false))
+	assert.Error(t, m.Mount(`Path("/a/<path:rest>")`, m, false))
+}
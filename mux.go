@@ -3,18 +3,190 @@ package route
 import (
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"maps"
 	"net/http"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
-// Mux implements router compatible with http.Handler
+// Mux implements router compatible with http.Handler.
+// Routes can be added, replaced and removed while the mux is serving requests: mutations are applied
+// to a copy of the router which is swapped atomically, so ServeHTTP never waits for them.
+// The mux settings, e.g. SetNotFound or SetHeadFallback, should be set before serving requests.
 type Mux struct {
 	// NotFound sets handler for routes that are not found
 	notFound http.Handler
-	router   Router
-	aliases  []alias
+	// router is the current route table, it is never modified once stored, see update
+	router  atomic.Pointer[router]
+	aliases []alias
+	// mutex serializes the mutations and guards the route bookkeeping below
+	mutex sync.RWMutex
+	// keyFunc computes the identity of an expression
+	keyFunc KeyFunc
+	// keys maps route keys to the routes registered for them
+	keys map[string]*entry
+	// sorted holds the registered routes in trie order, see compareEntries
+	sorted []*entry
+	// hash is the XOR of the hashes of the registered expressions
+	hash uint64
+	// subscribers receive the route table mutations
+	subscribers subscribers
+	// accounting enables the route traffic statistics
+	accounting bool
+	// hosts counts the routes per host of their Host matcher
+	hosts map[string]int
+	// hostPatterns counts the routes per Host matcher with a pattern or a wildcard and per HostRegexp matcher,
+	// hostRouter matches them and is rebuilt when they change, see knownHost
+	hostPatterns map[string]int
+	hostRouter   atomic.Pointer[router]
+	// methods counts the routes per method of their Method matcher
+	methods map[string]int
+	// paths indexes the methods of the routes by their Host and Path matchers, see MethodsFor
+	paths *pathIndex
+	// names maps the route names to the route keys, see HandleNamed
+	names map[string]string
+	// resolver is called on misses for unknown hosts
+	resolver   MissResolver
+	resolution missResolution
+	// misses is the negative cache of the resolver
+	misses *missCache
+	// methodNotAllowed handles the requests matching routes for other methods only
+	methodNotAllowed http.Handler
+	// notFoundGuard counts and throttles the requests that did not match any route
+	notFoundGuard notFoundGuard
+	// headFallback serves HEAD requests with the GET routes
+	headFallback bool
+	// autoOptions answers the OPTIONS requests with the methods of the routes, see SetAutoOptions
+	autoOptions bool
+	// queryErrorRenderer answers the requests violating the query schema of their route
+	queryErrorRenderer QueryErrorRenderer
+	// middleware wraps the handlers of the routes added after it is set, see Use
+	middleware []Middleware
+	// mocks serve canned responses instead of the route handlers, see SetMocks
+	mocks *MockRegistry
+	// strict rejects the replacement of routes by Handle, see SetStrict
+	strict bool
+	// restored is set while the route table is the one restored from a snapshot
+	restored bool
+	// churn counts the routes removed or replaced since the last compaction, see SetAutoCompact
+	churn       int
+	autoCompact int
+	// observer is told about every request served, see SetObserver
+	observer Observer
+	// tracer starts a span for every request served, see SetTracer
+	tracer Tracer
+	// background holds the background components, see Run
+	background backgroundState
+	// settings are read by the matchers and the handlers while serving requests, e.g. the clock
+	settings *settings
 }
 
+// entry is a route registered in the mux, it is stored in the router for both
+// the expression and its alias
+type entry struct {
+	expr string
+	// key is the identity of the route computed by the key function of the mux, see SetKeyFunc
+	key     string
+	handler http.Handler
+	// wrapped is the handler wrapped with the middleware of the route
+	wrapped    http.Handler
+	middleware []Middleware
+	// host and path are the arguments of the Host and Path matchers, used for ordering
+	host string
+	path string
+	// method is the argument of the Method matcher, methodRegexp the MethodRegexp matcher expression
+	method       string
+	methodRegexp *regexp.Regexp
+	// pathRegexp is the PathRegexp matcher expression when it has named groups
+	pathRegexp *regexp.Regexp
+	// params is true if the patterns of the route capture params, see Params
+	params bool
+	// stats are shared by the successive entries registered for the same key
+	stats *routeStats
+	// owner is the name of the subsystem owning the route, empty if the route is not protected
+	owner string
+	// name is the name of the route, see HandleNamed
+	name string
+	// alias is the expression rewritten by the aliases of the mux, empty if no alias applies
+	alias string
+	// aliasPatterns are the patterns of the alias capturing params, nil if there is no alias, see capture
+	aliasPatterns *paramPatterns
+	// priority orders the route before the routes of lower priority, see HandleWithPriority
+	priority int
+	// meta is the metadata of the route, see HandleMeta
+	meta map[string]string
+	// matched describes the route to its handler, see RouteFromContext
+	matched *MatchedRoute
+	// signatures verify the signatures of the requests for the SignedWith matchers, see Mux.verifySignatures
+	signatures []signatureVerifier
+	// options are the per-route settings, they are replaced as a whole, see Mux.setOptions
+	options atomic.Pointer[routeOptions]
+}
+
+func newEntry(expr string, handler http.Handler) *entry {
+	e := &entry{expr: expr, handler: handler, wrapped: handler, stats: &routeStats{}}
+	e.options.Store(&routeOptions{})
+	if ts, err := terms(expr); err == nil {
+		e.host = termArg(ts, "Host")
+		e.path = termArg(ts, "Path")
+		e.method = termArg(ts, "Method")
+		if expr := termArg(ts, "MethodRegexp"); expr != "" {
+			e.methodRegexp, _ = regexp.Compile(expr)
+		}
+		e.pathRegexp = namedGroups(termArg(ts, "PathRegexp"))
+	}
+	e.params = paramPatterns{host: e.host, path: e.path, pathRegexp: e.pathRegexp}.captures()
+	e.signatures, _ = signedWith(expr)
+	e.matched = &MatchedRoute{Expr: expr}
+	return e
+}
+
+// clone returns a copy of the entry sharing its handler, its stats and its options, so the settings of
+// a registered route are changed on a copy: the entries are read without the lock by the iterators
+func (e *entry) clone() *entry {
+	c := &entry{
+		expr: e.expr, key: e.key, handler: e.handler, wrapped: e.wrapped, middleware: e.middleware,
+		host: e.host, path: e.path, method: e.method, methodRegexp: e.methodRegexp, pathRegexp: e.pathRegexp, params: e.params,
+		stats: e.stats, owner: e.owner, name: e.name, alias: e.alias, aliasPatterns: e.aliasPatterns, priority: e.priority, meta: e.meta, matched: e.matched,
+		signatures: e.signatures,
+	}
+	c.options.Store(e.options.Load())
+	return c
+}
+
+// setAlias sets the expression rewritten by the aliases of the mux the route is also registered for
+func (e *entry) setAlias(alias string) {
+	patterns := newParamPatterns(alias)
+	e.alias = alias
+	e.aliasPatterns = &patterns
+	e.params = e.params || patterns.captures()
+}
+
+func (e *entry) routePriority() int {
+	return e.priority
+}
+
+// registration holds the settings given when a route is registered, they are not kept when the route is replaced
+type registration struct {
+	priority int
+	meta     map[string]string
+}
+
+func (e *entry) register(reg registration) {
+	e.priority = reg.priority
+	e.meta = maps.Clone(reg.meta)
+	e.matched = &MatchedRoute{Expr: e.expr, Meta: e.meta}
+}
+
+// KeyFunc returns the identity of a route expression,
+// expressions with equal keys are treated as the same route by Handle and Remove.
+type KeyFunc func(expr string) string
+
 type alias struct {
 	match   string
 	replace string
@@ -22,10 +194,44 @@ type alias struct {
 
 // NewMux returns new Mux router
 func NewMux() *Mux {
-	return &Mux{
-		router:   New(),
-		notFound: &notFound{},
+	m := &Mux{
+		notFound:     &notFound{},
+		keyFunc:      CanonicalExpr,
+		keys:         make(map[string]*entry),
+		hosts:        make(map[string]int),
+		hostPatterns: make(map[string]int),
+		methods:      make(map[string]int),
+		paths:        newPathIndex(),
+		names:        make(map[string]string),
+		settings:     &settings{},
 	}
+	m.background.compactions = make(chan struct{}, 1)
+	r := New().(*router)
+	r.settings = m.settings
+	m.router.Store(r)
+	return m
+}
+
+// update applies the mutation to a copy of the router and stores the copy once the mutation succeeded,
+// so requests are routed either by the previous or by the new route table, never by a partial one.
+// The caller holds the lock.
+func (m *Mux) update(fn func(r *router) error) error {
+	r := m.router.Load().clone()
+	if err := fn(r); err != nil {
+		return err
+	}
+	m.router.Store(r)
+	return nil
+}
+
+// SetKeyFunc sets the function used to compute route identity, CanonicalExpr is used by default.
+// It should be called before any routes are added.
+func (m *Mux) SetKeyFunc(fn KeyFunc) error {
+	if fn == nil {
+		return errors.New("key function cannot be nil: operation rejected")
+	}
+	m.keyFunc = fn
+	return nil
 }
 
 // AddAlias adds an alias for matchers in an expression. If the string
@@ -46,34 +252,247 @@ func (m *Mux) applyAliases(expr string) (string, bool) {
 // InitHandlers This adds a map of handlers and expressions in a single call. This allows
 // init to load many rules on first startup, thus reducing the time it takes to
 // create the initial mux.
+// When the handlers are invalid, the returned error is a *ValidationError listing every offending expression.
 func (m *Mux) InitHandlers(handlers map[string]interface{}) error {
-	if len(m.aliases) == 0 {
-		return m.router.InitRoutes(handlers)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.initHandlers(handlers, nil, false)
+}
+
+// initHandlers replaces the routes, the routes get their settings from the registrations keyed by expression.
+// The owned routes are replaced only if ignoreOwners is true.
+func (m *Mux) initHandlers(handlers map[string]interface{}, registrations map[string]registration, ignoreOwners bool) error {
+	if !ignoreOwners {
+		if err := m.checkOwnersLocked(); err != nil {
+			return err
+		}
 	}
 
-	// Apply aliases to routes
-	modified := make(map[string]interface{}, len(handlers))
-	for k, v := range handlers {
+	keys, err := m.entriesFor(handlers)
+	if err != nil {
+		return err
+	}
+	for _, e := range keys {
+		if reg, ok := registrations[e.expr]; ok {
+			e.register(reg)
+		}
+	}
+
+	routes := m.routesFor(keys)
+	err = m.update(func(r *router) error {
+		return r.InitRoutes(routes)
+	})
+	if err != nil {
+		return m.compileError(routes, err)
+	}
+	m.keys = make(map[string]*entry, len(keys))
+	m.sorted = make([]*entry, 0, len(keys))
+	m.hosts = make(map[string]int)
+	m.hostPatterns = make(map[string]int)
+	m.hostRouter.Store(nil)
+	m.methods = make(map[string]int)
+	m.paths = newPathIndex()
+	m.names = make(map[string]string)
+	m.hash = 0
+	m.churn = 0
+	if m.misses != nil {
+		m.misses.clear()
+	}
+	for key, e := range keys {
+		m.track(key, e)
+	}
+	m.restored = false
+	m.notify(MutationReset, "", "")
+	return nil
+}
+
+// checkOwnersLocked returns an error if any route is owned, so the route table can't be replaced as a whole
+func (m *Mux) checkOwnersLocked() error {
+	for _, e := range m.keys {
+		if e.owner != "" {
+			return ownedError(e)
+		}
+	}
+	return nil
+}
+
+// entriesFor creates the entries for the handlers keyed by route key,
+// rejecting expressions sharing the same key. The returned error is a *ValidationError.
+func (m *Mux) entriesFor(handlers map[string]interface{}) (map[string]*entry, error) {
+	exprs := make([]string, 0, len(handlers))
+	for expr := range handlers {
+		exprs = append(exprs, expr)
+	}
+	sort.Strings(exprs)
+
+	report := &ValidationError{}
+	keys := make(map[string]*entry, len(handlers))
+	for _, expr := range exprs {
+		h, ok := handlers[expr].(http.Handler)
+		if !ok {
+			report.add(ProblemHandler, expr, fmt.Errorf("handler for '%s' is %T, not http.Handler", expr, handlers[expr]))
+			continue
+		}
+		key := m.keyFunc(expr)
+		if prev, ok := keys[key]; ok {
+			report.add(ProblemConflict, expr, existsError(prev, expr))
+			continue
+		}
+		e := newEntry(expr, h)
+		e.key = key
+		e.setMiddleware(m.middleware, nil)
+		keys[key] = e
+	}
+	if err := report.errOrNil(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// routesFor returns the router values for the entries, registered for both their expression and their alias
+func (m *Mux) routesFor(keys map[string]*entry) map[string]interface{} {
+	routes := make(map[string]interface{}, len(keys))
+	for _, e := range keys {
 		// If an alias matched, add the modified route to the handlers passed
-		if alias, ok := m.applyAliases(k); ok {
-			modified[alias] = v
+		if alias, ok := m.applyAliases(e.expr); ok {
+			routes[alias] = e
+			e.setAlias(alias)
 		}
-		modified[k] = v
+		routes[e.expr] = e
 	}
-	return m.router.InitRoutes(modified)
+	return routes
 }
 
-// Handle adds http handler for route expression
+// Handle adds http handler for route expression.
+// If an expression with the same key is already registered, it is replaced, unless the mux is strict.
 func (m *Mux) Handle(expr string, handler http.Handler) error {
-	if err := m.router.UpsertRoute(expr, handler); err != nil {
+	return m.handle("", expr, handler)
+}
+
+func (m *Mux) handle(owner, expr string, handler http.Handler, middleware ...Middleware) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.handleLocked(owner, expr, registration{}, handler, middleware...)
+}
+
+// HandleWithPriority adds http handler for route expression with an explicit priority: when several routes
+// match a request, the route with the highest priority wins whatever the matchers of the expressions.
+// Routes of the same priority are ordered like the routes added with Handle, which have the priority 0,
+// and routes with a negative priority are only tried after them.
+func (m *Mux) HandleWithPriority(expr string, handler http.Handler, priority int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.handleLocked("", expr, registration{priority: priority}, handler)
+}
+
+// handleLocked adds the route, the caller holds the lock
+func (m *Mux) handleLocked(owner, expr string, reg registration, handler http.Handler, middleware ...Middleware) error {
+	key, e, err := m.newEntryLocked(owner, expr, reg, handler, middleware...)
+	if err != nil {
+		return err
+	}
+	return m.upsertLocked(key, e)
+}
+
+// newEntryLocked returns the key and the entry of the route, an error if the route can't replace
+// the route registered for the key. The caller holds the lock.
+func (m *Mux) newEntryLocked(owner, expr string, reg registration, handler http.Handler, middleware ...Middleware) (string, *entry, error) {
+	key := m.keyFunc(expr)
+	prev, replaced := m.keys[key]
+	if replaced && prev.owner != owner {
+		return "", nil, ownedError(prev)
+	}
+	if replaced && m.strict {
+		return "", nil, existsError(prev, expr)
+	}
+
+	e := newEntry(expr, handler)
+	e.setMiddleware(m.middleware, middleware)
+	e.owner = owner
+	e.register(reg)
+	return key, e, nil
+}
+
+// upsertLocked adds the entry for the key or replaces the entry registered for the key, the caller holds the lock
+func (m *Mux) upsertLocked(key string, e *entry) error {
+	return m.upsertAllLocked([]string{key}, []*entry{e})
+}
+
+// upsertAllLocked adds or replaces the entries for the keys in a single router update,
+// so either all or none of the entries are routed. The caller holds the lock.
+func (m *Mux) upsertAllLocked(keys []string, entries []*entry) error {
+	prevs := make([]*entry, len(entries))
+	seen := make(map[string]*entry, len(keys))
+	for i, key := range keys {
+		e := entries[i]
+		if other, ok := seen[key]; ok {
+			return existsError(other, e.expr)
+		}
+		seen[key] = e
+		e.key = key
+		if prev, replaced := m.keys[key]; replaced {
+			e.stats = prev.stats
+			e.options.Store(prev.options.Load())
+			e.name = prev.name
+			prevs[i] = prev
+		}
+	}
+	err := m.update(func(r *router) error {
+		for i, e := range entries {
+			if prev := prevs[i]; prev != nil && prev.expr != e.expr {
+				if err := m.remove(r, prev.expr); err != nil {
+					return err
+				}
+			}
+			if err := r.UpsertRoute(e.expr, e); err != nil {
+				return explainError(e.expr, err)
+			}
+			if alias, ok := m.applyAliases(e.expr); ok {
+				if err := r.UpsertRoute(alias, e); err != nil {
+					return fmt.Errorf("while adding alias handler: %s", err)
+				}
+				e.setAlias(alias)
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
+	for i, e := range entries {
+		m.forget(keys[i])
+		m.track(keys[i], e)
+		if prev := prevs[i]; prev != nil {
+			m.notify(MutationReplace, e.expr, prev.expr)
+		} else {
+			m.notify(MutationAdd, e.expr, "")
+		}
+	}
+	return nil
+}
 
-	if alias, ok := m.applyAliases(expr); ok {
-		if err := m.router.UpsertRoute(alias, handler); err != nil {
-			return fmt.Errorf("while adding alias handler: %s", err)
+// swapLocked replaces the entry registered for the key with a copy updated by fn, without notifying
+// the subscribers as the route still matches and serves the same requests. The caller holds the lock.
+func (m *Mux) swapLocked(key string, fn func(e *entry)) error {
+	e := m.keys[key].clone()
+	fn(e)
+	err := m.update(func(r *router) error {
+		if err := r.UpsertRoute(e.expr, e); err != nil {
+			return err
 		}
+		if e.alias != "" {
+			return r.UpsertRoute(e.alias, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	m.forget(key)
+	m.track(key, e)
 	return nil
 }
 
@@ -82,27 +501,187 @@ func (m *Mux) HandleFunc(expr string, handler func(http.ResponseWriter, *http.Re
 	return m.Handle(expr, http.HandlerFunc(handler))
 }
 
+// Remove removes the route registered for the expression or any expression with the same key
 func (m *Mux) Remove(expr string) error {
-	if err := m.router.RemoveRoute(expr); err != nil {
+	return m.removeOwned("", expr)
+}
+
+func (m *Mux) removeOwned(owner, expr string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := m.keyFunc(expr)
+	registered, ok := m.keys[key]
+	if ok && registered.owner != owner {
+		return ownedError(registered)
+	}
+	if ok {
+		expr = registered.expr
+	}
+	err := m.update(func(r *router) error {
+		return m.remove(r, expr)
+	})
+	if err != nil {
 		return err
 	}
+	m.forget(key)
+	if ok {
+		m.notify(MutationRemove, expr, "")
+	}
+	return nil
+}
 
-	if alias, ok := m.applyAliases(expr); ok {
-		if err := m.router.RemoveRoute(alias); err != nil {
+// remove removes the routes for the expressions and their aliases from the router
+func (m *Mux) remove(r *router, exprs ...string) error {
+	if err := r.removeRoutes(exprs...); err != nil {
+		return err
+	}
+
+	var aliases []string
+	for _, expr := range exprs {
+		if alias, ok := m.applyAliases(expr); ok {
+			aliases = append(aliases, alias)
+		}
+	}
+	if len(aliases) != 0 {
+		if err := r.removeRoutes(aliases...); err != nil {
 			return fmt.Errorf("while removing alias handler: %s", err)
 		}
 	}
 	return nil
 }
 
+// track adds the route registered for the key to the mux bookkeeping
+func (m *Mux) track(key string, e *entry) {
+	m.keys[key] = e
+	i, _ := slices.BinarySearchFunc(m.sorted, e, compareEntries)
+	m.sorted = slices.Insert(m.sorted, i, e)
+	m.hash ^= hashExpr(e.expr)
+	m.trackHost(e, 1)
+	m.trackMethod(e, 1)
+	if e.name != "" {
+		m.names[e.name] = key
+	}
+}
+
+// forget drops the route registered for the key from the mux bookkeeping
+func (m *Mux) forget(key string) {
+	e, ok := m.keys[key]
+	if !ok {
+		return
+	}
+	delete(m.keys, key)
+	m.addChurn()
+	if i, found := slices.BinarySearchFunc(m.sorted, e, compareEntries); found {
+		m.sorted = slices.Delete(m.sorted, i, i+1)
+	}
+	m.hash ^= hashExpr(e.expr)
+	m.trackHost(e, -1)
+	m.trackMethod(e, -1)
+	if e.name != "" {
+		delete(m.names, e.name)
+	}
+}
+
+// Hash returns the hash of the route table, it is updated on every mutation.
+// The hash depends only on the registered expressions, not on their order or handlers,
+// so it can be compared to HashExprs computed by a control plane.
+func (m *Mux) Hash() uint64 {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.hash
+}
+
+// HashExprs returns the hash of a route table made of the given expressions, see Mux.Hash
+func HashExprs(exprs ...string) uint64 {
+	var h uint64
+	for _, expr := range exprs {
+		h ^= hashExpr(expr)
+	}
+	return h
+}
+
+func hashExpr(expr string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(expr))
+	return h.Sum64()
+}
+
 // ServeHTTP routes the request and passes it to handler
 func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	h, err := m.router.Route(r)
-	if err != nil || h == nil {
-		m.notFound.ServeHTTP(w, r)
+	if m.observer != nil || m.tracer != nil {
+		m.serveInstrumented(w, r)
+		return
+	}
+	m.serve(w, r, nil)
+}
+
+// serve routes the request and passes it to handler, matched is set to the matched route, if any,
+// before the route serves the request
+func (m *Mux) serve(w http.ResponseWriter, r *http.Request, matched **entry) {
+	res := m.router.Load().route(r)
+	if res == nil {
+		var ok bool
+		if res, ok = m.routeHead(r); ok {
+			w = headWriter{ResponseWriter: w}
+		} else if m.servePreflight(w, r) {
+			return
+		} else if m.serveOptions(w, r) {
+			return
+		} else if res, ok = m.resolveMiss(r); !ok {
+			if !m.serveMethodNotAllowed(w, r) {
+				m.serveNotFound(w, r)
+			}
+			return
+		}
+	}
+	e := res.(*entry)
+	if matched != nil {
+		*matched = e
+	}
+	if e.params {
+		r = e.withParams(r)
+	}
+	r = m.settings.withClock(e.withRoute(r))
+	options := e.options.Load()
+	if options.disabled {
+		m.serveNotFound(w, r)
+		return
+	}
+	if c := options.cors; c != nil {
+		if isPreflight(r) {
+			c.preflight(w, r)
+			return
+		}
+		c.apply(w, r)
+	}
+	if d := options.deprecation; d != nil && !d.apply(w, m.settings.getClock()) {
+		http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+		return
+	}
+	if len(options.query) != 0 {
+		if err := validateQuery(r, options.query); err != nil {
+			m.renderQueryError(w, r, err)
+			return
+		}
+	}
+	if len(e.signatures) != 0 && !m.verifySignatures(w, r, e) {
+		return
+	}
+	r = options.apply(w, r)
+	h := e.wrapped
+	if m.mocks != nil {
+		if mock, ok := m.mocks.handler(e.expr); ok {
+			h = wrapMiddleware(mock, e.middleware)
+		}
+	}
+	h = options.handler(h)
+	if m.accounting {
+		e.serveAccounted(w, r, h)
 		return
 	}
-	h.(http.Handler).ServeHTTP(w, r)
+	h.ServeHTTP(w, r)
 }
 
 func (m *Mux) SetNotFound(n http.Handler) error {
@@ -2,7 +2,9 @@ package route
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -152,6 +154,118 @@ func (s *MuxSuite) TestAddAliasOrder() {
 	s.Equal(http.StatusCreated, w.header)
 }
 
+func (s *MuxSuite) TestCanonicalKey() {
+	r := NewMux()
+
+	s.Require().NoError(r.Handle(`Host("localhost") && Path("/p")`, newStatusHandler(http.StatusCreated)))
+	s.Require().NoError(r.Handle(`Host( "localhost" )&&Path("/p")`, newStatusHandler(http.StatusAccepted)))
+
+	w := newWriter()
+	r.ServeHTTP(w, makeReq(req{url: "/p", host: "localhost"}))
+	s.Equal(http.StatusAccepted, w.header)
+	s.Len(r.keys, 1)
+
+	s.Require().NoError(r.Remove("Host(`localhost`) && Path(`/p`)"))
+
+	w = newWriter()
+	r.ServeHTTP(w, makeReq(req{url: "/p", host: "localhost"}))
+	s.Equal(http.StatusNotFound, w.header)
+}
+
+func (s *MuxSuite) TestCustomKeyFunc() {
+	r := NewMux()
+	s.Require().Error(r.SetKeyFunc(nil))
+	s.Require().NoError(r.SetKeyFunc(func(expr string) string {
+		return "one"
+	}))
+
+	s.Require().NoError(r.Handle(`Path("/a")`, newStatusHandler(http.StatusCreated)))
+	s.Require().NoError(r.Handle(`Path("/b")`, newStatusHandler(http.StatusAccepted)))
+
+	w := newWriter()
+	r.ServeHTTP(w, makeReq(req{url: "/a"}))
+	s.Equal(http.StatusNotFound, w.header)
+
+	w = newWriter()
+	r.ServeHTTP(w, makeReq(req{url: "/b"}))
+	s.Equal(http.StatusAccepted, w.header)
+
+	err := r.InitHandlers(map[string]interface{}{
+		`Path("/a")`: newStatusHandler(http.StatusCreated),
+		`Path("/b")`: newStatusHandler(http.StatusCreated),
+	})
+	s.Require().Error(err)
+}
+
+func (s *MuxSuite) TestHash() {
+	r := NewMux()
+	s.Equal(uint64(0), r.Hash())
+
+	s.Require().NoError(r.Handle(`Path("/a")`, newStatusHandler(http.StatusOK)))
+	s.Require().NoError(r.Handle(`Path("/b")`, newStatusHandler(http.StatusOK)))
+	s.Equal(HashExprs(`Path("/b")`, `Path("/a")`), r.Hash())
+
+	// replacing the handler keeps the hash
+	hash := r.Hash()
+	s.Require().NoError(r.Handle(`Path("/b")`, newStatusHandler(http.StatusCreated)))
+	s.Equal(hash, r.Hash())
+
+	s.Require().NoError(r.Remove(`Path("/b")`))
+	s.Equal(HashExprs(`Path("/a")`), r.Hash())
+
+	s.Require().NoError(r.InitHandlers(map[string]interface{}{
+		`Path("/c")`: newStatusHandler(http.StatusOK),
+		`Path("/d")`: newStatusHandler(http.StatusOK),
+	}))
+	s.Equal(HashExprs(`Path("/c")`, `Path("/d")`), r.Hash())
+}
+
+func (s *MuxSuite) TestConcurrentMutations() {
+	r := NewMux()
+	s.Require().NoError(r.Handle(`Path("/stable")`, newStatusHandler(http.StatusOK)))
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				w := newWriter()
+				r.ServeHTTP(w, makeReq(req{url: "/stable"}))
+				if w.header != http.StatusOK {
+					s.Failf("stable route not served", "got status %d", w.header)
+					return
+				}
+				r.ServeHTTP(newWriter(), makeReq(req{url: "/volatile/1"}))
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		expr := fmt.Sprintf(`Path("/volatile/%d")`, i%3)
+		s.Require().NoError(r.Handle(expr, newStatusHandler(http.StatusCreated)))
+		s.Require().NoError(r.SetAltSvc(expr, `h3=":443"`))
+		s.Require().NoError(r.Remove(expr))
+	}
+	s.Require().NoError(r.InitHandlers(map[string]interface{}{
+		`Path("/stable")`: newStatusHandler(http.StatusOK),
+	}))
+	close(done)
+	wg.Wait()
+}
+
+func newStatusHandler(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	})
+}
+
 type testWriter struct {
 	header  int
 	buf     *bytes.Buffer
@@ -180,3 +294,54 @@ func (w *testWriter) WriteString(s string) (n int, err error) {
 func (w *testWriter) WriteHeader(h int) {
 	w.header = h
 }
+
+func (s *MuxSuite) TestHandleWithPriority() {
+	r := NewMux()
+	status := func(code int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(code)
+		})
+	}
+	serve := func(url string, headers http.Header) int {
+		w := newWriter()
+		r.ServeHTTP(w, makeReq(req{url: url, headers: headers}))
+		return w.header
+	}
+
+	// by default the Path route is tried before the Header route
+	s.Require().NoError(r.Handle(`Path("/api")`, status(http.StatusOK)))
+	s.Require().NoError(r.Handle(`Header("X-Beta", "1") && Path("/api")`, status(http.StatusAccepted)))
+	s.Equal(http.StatusOK, serve("/api", http.Header{"X-Beta": {"1"}}))
+
+	s.Require().NoError(r.HandleWithPriority(`Header("X-Beta", "1") && Path("/api")`, status(http.StatusAccepted), 10))
+	s.Equal(http.StatusAccepted, serve("/api", http.Header{"X-Beta": {"1"}}))
+	s.Equal(http.StatusOK, serve("/api", nil))
+
+	// merged tries prefer the most specific route, priorities win over it
+	s.Require().NoError(r.Handle(`Path("/users/me")`, status(http.StatusOK)))
+	s.Require().NoError(r.Handle(`Path("/users/<id>")`, status(http.StatusAccepted)))
+	s.Equal(http.StatusOK, serve("/users/me", nil))
+	s.Require().NoError(r.HandleWithPriority(`Path("/users/<id>")`, status(http.StatusAccepted), 1))
+	s.Equal(http.StatusAccepted, serve("/users/me", nil))
+
+	// negative priorities are tried after the routes added with Handle
+	s.Require().NoError(r.HandleWithPriority(`PathRegexp("/.*")`, status(http.StatusNotImplemented), -1))
+	s.Equal(http.StatusOK, serve("/api", nil))
+	s.Equal(http.StatusNotImplemented, serve("/other", nil))
+
+	// priorities are restored from snapshots
+	snapshot := r.Snapshot()
+	restored := NewMux()
+	s.Require().NoError(restored.Restore(snapshot, nil))
+	w := newWriter()
+	restored.ServeHTTP(w, makeReq(req{url: "/users/me"}))
+	s.Equal(http.StatusAccepted, w.header)
+
+	results, err := Simulate(snapshot, snapshot, []SyntheticRequest{{URL: "http://localhost/users/me"}})
+	s.Require().NoError(err)
+	s.Equal(`Path("/users/<id>")`, results[0].Current)
+
+	// Handle resets the priority
+	s.Require().NoError(r.Handle(`Path("/users/<id>")`, status(http.StatusAccepted)))
+	s.Equal(http.StatusOK, serve("/users/me", nil))
+}
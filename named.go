@@ -0,0 +1,129 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HandleNamed adds http handler for route expression like Handle and registers the route under the name,
+// so its URL can be built with URL. Names are unique: a name can't be given to another route.
+func (m *Mux) HandleNamed(name, expr string, handler http.Handler) error {
+	if name == "" {
+		return errors.New("route name cannot be empty: operation rejected")
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := m.keyFunc(expr)
+	if registered, ok := m.names[name]; ok && registered != key {
+		return fmt.Errorf("route name %s is already used by '%s'", name, m.keys[registered].expr)
+	}
+	if err := m.handleLocked("", expr, registration{}, handler); err != nil {
+		return err
+	}
+	return m.nameLocked(key, name)
+}
+
+// nameLocked registers the route of the key under the name, the caller holds the lock and checked the name is free
+func (m *Mux) nameLocked(key, name string) error {
+	if m.keys[key].name == name {
+		return nil
+	}
+	return m.swapLocked(key, func(e *entry) {
+		e.name = name
+	})
+}
+
+// URL builds the URL of the route registered under the name from the Host and Path patterns
+// of its expression, params are pairs of param names and values, e.g. URL("user", "id", "42")
+// for Path("/users/<int:id>"). The host is set only if the expression has a Host matcher,
+// the scheme is left to the caller. Routes without a Path matcher can't be reversed.
+func (m *Mux) URL(name string, params ...string) (*url.URL, error) {
+	m.mutex.RLock()
+	key, ok := m.names[name]
+	e := m.keys[key]
+	m.mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("route %s not found", name)
+	}
+	if e.path == "" {
+		return nil, fmt.Errorf("route %s has no Path matcher", name)
+	}
+	if strings.HasPrefix(e.host, "*.") {
+		return nil, fmt.Errorf("route %s has a wildcard Host matcher", name)
+	}
+	if len(params)%2 != 0 {
+		return nil, errors.New("params should be pairs of names and values")
+	}
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	u := &url.URL{}
+	var err error
+	if e.host != "" {
+		if u.Host, err = expandPattern(e.host, values, nil); err != nil {
+			return nil, fmt.Errorf("route %s: %w", name, err)
+		}
+	}
+	if u.Path, err = expandPattern(e.path, values, nil); err != nil {
+		return nil, fmt.Errorf("route %s: %w", name, err)
+	}
+	if u.RawPath, err = expandPattern(e.path, values, url.PathEscape); err != nil {
+		return nil, fmt.Errorf("route %s: %w", name, err)
+	}
+	if u.RawPath == u.Path {
+		u.RawPath = ""
+	}
+	return u, nil
+}
+
+// expandPattern replaces the params of the pattern with their values, escaped with escape when set.
+// <path:name> values are escaped segment by segment.
+func expandPattern(pattern string, values map[string]string, escape func(string) string) (string, error) {
+	if escape == nil {
+		escape = func(s string) string { return s }
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(pattern); {
+		m, next, err := parsePatternMatcher(i, pattern)
+		if err != nil {
+			return "", err
+		}
+		if m == nil {
+			b.WriteByte(pattern[i])
+			i++
+			continue
+		}
+
+		value, ok := values[m.getName()]
+		if !ok {
+			return "", fmt.Errorf("missing param %s", m.getName())
+		}
+		switch m.(type) {
+		case *pathMatcher:
+			segments := strings.Split(value, "/")
+			for j, s := range segments {
+				segments[j] = escape(s)
+			}
+			value = strings.Join(segments, "/")
+		case *intMatcher:
+			if value == "" || strings.Trim(value, "0123456789") != "" {
+				return "", fmt.Errorf("param %s should be an integer, got: %s", m.getName(), value)
+			}
+		default:
+			if value == "" {
+				return "", fmt.Errorf("param %s cannot be empty", m.getName())
+			}
+			value = escape(value)
+		}
+		b.WriteString(value)
+		i = next
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,66 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURL(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleNamed("file", `Host("<tenant>.example.com") && Path("/users/<int:id>/files/<path:file>")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.HandleNamed("search", `Method("GET") && Path("/search/<term>")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.HandleNamed("static", `PathRegexp("/static/.*")`, newStatusHandler(http.StatusOK)))
+
+	u, err := m.URL("file", "tenant", "acme", "id", "42", "file", "docs/a b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "acme.example.com", u.Host)
+	assert.Equal(t, "/users/42/files/docs/a b.txt", u.Path)
+	u.Scheme = "https"
+	assert.Equal(t, "https://acme.example.com/users/42/files/docs/a%20b.txt", u.String())
+
+	u, err = m.URL("search", "term", "a/b")
+	require.NoError(t, err)
+	assert.Equal(t, "/search/a%2Fb", u.String())
+
+	// the URL matches the route
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: u.String(), method: http.MethodGet}))
+	assert.Equal(t, http.StatusOK, w.header)
+
+	_, err = m.URL("file", "tenant", "acme", "id", "x", "file", "a")
+	assert.Error(t, err)
+	_, err = m.URL("search")
+	assert.Error(t, err)
+	_, err = m.URL("search", "term")
+	assert.Error(t, err)
+	_, err = m.URL("static")
+	assert.Error(t, err)
+	_, err = m.URL("unknown")
+	assert.Error(t, err)
+}
+
+func TestHandleNamed(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleNamed("a", `Path("/a")`, newStatusHandler(http.StatusOK)))
+	assert.Error(t, m.HandleNamed("a", `Path("/b")`, newStatusHandler(http.StatusOK)))
+	assert.Error(t, m.HandleNamed("", `Path("/b")`, newStatusHandler(http.StatusOK)))
+
+	// names are kept when the route is replaced
+	require.NoError(t, m.Handle(`Path( "/a" )`, newStatusHandler(http.StatusAccepted)))
+	u, err := m.URL("a")
+	require.NoError(t, err)
+	assert.Equal(t, "/a", u.Path)
+
+	// and can be changed
+	require.NoError(t, m.HandleNamed("renamed", `Path("/a")`, newStatusHandler(http.StatusOK)))
+	_, err = m.URL("a")
+	assert.Error(t, err)
+
+	require.NoError(t, m.Remove(`Path("/a")`))
+	_, err = m.URL("renamed")
+	assert.Error(t, err)
+	require.NoError(t, m.HandleNamed("a", `Path("/b")`, newStatusHandler(http.StatusOK)))
+}
@@ -0,0 +1,113 @@
+package route
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// missCache is a bounded cache of the hosts the miss resolver failed to resolve,
+// entries expire after their ttl and the least recently added entries are evicted first
+type missCache struct {
+	mutex sync.Mutex
+	size  int
+	// ttl is the ttl of the hosts still missing once resolved, errorTTL the ttl of the hosts the resolver failed on
+	ttl      time.Duration
+	errorTTL time.Duration
+	// order holds the cached hosts from the oldest to the newest
+	order   *list.List
+	entries map[string]*list.Element
+	// settings are the settings of the Mux, read for its clock
+	settings *settings
+}
+
+type missEntry struct {
+	host    string
+	expires time.Time
+}
+
+func newMissCache(size int, ttl, errorTTL time.Duration, s *settings) *missCache {
+	return &missCache{
+		size:     size,
+		ttl:      ttl,
+		errorTTL: errorTTL,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element, size),
+		settings: s,
+	}
+}
+
+// contains returns true if the host missed recently
+func (c *missCache) contains(host string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	el, ok := c.entries[host]
+	if !ok {
+		return false
+	}
+	if c.settings.getClock().Now().After(el.Value.(*missEntry).expires) {
+		c.order.Remove(el)
+		delete(c.entries, host)
+		return false
+	}
+	return true
+}
+
+// add caches the host for the ttl
+func (c *missCache) add(host string, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if el, ok := c.entries[host]; ok {
+		c.order.Remove(el)
+	}
+	for c.order.Len() >= c.size {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*missEntry).host)
+	}
+	c.entries[host] = c.order.PushBack(&missEntry{host: host, expires: c.settings.getClock().Now().Add(ttl)})
+}
+
+func (c *missCache) remove(host string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if el, ok := c.entries[host]; ok {
+		c.order.Remove(el)
+		delete(c.entries, host)
+	}
+}
+
+func (c *missCache) clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element, c.size)
+}
+
+// SetMissCache enables a negative cache for the miss resolver: hosts that still miss after being
+// resolved are not resolved again until the ttl expires or a route for the host is added, and hosts
+// the resolver failed on are not resolved again until the errorTTL expires, so a failing control plane
+// is not called on every request. A zero errorTTL uses the ttl.
+// At most size hosts are cached, a size of zero disables the cache.
+func (m *Mux) SetMissCache(size int, ttl, errorTTL time.Duration) {
+	if size <= 0 || ttl <= 0 {
+		m.misses = nil
+		return
+	}
+	if errorTTL <= 0 {
+		errorTTL = ttl
+	}
+	m.misses = newMissCache(size, ttl, errorTTL, m.settings)
+}
+
+// InvalidateMiss removes the host from the negative cache, so the next miss for the host is resolved again
+func (m *Mux) InvalidateMiss(host string) {
+	if m.misses != nil {
+		m.misses.remove(strings.ToLower(host))
+	}
+}
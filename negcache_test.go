@@ -0,0 +1,96 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissCache(t *testing.T) {
+	c := newMissCache(2, time.Hour, time.Hour, nil)
+
+	c.add("a", c.ttl)
+	c.add("b", c.ttl)
+	assert.True(t, c.contains("a"))
+
+	c.add("c", c.ttl)
+	assert.False(t, c.contains("a"))
+	assert.True(t, c.contains("b"))
+	assert.True(t, c.contains("c"))
+
+	c.remove("b")
+	assert.False(t, c.contains("b"))
+
+	c.clear()
+	assert.False(t, c.contains("c"))
+}
+
+func TestMissCacheExpiration(t *testing.T) {
+	c := newMissCache(2, time.Nanosecond, time.Nanosecond, nil)
+
+	c.add("a", c.ttl)
+	time.Sleep(time.Millisecond)
+	assert.False(t, c.contains("a"))
+	assert.Empty(t, c.entries)
+}
+
+func TestMuxMissCache(t *testing.T) {
+	m := NewMux()
+	m.SetMissCache(10, time.Hour, 0)
+
+	calls := 0
+	m.SetMissResolver(func(mux *Mux, host string) error {
+		calls++
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/", host: "missing"}))
+		assert.Equal(t, http.StatusNotFound, w.header)
+	}
+	assert.Equal(t, 1, calls)
+
+	m.InvalidateMiss("Missing")
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/", host: "missing"}))
+	assert.Equal(t, 2, calls)
+
+	// adding a route for the host invalidates the entry
+	require.NoError(t, m.Handle(`Host("missing") && Path("/a")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Remove(`Host("missing") && Path("/a")`))
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/", host: "missing"}))
+	assert.Equal(t, 3, calls)
+
+	m.SetMissCache(0, time.Hour, 0)
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/", host: "missing"}))
+	assert.Equal(t, 4, calls)
+}
+
+func TestMuxMissCacheErrors(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMux()
+	m.SetClock(clock)
+	m.SetMissCache(10, time.Hour, time.Minute)
+
+	calls := 0
+	m.SetMissResolver(func(mux *Mux, host string) error {
+		calls++
+		return errors.New("control plane unavailable")
+	})
+
+	for i := 0; i < 3; i++ {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/", host: "tenant"}))
+		assert.Equal(t, http.StatusNotFound, w.header)
+	}
+	assert.Equal(t, 1, calls)
+
+	// errors expire after their own ttl
+	clock.advance(2 * time.Minute)
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/", host: "tenant"}))
+	assert.Equal(t, 2, calls)
+}
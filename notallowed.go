@@ -0,0 +1,40 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// SetMethodNotAllowed sets the handler of the requests whose path matches routes for other methods only,
+// it is called with the Allow header set to the methods of these routes, see MethodsFor.
+// A nil handler disables it, such requests are then handled by the not found handler.
+func (m *Mux) SetMethodNotAllowed(h http.Handler) {
+	m.methodNotAllowed = h
+}
+
+// MethodNotAllowed is a http.Handler answering with a plain text 405 Method Not Allowed
+var MethodNotAllowed http.Handler = methodNotAllowed{}
+
+type methodNotAllowed struct{}
+
+func (methodNotAllowed) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusMethodNotAllowed)
+	_, _ = fmt.Fprint(w, http.StatusText(http.StatusMethodNotAllowed))
+}
+
+// serveMethodNotAllowed serves the method not allowed handler if the path matches routes for other methods
+func (m *Mux) serveMethodNotAllowed(w http.ResponseWriter, r *http.Request) bool {
+	if m.methodNotAllowed == nil {
+		return false
+	}
+	methods := m.methodsFor(r)
+	if len(methods) == 0 || slices.Contains(methods, r.Method) {
+		return false
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	m.methodNotAllowed.ServeHTTP(w, r)
+	return true
+}
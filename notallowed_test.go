@@ -0,0 +1,38 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodNotAllowed(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Method("GET") && Path("/users/<id>")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Method("DELETE") && Path("/users/<id>")`, newStatusHandler(http.StatusOK)))
+
+	serve := func(method, url string) *testWriter {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: url, method: method}))
+		return w
+	}
+
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/users/42").header)
+
+	m.SetMethodNotAllowed(MethodNotAllowed)
+	w := serve(http.MethodPost, "/users/42")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.header)
+	assert.Equal(t, "DELETE, GET", w.Header().Get("Allow"))
+	assert.Equal(t, "Method Not Allowed", w.buf.String())
+
+	w = serve(http.MethodPost, "/orders/42")
+	assert.Equal(t, http.StatusNotFound, w.header)
+	assert.Empty(t, w.Header().Get("Allow"))
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodDelete, "/users/42").header)
+
+	m.SetMethodNotAllowed(nil)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/users/42").header)
+}
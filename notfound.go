@@ -0,0 +1,118 @@
+package route
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxNotFoundPrefixes bounds the number of prefixes counted by NotFoundStats,
+// requests for other prefixes are counted under OtherPrefix
+const maxNotFoundPrefixes = 1024
+
+// OtherPrefix is the NotFoundStats prefix counting requests once too many prefixes were seen
+const OtherPrefix = "<other>"
+
+// NotFoundStats are the statistics of the requests that did not match any route
+type NotFoundStats struct {
+	// Total is the number of requests that did not match any route
+	Total int64
+	// Throttled is the number of requests that got the static response because of the limit
+	Throttled int64
+	// ByPrefix counts requests by host and first path segment, e.g. "example.com/wp-admin"
+	ByPrefix map[string]int64
+}
+
+// notFoundGuard counts the not found requests and throttles the not found handler with a token bucket
+type notFoundGuard struct {
+	mutex sync.Mutex
+	stats NotFoundStats
+
+	// rate is the number of tokens added per second, zero disables throttling
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// SetNotFoundLimit limits the requests served by the not found handler to rate per second,
+// with bursts of up to burst requests. Requests above the limit get a static 404 response
+// without calling the handler, protecting logging and metrics pipelines from scanner traffic.
+// A zero rate removes the limit.
+func (m *Mux) SetNotFoundLimit(rate float64, burst int) {
+	m.notFoundGuard.mutex.Lock()
+	defer m.notFoundGuard.mutex.Unlock()
+
+	m.notFoundGuard.rate = rate
+	m.notFoundGuard.burst = float64(max(burst, 1))
+	m.notFoundGuard.tokens = m.notFoundGuard.burst
+	m.notFoundGuard.last = m.settings.getClock().Now()
+}
+
+// NotFoundStats returns the statistics of the requests that did not match any route
+func (m *Mux) NotFoundStats() NotFoundStats {
+	m.notFoundGuard.mutex.Lock()
+	defer m.notFoundGuard.mutex.Unlock()
+
+	stats := m.notFoundGuard.stats
+	stats.ByPrefix = make(map[string]int64, len(m.notFoundGuard.stats.ByPrefix))
+	for k, v := range m.notFoundGuard.stats.ByPrefix {
+		stats.ByPrefix[k] = v
+	}
+	return stats
+}
+
+// serveNotFound passes the request to the not found handler unless it is throttled
+func (m *Mux) serveNotFound(w http.ResponseWriter, r *http.Request) {
+	if m.notFoundGuard.allow(notFoundPrefix(r), m.settings.getClock()) {
+		m.notFound.ServeHTTP(w, r)
+		return
+	}
+	staticNotFound(w)
+}
+
+func (g *notFoundGuard) allow(prefix string, clock Clock) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.stats.Total++
+	if g.stats.ByPrefix == nil {
+		g.stats.ByPrefix = make(map[string]int64)
+	}
+	if _, ok := g.stats.ByPrefix[prefix]; !ok && len(g.stats.ByPrefix) >= maxNotFoundPrefixes {
+		prefix = OtherPrefix
+	}
+	g.stats.ByPrefix[prefix]++
+
+	if g.rate == 0 {
+		return true
+	}
+
+	now := clock.Now()
+	g.tokens = min(g.burst, g.tokens+now.Sub(g.last).Seconds()*g.rate)
+	g.last = now
+	if g.tokens < 1 {
+		g.stats.Throttled++
+		return false
+	}
+	g.tokens--
+	return true
+}
+
+// notFoundPrefix returns the host and the first path segment of the request
+func notFoundPrefix(r *http.Request) string {
+	path := strings.TrimPrefix(rawPath(r), "/")
+	if i := strings.IndexByte(path, '/'); i != -1 {
+		path = path[:i]
+	}
+	return (&hostMapper{}).mapRequest(r) + "/" + path
+}
+
+var staticNotFoundBody = []byte(http.StatusText(http.StatusNotFound))
+
+// staticNotFound writes a 404 response without any allocation
+func staticNotFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = w.Write(staticNotFoundBody)
+}
@@ -0,0 +1,49 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotFoundLimit(t *testing.T) {
+	m := NewMux()
+
+	calls := 0
+	require.NoError(t, m.SetNotFound(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusGone)
+	})))
+	m.SetNotFoundLimit(0.001, 2)
+
+	var statuses []int
+	for i := 0; i < 4; i++ {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/wp-admin/login.php", host: "example.com"}))
+		statuses = append(statuses, w.header)
+	}
+	assert.Equal(t, []int{http.StatusGone, http.StatusGone, http.StatusNotFound, http.StatusNotFound}, statuses)
+	assert.Equal(t, 2, calls)
+
+	m.SetNotFoundLimit(0, 0)
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/.env", host: "example.com:443"}))
+	assert.Equal(t, http.StatusGone, w.header)
+
+	assert.Equal(t, NotFoundStats{
+		Total:     5,
+		Throttled: 2,
+		ByPrefix:  map[string]int64{"example.com/wp-admin": 4, "example.com/.env": 1},
+	}, m.NotFoundStats())
+}
+
+func TestNotFoundStatsBounded(t *testing.T) {
+	var g notFoundGuard
+	for i := 0; i < maxNotFoundPrefixes+10; i++ {
+		g.allow(string(rune('a'+i%26))+string(rune(i)), systemClock{})
+	}
+	assert.Len(t, g.stats.ByPrefix, maxNotFoundPrefixes+1)
+	assert.Equal(t, int64(10), g.stats.ByPrefix[OtherPrefix])
+}
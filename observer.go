@@ -0,0 +1,56 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Observer is told about every request served by the Mux, e.g. to count the requests and measure
+// their latency per route without wrapping every handler
+type Observer interface {
+	// ObserveRequest is called once the request is served with the expression of the matched route,
+	// empty if no route matched, the status of the response and the time taken to serve it
+	ObserveRequest(r *http.Request, expr string, status int, duration time.Duration)
+}
+
+// SetObserver sets the observer told about the requests served by the mux, nil removes it
+func (m *Mux) SetObserver(o Observer) {
+	m.observer = o
+}
+
+// serveInstrumented serves the request within the span of the tracer and tells the observer about it.
+// The span is ended and the observer told in a deferred call, so the requests whose handler panics
+// are reported with the status 500 before the panic is propagated.
+func (m *Mux) serveInstrumented(w http.ResponseWriter, r *http.Request) {
+	clock := m.settings.getClock()
+	start := clock.Now()
+	observed := r
+	var span Span
+	if m.tracer != nil {
+		var ctx context.Context
+		ctx, span = m.tracer.Start(r.Context(), r)
+		r = r.WithContext(ctx)
+	}
+	sw := newStatusWriter(w)
+	var e *entry
+	completed := false
+	defer func() {
+		status := sw.Status()
+		if !completed && sw.status == 0 {
+			status = http.StatusInternalServerError
+		}
+		var expr string
+		if e != nil {
+			expr = e.expr
+		}
+		if span != nil {
+			span.End(TraceResult{Expr: expr, Matched: e != nil, Status: status})
+		}
+		if m.observer != nil {
+			m.observer.ObserveRequest(observed, expr, status, clock.Now().Sub(start))
+		}
+	}()
+	m.serve(sw, r, &e)
+	completed = true
+}
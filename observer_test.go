@@ -0,0 +1,111 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type observation struct {
+	expr     string
+	status   int
+	duration time.Duration
+}
+
+type recordingObserver []observation
+
+func (o *recordingObserver) ObserveRequest(_ *http.Request, expr string, status int, duration time.Duration) {
+	*o = append(*o, observation{expr: expr, status: status, duration: duration})
+}
+
+func TestObserver(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMux()
+	m.SetClock(clock)
+	observed := &recordingObserver{}
+	m.SetObserver(observed)
+	require.NoError(t, m.HandleFunc(`Path("/slow")`, func(w http.ResponseWriter, r *http.Request) {
+		clock.advance(250 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	require.NoError(t, m.HandleFunc(`Path("/implicit")`, func(w http.ResponseWriter, r *http.Request) {}))
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/slow"}))
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/implicit"}))
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/missing"}))
+
+	assert.Equal(t, &recordingObserver{
+		{expr: `Path("/slow")`, status: http.StatusAccepted, duration: 250 * time.Millisecond},
+		{expr: `Path("/implicit")`, status: http.StatusOK},
+		{status: http.StatusNotFound},
+	}, observed)
+
+	m.SetObserver(nil)
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/slow"}))
+	assert.Len(t, *observed, 3)
+}
+
+func TestObserverPanic(t *testing.T) {
+	tracer := &testTracer{}
+	observed := &recordingObserver{}
+	m := NewMux()
+	m.SetClock(newFakeClock())
+	m.SetTracer(tracer)
+	m.SetObserver(observed)
+	require.NoError(t, m.HandleFunc(`Path("/panic")`, func(http.ResponseWriter, *http.Request) {
+		panic("handler bug")
+	}))
+	require.NoError(t, m.HandleFunc(`Path("/partial")`, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic(http.ErrAbortHandler)
+	}))
+
+	// the panic is propagated once the span is ended and the observer told
+	assert.PanicsWithValue(t, "handler bug", func() {
+		m.ServeHTTP(newWriter(), makeReq(req{url: "/panic"}))
+	})
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		m.ServeHTTP(newWriter(), makeReq(req{url: "/partial"}))
+	})
+
+	require.Len(t, tracer.spans, 2)
+	assert.Equal(t, &TraceResult{Expr: `Path("/panic")`, Matched: true, Status: http.StatusInternalServerError}, tracer.spans[0].result)
+	assert.Equal(t, &TraceResult{Expr: `Path("/partial")`, Matched: true, Status: http.StatusAccepted}, tracer.spans[1].result)
+	assert.Equal(t, &recordingObserver{
+		{expr: `Path("/panic")`, status: http.StatusInternalServerError},
+		{expr: `Path("/partial")`, status: http.StatusAccepted},
+	}, observed)
+}
+
+func TestObserverEarlyHints(t *testing.T) {
+	m := NewMux()
+	observed := &recordingObserver{}
+	m.SetObserver(observed)
+	require.NoError(t, m.HandleFunc(`Path("/page")`, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	require.NoError(t, m.SetEarlyHints(`Path("/page")`, "</style.css>; rel=preload; as=style"))
+
+	r := makeReq(req{url: "/page", method: http.MethodGet})
+	r.ProtoMajor, r.ProtoMinor = 1, 1
+	m.ServeHTTP(httptest.NewRecorder(), r)
+	require.Len(t, *observed, 1)
+	assert.Equal(t, http.StatusInternalServerError, (*observed)[0].status)
+}
+
+func TestObserverHijacker(t *testing.T) {
+	m := NewMux()
+	m.SetObserver(&recordingObserver{})
+	require.NoError(t, m.HandleFunc(`Path("/ws")`, func(w http.ResponseWriter, r *http.Request) {
+		_, ok := w.(http.Hijacker)
+		assert.True(t, ok)
+		_, ok = w.(http.Flusher)
+		assert.True(t, ok)
+		w.WriteHeader(http.StatusOK)
+	}))
+	m.ServeHTTP(httptest.NewRecorder(), makeReq(req{url: "/ws", method: http.MethodGet}))
+}
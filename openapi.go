@@ -0,0 +1,149 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OperationResolver returns the handler of the OpenAPI operation, nil if the operation is not implemented
+type OperationResolver func(operationID string) http.Handler
+
+// openAPIDocument holds the parts of an OpenAPI 3 document used for routing
+type openAPIDocument struct {
+	OpenAPI string `yaml:"openapi"`
+	// Paths are the path items by path template, their fields are decoded once known to be operations
+	Paths map[string]map[string]yaml.Node `yaml:"paths"`
+}
+
+type openAPIOperation struct {
+	OperationID string `yaml:"operationId"`
+}
+
+// openAPIMethods maps the operation fields of the OpenAPI path items to the request methods
+var openAPIMethods = map[string]string{
+	"get":     http.MethodGet,
+	"put":     http.MethodPut,
+	"post":    http.MethodPost,
+	"delete":  http.MethodDelete,
+	"options": http.MethodOptions,
+	"head":    http.MethodHead,
+	"patch":   http.MethodPatch,
+	"trace":   http.MethodTrace,
+}
+
+// FromOpenAPI returns a mux routing the operations of the OpenAPI 3 document, see Mux.HandleOpenAPI
+func FromOpenAPI(spec []byte, resolver OperationResolver) (*Mux, error) {
+	m := NewMux()
+	if err := m.HandleOpenAPI(spec, resolver); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// HandleOpenAPI adds a route for every operation of the OpenAPI 3 document, in YAML or JSON, with the handler
+// returned by the resolver for its operationId, e.g. Method("GET") && Path("/users/<id>") for the get
+// operation of /users/{id}. The paths are routed as is, without the base path of the servers, see Mount
+// to serve them under a prefix. Path parameters should span a whole segment. It returns an error if an
+// operation has no operationId, no handler or can't be routed, in which case no route is added.
+func (m *Mux) HandleOpenAPI(spec []byte, resolver OperationResolver) error {
+	if resolver == nil {
+		return errors.New("operation resolver cannot be nil: operation rejected")
+	}
+	var doc openAPIDocument
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return fmt.Errorf("unsupported OpenAPI version %q, expected 3.x", doc.OpenAPI)
+	}
+
+	handlers := make(map[string]http.Handler)
+	var exprs []string
+	for path, item := range doc.Paths {
+		pattern, err := openAPIPattern(path)
+		if err != nil {
+			return err
+		}
+		for field, node := range item {
+			method, ok := openAPIMethods[field]
+			if !ok {
+				// parameters, servers, summary and the other fields of the path item
+				continue
+			}
+			var op openAPIOperation
+			if err := node.Decode(&op); err != nil {
+				return fmt.Errorf("%s %s: invalid operation: %w", method, path, err)
+			}
+			if op.OperationID == "" {
+				return fmt.Errorf("%s %s: operationId is required", method, path)
+			}
+			h := resolver(op.OperationID)
+			if h == nil {
+				return fmt.Errorf("%s %s: no handler for operation %s", method, path, op.OperationID)
+			}
+			expr := fmt.Sprintf("Method(%s) && Path(%s)", strconv.Quote(method), strconv.Quote(pattern))
+			handlers[expr] = h
+			exprs = append(exprs, expr)
+		}
+	}
+	sort.Strings(exprs)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	keys := make([]string, len(exprs))
+	entries := make([]*entry, len(exprs))
+	for i, expr := range exprs {
+		key, e, err := m.newEntryLocked("", expr, registration{}, handlers[expr])
+		if err != nil {
+			return err
+		}
+		keys[i], entries[i] = key, e
+	}
+	return m.upsertAllLocked(keys, entries)
+}
+
+// openAPIPattern converts the OpenAPI path template to a Path pattern, e.g. /users/{id} to /users/<id>.
+// Parameters should span a whole segment, the patterns can't match a part of a segment like {name}.json.
+func openAPIPattern(path string) (string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("path %s should start with /", path)
+	}
+	if strings.ContainsAny(path, "<>") {
+		return "", fmt.Errorf("path %s cannot contain < or >", path)
+	}
+	var b strings.Builder
+	for rest := path; rest != ""; {
+		start := strings.IndexByte(rest, '{')
+		if start == -1 {
+			if strings.ContainsRune(rest, '}') {
+				return "", fmt.Errorf("path %s has an unmatched }", path)
+			}
+			b.WriteString(rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end == -1 {
+			return "", fmt.Errorf("path %s has an unmatched {", path)
+		}
+		name := rest[start+1 : start+end]
+		if name == "" || strings.ContainsAny(name, ":{/") || strings.ContainsRune(rest[:start], '}') {
+			return "", fmt.Errorf("path %s has a malformed parameter %s", path, rest[start:start+end+1])
+		}
+		// the path starts with /, so a parameter at the start of the rest directly follows another parameter
+		after := rest[start+end+1:]
+		if start == 0 || rest[start-1] != '/' || (after != "" && after[0] != '/') {
+			return "", fmt.Errorf("path %s has a parameter %s not spanning a whole segment", path, rest[start:start+end+1])
+		}
+		b.WriteString(rest[:start])
+		b.WriteString("<" + name + ">")
+		rest = after
+	}
+	return b.String(), nil
+}
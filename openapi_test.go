@@ -0,0 +1,130 @@
+package route
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petstore = `
+openapi: 3.0.3
+info:
+  title: Petstore
+  version: 1.0.0
+paths:
+  /pets:
+    summary: Pets
+    get:
+      operationId: listPets
+    post:
+      operationId: createPet
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+    get:
+      operationId: showPet
+`
+
+func TestFromOpenAPI(t *testing.T) {
+	var resolved []string
+	m, err := FromOpenAPI([]byte(petstore), func(operationID string) http.Handler {
+		resolved = append(resolved, operationID)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Operation", operationID)
+			w.Header().Set("X-Pet", Param(r, "petId"))
+		})
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"listPets", "createPet", "showPet"}, resolved)
+
+	var exprs []string
+	for info := range m.Routes() {
+		exprs = append(exprs, info.Expr)
+	}
+	slices.Sort(exprs)
+	assert.Equal(t, []string{
+		`Method("GET") && Path("/pets")`,
+		`Method("GET") && Path("/pets/<petId>")`,
+		`Method("POST") && Path("/pets")`,
+	}, exprs)
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/pets/42", method: http.MethodGet}))
+	assert.Equal(t, "showPet", w.headers.Get("X-Operation"))
+	assert.Equal(t, "42", w.headers.Get("X-Pet"))
+
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/pets", method: http.MethodPost}))
+	assert.Equal(t, "createPet", w.headers.Get("X-Operation"))
+}
+
+func TestFromOpenAPIJSON(t *testing.T) {
+	spec := `{"openapi": "3.1.0", "paths": {"/health": {"get": {"operationId": "health"}}}}`
+	m, err := FromOpenAPI([]byte(spec), func(string) http.Handler { return newStatusHandler(http.StatusNoContent) })
+	require.NoError(t, err)
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/health", method: http.MethodGet}))
+	assert.Equal(t, http.StatusNoContent, w.header)
+}
+
+func TestHandleOpenAPIAtomic(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleOwned("infra", `Method("POST") && Path("/pets")`, newStatusHandler(http.StatusOK)))
+
+	err := m.HandleOpenAPI([]byte(petstore), func(string) http.Handler { return newStatusHandler(http.StatusOK) })
+	require.ErrorIs(t, err, ErrRouteOwned)
+
+	// the operations sorted before the owned route are not added either
+	var exprs []string
+	for info := range m.Routes() {
+		exprs = append(exprs, info.Expr)
+	}
+	assert.Equal(t, []string{`Method("POST") && Path("/pets")`}, exprs)
+}
+
+func TestFromOpenAPIErrors(t *testing.T) {
+	handler := func(string) http.Handler { return newStatusHandler(http.StatusOK) }
+	tcs := []struct {
+		name     string
+		spec     string
+		resolver OperationResolver
+	}{
+		{name: "swagger 2", spec: `{"swagger": "2.0", "paths": {}}`, resolver: handler},
+		{name: "malformed", spec: `openapi: [`, resolver: handler},
+		{name: "no resolver", spec: `openapi: 3.0.0`},
+		{name: "no operationId", spec: `{"openapi": "3.0.0", "paths": {"/a": {"get": {}}}}`, resolver: handler},
+		{name: "no handler", spec: `{"openapi": "3.0.0", "paths": {"/a": {"get": {"operationId": "a"}}}}`,
+			resolver: func(string) http.Handler { return nil }},
+		{name: "relative path", spec: `{"openapi": "3.0.0", "paths": {"a": {"get": {"operationId": "a"}}}}`, resolver: handler},
+		{name: "unmatched brace", spec: `{"openapi": "3.0.0", "paths": {"/a/{id": {"get": {"operationId": "a"}}}}`, resolver: handler},
+		{name: "empty param", spec: `{"openapi": "3.0.0", "paths": {"/a/{}": {"get": {"operationId": "a"}}}}`, resolver: handler},
+		{name: "partial segment param", spec: `{"openapi": "3.0.0", "paths": {"/files/{name}.json": {"get": {"operationId": "a"}}}}`, resolver: handler},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := FromOpenAPI([]byte(tc.spec), tc.resolver)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOpenAPIPattern(t *testing.T) {
+	pattern, err := openAPIPattern("/users/{userId}/posts/{postId}")
+	require.NoError(t, err)
+	assert.Equal(t, "/users/<userId>/posts/<postId>", pattern)
+
+	pattern, err = openAPIPattern("/files/{name}/")
+	require.NoError(t, err)
+	assert.Equal(t, "/files/<name>/", pattern)
+
+	for _, path := range []string{"/a}", "/{a/b}", "/<a>", "/{a:b}", "/files/{name}.json", "/files/v{version}", "/{a}{b}"} {
+		_, err := openAPIPattern(path)
+		assert.Error(t, err, path)
+	}
+}
@@ -0,0 +1,118 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// routeOptions are the per-route settings applied by the mux before calling the route handler,
+// they are kept when the route is replaced by Handle
+type routeOptions struct {
+	// altSvc is the Alt-Svc header advertising alternative services, e.g. HTTP/3
+	altSvc string
+	// earlyHints are the Link header values sent in a 103 Early Hints response
+	earlyHints []string
+	// priority is the RFC 9218 Priority header of the requests, see Mux.SetPriority
+	priority string
+	// query are the query parameters expected by the route, see Mux.SetQuerySchema
+	query []QueryParam
+	// context decorates the request context, see Mux.SetContext
+	context ContextFunc
+	// transformers modify the responses, see Mux.SetTransformers
+	transformers []ResponseTransformer
+	// adapters adapt the requests and the responses to the current API version, see Mux.SetVersionAdapters
+	adapters []VersionAdapter
+	// deprecation marks the route as deprecated, see Mux.SetDeprecation
+	deprecation *deprecation
+	// sampling hands a share of the requests to a callback, see Mux.SetSampling
+	sampling *sampling
+	// cors is the CORS policy of the route, see Mux.SetCORS
+	cors *cors
+	// coalescing coalesces the concurrent identical requests, see Mux.SetCoalescing
+	coalescing *coalescing
+	// disabled answers the requests of the route as not found, see Route.Disable
+	disabled bool
+}
+
+// apply applies the route options to the request and the response before the handler is called
+func (o *routeOptions) apply(w http.ResponseWriter, r *http.Request) *http.Request {
+	if o.altSvc != "" {
+		w.Header().Set("Alt-Svc", o.altSvc)
+	}
+	if len(o.earlyHints) != 0 {
+		sendEarlyHints(w, r, o.earlyHints)
+	}
+	if o.priority != "" {
+		setPriority(r, o.priority)
+	}
+	if o.context != nil {
+		r = r.WithContext(o.context(r.Context()))
+	}
+	return r
+}
+
+// handler returns the handler serving the requests of the route
+func (o *routeOptions) handler(h http.Handler) http.Handler {
+	if adapters := o.adapters; len(adapters) != 0 {
+		next := h
+		h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveAdapted(w, r, next, adapters)
+		})
+	}
+	if transformers := o.transformers; len(transformers) != 0 {
+		next := h
+		h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveTransformed(w, r, next, transformers)
+		})
+	}
+	if s := o.sampling; s != nil {
+		next := h
+		h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.serve(w, r, next)
+		})
+	}
+	if c := o.coalescing; c != nil {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c.serve(w, r, h)
+		})
+	}
+	return h
+}
+
+// route returns the route registered for the expression, the caller holds the lock
+func (m *Mux) route(expr string) (*entry, error) {
+	e, ok := m.keys[m.keyFunc(expr)]
+	if !ok {
+		return nil, fmt.Errorf("expression '%s' not found", expr)
+	}
+	return e, nil
+}
+
+// setOptions updates the options of the route registered for the expression
+func (m *Mux) setOptions(expr string, fn func(o *routeOptions)) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	e, err := m.route(expr)
+	if err != nil {
+		return err
+	}
+	e.updateOptions(fn)
+	return nil
+}
+
+// updateOptions applies fn to a copy of the options and stores it, so the requests being served
+// see either the previous or the updated options. The caller holds the lock of the mux.
+func (e *entry) updateOptions(fn func(o *routeOptions)) {
+	o := *e.options.Load()
+	fn(&o)
+	e.options.Store(&o)
+}
+
+// SetAltSvc sets the Alt-Svc header added to the responses of the route registered for the expression,
+// e.g. `h3=":443"; ma=86400` to advertise HTTP/3 to the clients. An empty value removes the header.
+func (m *Mux) SetAltSvc(expr, value string) error {
+	return m.setOptions(expr, func(o *routeOptions) {
+		o.altSvc = value
+	})
+}
@@ -0,0 +1,34 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetAltSvc(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/a")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path("/b")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.SetAltSvc(`Path( "/a" )`, `h3=":443"; ma=86400`))
+
+	serve := func(url string) *testWriter {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: url}))
+		return w
+	}
+
+	assert.Equal(t, `h3=":443"; ma=86400`, serve("/a").Header().Get("Alt-Svc"))
+	assert.Empty(t, serve("/b").Header().Get("Alt-Svc"))
+
+	// options are kept when the route is replaced
+	require.NoError(t, m.Handle(`Path("/a")`, newStatusHandler(http.StatusAccepted)))
+	assert.Equal(t, `h3=":443"; ma=86400`, serve("/a").Header().Get("Alt-Svc"))
+
+	require.NoError(t, m.SetAltSvc(`Path("/a")`, ""))
+	assert.Empty(t, serve("/a").Header().Get("Alt-Svc"))
+
+	assert.Error(t, m.SetAltSvc(`Path("/c")`, `h3=":443"`))
+}
@@ -0,0 +1,60 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrRouteOwned is returned when mutating a route owned by another owner
+var ErrRouteOwned = errors.New("route is owned by another owner")
+
+func ownedError(e *entry) error {
+	if e.owner == "" {
+		return fmt.Errorf("expression '%s' is not owned: %w", e.expr, ErrRouteOwned)
+	}
+	return fmt.Errorf("expression '%s' is owned by %s: %w", e.expr, e.owner, ErrRouteOwned)
+}
+
+// HandleOwned adds http handler for route expression on behalf of the owner, e.g. a subsystem name.
+// Owned routes are protected: Handle, Remove, InitHandlers and RemoveUnder, or the same calls
+// made by another owner, are rejected with ErrRouteOwned. Use SetOwner to force a change of ownership.
+func (m *Mux) HandleOwned(owner, expr string, handler http.Handler) error {
+	if owner == "" {
+		return errors.New("owner cannot be empty: operation rejected")
+	}
+	return m.handle(owner, expr, handler)
+}
+
+// RemoveOwned removes the route owned by the owner, see HandleOwned
+func (m *Mux) RemoveOwned(owner, expr string) error {
+	if owner == "" {
+		return errors.New("owner cannot be empty: operation rejected")
+	}
+	return m.removeOwned(owner, expr)
+}
+
+// SetOwner forces the owner of the route registered for the expression,
+// an empty owner removes the protection of the route.
+func (m *Mux) SetOwner(expr, owner string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, err := m.route(expr); err != nil {
+		return err
+	}
+	return m.swapLocked(m.keyFunc(expr), func(e *entry) {
+		e.owner = owner
+	})
+}
+
+// Owner returns the owner of the route registered for the expression, empty if the route is not owned
+func (m *Mux) Owner(expr string) string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if e, ok := m.keys[m.keyFunc(expr)]; ok {
+		return e.owner
+	}
+	return ""
+}
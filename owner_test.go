@@ -0,0 +1,96 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnedRoutes(t *testing.T) {
+	m := NewMux()
+
+	require.NoError(t, m.HandleOwned("infra", `Path("/healthz")`, newStatusHandler(http.StatusOK)))
+	assert.Equal(t, "infra", m.Owner(`Path("/healthz")`))
+
+	// other owners can't touch the route
+	require.ErrorIs(t, m.Handle(`Path("/healthz")`, newStatusHandler(http.StatusNotFound)), ErrRouteOwned)
+	require.ErrorIs(t, m.Handle(`Path( "/healthz" )`, newStatusHandler(http.StatusNotFound)), ErrRouteOwned)
+	require.ErrorIs(t, m.HandleOwned("tenant-sync", `Path("/healthz")`, newStatusHandler(http.StatusNotFound)), ErrRouteOwned)
+	require.ErrorIs(t, m.Remove(`Path("/healthz")`), ErrRouteOwned)
+	require.ErrorIs(t, m.RemoveOwned("tenant-sync", `Path("/healthz")`), ErrRouteOwned)
+	require.ErrorIs(t, m.InitHandlers(map[string]interface{}{}), ErrRouteOwned)
+	_, err := m.RemoveUnder("/healthz")
+	require.ErrorIs(t, err, ErrRouteOwned)
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/healthz"}))
+	assert.Equal(t, http.StatusOK, w.header)
+
+	// the owner can
+	require.NoError(t, m.HandleOwned("infra", `Path("/healthz")`, newStatusHandler(http.StatusAccepted)))
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/healthz"}))
+	assert.Equal(t, http.StatusAccepted, w.header)
+
+	// owned routes can't be mutated anonymously and vice versa
+	require.NoError(t, m.Handle(`Path("/public")`, newStatusHandler(http.StatusOK)))
+	require.ErrorIs(t, m.HandleOwned("infra", `Path("/public")`, newStatusHandler(http.StatusOK)), ErrRouteOwned)
+
+	require.NoError(t, m.RemoveOwned("infra", `Path("/healthz")`))
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/healthz"}))
+	assert.Equal(t, http.StatusNotFound, w.header)
+
+	require.Error(t, m.HandleOwned("", `Path("/a")`, newStatusHandler(http.StatusOK)))
+	require.Error(t, m.RemoveOwned("", `Path("/a")`))
+}
+
+func TestSetOwner(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleOwned("infra", `Path("/healthz")`, newStatusHandler(http.StatusOK)))
+
+	require.NoError(t, m.SetOwner(`Path("/healthz")`, "tenant-sync"))
+	require.NoError(t, m.HandleOwned("tenant-sync", `Path("/healthz")`, newStatusHandler(http.StatusOK)))
+
+	require.NoError(t, m.SetOwner(`Path("/healthz")`, ""))
+	require.NoError(t, m.Remove(`Path("/healthz")`))
+	assert.Empty(t, m.Owner(`Path("/healthz")`))
+
+	require.Error(t, m.SetOwner(`Path("/missing")`, "infra"))
+}
+
+func TestSetOwnerWhileIterating(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/healthz")`, newStatusHandler(http.StatusOK)))
+	route, err := m.HandleRoute(`Path("/users")`, newStatusHandler(http.StatusOK))
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 100 {
+			assert.NoError(t, m.SetOwner(`Path("/healthz")`, fmt.Sprintf("owner-%d", i)))
+			assert.NoError(t, route.Name(fmt.Sprintf("users-%d", i)))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		for info := range m.Routes() {
+			_ = info.Owner
+		}
+	}
+	<-done
+
+	assert.Equal(t, "owner-99", m.Owner(`Path("/healthz")`))
+	u, err := m.URL("users-99")
+	require.NoError(t, err)
+	assert.Equal(t, "/users", u.Path)
+	_, err = m.URL("users-98")
+	assert.Error(t, err)
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/healthz", method: http.MethodGet}))
+	assert.Equal(t, http.StatusOK, w.header)
+}
@@ -0,0 +1,247 @@
+package route
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Params are the values captured by the patterns of the matched route,
+// e.g. tenant for Host("<tenant>.example.com"), id for Path("/users/<id>")
+// or id for PathRegexp("/users/(?P<id>[0-9]+)")
+type Params map[string]string
+
+type paramsKey struct{}
+
+// ParamsFromContext returns the params captured for the route matched by the Mux, nil if there are none
+func ParamsFromContext(ctx context.Context) Params {
+	params, _ := ctx.Value(paramsKey{}).(Params)
+	return params
+}
+
+// Param returns the named param captured for the request, empty if the param was not captured
+func Param(r *http.Request, name string) string {
+	return ParamsFromContext(r.Context())[name]
+}
+
+// ErrParamMissing is returned by the typed param accessors when the param was not captured
+var ErrParamMissing = errors.New("missing param")
+
+// ParamError is returned by the typed param accessors when the param is missing or malformed,
+// it is a client error and is meant to be answered with a 400 Bad Request
+type ParamError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	if errors.Is(e.Err, ErrParamMissing) {
+		return fmt.Sprintf("param %s: %v", e.Name, e.Err)
+	}
+	return fmt.Sprintf("param %s: bad value %q: %v", e.Name, e.Value, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// paramValue returns the value of the param, a *ParamError if it was not captured
+func paramValue(r *http.Request, name string) (string, error) {
+	value, ok := ParamsFromContext(r.Context())[name]
+	if !ok {
+		return "", &ParamError{Name: name, Err: ErrParamMissing}
+	}
+	return value, nil
+}
+
+// ParamInt returns the named param as an int, the error is a *ParamError
+func ParamInt(r *http.Request, name string) (int, error) {
+	value, err := paramValue(r, name)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &ParamError{Name: name, Value: value, Err: errors.Unwrap(err)}
+	}
+	return i, nil
+}
+
+// ParamUUID returns the named param as a UUID in its canonical textual form,
+// e.g. 123e4567-e89b-12d3-a456-426614174000. The error is a *ParamError.
+func ParamUUID(r *http.Request, name string) ([16]byte, error) {
+	var uuid [16]byte
+	value, err := paramValue(r, name)
+	if err != nil {
+		return uuid, err
+	}
+	if len(value) != 36 || value[8] != '-' || value[13] != '-' || value[18] != '-' || value[23] != '-' {
+		return uuid, &ParamError{Name: name, Value: value, Err: errors.New("invalid UUID format")}
+	}
+	raw := value[:8] + value[9:13] + value[14:18] + value[19:23] + value[24:]
+	if _, err := hex.Decode(uuid[:], []byte(raw)); err != nil {
+		return uuid, &ParamError{Name: name, Value: value, Err: err}
+	}
+	return uuid, nil
+}
+
+// ParamTime returns the named param parsed with the layout, e.g. time.DateOnly. The error is a *ParamError.
+func ParamTime(r *http.Request, name, layout string) (time.Time, error) {
+	value, err := paramValue(r, name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, &ParamError{Name: name, Value: value, Err: err}
+	}
+	return t, nil
+}
+
+// withParams adds the params captured by the patterns of the route to the request context
+func (e *entry) withParams(r *http.Request) *http.Request {
+	params := e.capture(r)
+	if len(params) == 0 {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), paramsKey{}, params))
+}
+
+// capture returns the params captured by the patterns of the route for the request. The request is matched
+// by the expression or by the alias of the route, so the patterns of the alias capture the params
+// when the expression has none or the request does not match them.
+func (e *entry) capture(r *http.Request) Params {
+	patterns := paramPatterns{host: e.host, path: e.path, pathRegexp: e.pathRegexp}
+	if patterns.captures() {
+		if params, ok := patterns.capture(r); ok {
+			return params
+		}
+	}
+	if e.aliasPatterns != nil {
+		if params, ok := e.aliasPatterns.capture(r); ok {
+			return params
+		}
+	}
+	return nil
+}
+
+// paramPatterns are the patterns of an expression capturing params: the arguments of its Host and Path matchers
+// and its PathRegexp matcher when it has named groups
+type paramPatterns struct {
+	host       string
+	path       string
+	pathRegexp *regexp.Regexp
+}
+
+func newParamPatterns(expr string) paramPatterns {
+	ts, err := terms(expr)
+	if err != nil {
+		return paramPatterns{}
+	}
+	return paramPatterns{host: termArg(ts, "Host"), path: termArg(ts, "Path"), pathRegexp: namedGroups(termArg(ts, "PathRegexp"))}
+}
+
+// captures returns true if the patterns capture params
+func (p paramPatterns) captures() bool {
+	return strings.Contains(p.host, "<") || strings.Contains(p.path, "<") || p.pathRegexp != nil
+}
+
+// capture returns the params captured by the patterns for the request, false if the request does not match them
+func (p paramPatterns) capture(r *http.Request) (Params, bool) {
+	params := Params{}
+	if strings.Contains(p.host, "<") {
+		if !captureParams(strings.ToLower(p.host), (&hostMapper{}).mapRequest(r), domainSep, params) {
+			return nil, false
+		}
+	}
+	if strings.Contains(p.path, "<") || p.pathRegexp != nil {
+		// paths are matched escaped, the captured values are unescaped
+		path := Params{}
+		if strings.Contains(p.path, "<") && !captureParams(p.path, rawPath(r), pathSep, path) {
+			return nil, false
+		}
+		if p.pathRegexp != nil && !captureGroups(p.pathRegexp, rawPath(r), path) {
+			return nil, false
+		}
+		for name, value := range path {
+			if unescaped, err := url.PathUnescape(value); err == nil {
+				value = unescaped
+			}
+			params[name] = value
+		}
+	}
+	return params, true
+}
+
+// captureParams captures the values of the pattern params like the trie matches them:
+// <string:name> and <name> run to the next separator, <int:name> spans digits and <path:name> runs to the end.
+// It returns false if the value does not match the pattern.
+func captureParams(pattern, value string, sep byte, params Params) bool {
+	i, j := 0, 0
+	for i < len(pattern) {
+		m, next, err := parsePatternMatcher(i, pattern)
+		if err != nil {
+			return false
+		}
+		if m == nil {
+			if j >= len(value) || value[j] != pattern[i] {
+				return false
+			}
+			i++
+			j++
+			continue
+		}
+
+		end := j
+		switch m.(type) {
+		case *pathMatcher:
+			end = len(value)
+		case *intMatcher:
+			for end < len(value) && value[end] >= '0' && value[end] <= '9' {
+				end++
+			}
+		default:
+			for end < len(value) && value[end] != sep {
+				end++
+			}
+		}
+		params[m.getName()] = value[j:end]
+		i, j = next, end
+	}
+	return j == len(value)
+}
+
+// namedGroups compiles the regular expression if it has named groups, nil otherwise
+func namedGroups(expr string) *regexp.Regexp {
+	if !strings.Contains(expr, "(?P<") && !strings.Contains(expr, "(?<") {
+		return nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+// captureGroups captures the values of the named groups of the regular expression,
+// it returns false if the value does not match the regular expression
+func captureGroups(re *regexp.Regexp, value string, params Params) bool {
+	m := re.FindStringSubmatch(value)
+	if m == nil {
+		return false
+	}
+	for i, name := range re.SubexpNames() {
+		if name != "" && i < len(m) {
+			params[name] = m[i]
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureParams(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		value    string
+		expected Params
+		ok       bool
+	}{
+		{pattern: "<tenant>.example.com", value: "acme.example.com", expected: Params{"tenant": "acme"}, ok: true},
+		{pattern: "<env>.<tenant>.example.com", value: "dev.acme.example.com", expected: Params{"env": "dev", "tenant": "acme"}, ok: true},
+		{pattern: "<string:tenant>.example.com", value: "acme.example.com", expected: Params{"tenant": "acme"}, ok: true},
+		{pattern: "shard<int:id>.example.com", value: "shard12.example.com", expected: Params{"id": "12"}, ok: true},
+		{pattern: "/files/<path:rest>", value: "/files/a/b.txt", expected: Params{"rest": "a/b.txt"}, ok: true},
+		{pattern: "example.com", value: "example.com", expected: Params{}, ok: true},
+		{pattern: "<tenant>.example.com", value: "acme.example.org", expected: Params{"tenant": "acme"}, ok: false},
+	}
+	for _, test := range testCases {
+		params := Params{}
+		ok := captureParams(test.pattern, test.value, domainSep, params)
+		assert.Equal(t, test.ok, ok, test.pattern)
+		assert.Equal(t, test.expected, params, test.pattern)
+	}
+}
+
+func TestHostParams(t *testing.T) {
+	var params Params
+	m := NewMux()
+	require.NoError(t, m.HandleFunc(`Host("<tenant>.Example.com") && Path("/")`, func(_ http.ResponseWriter, r *http.Request) {
+		params = ParamsFromContext(r.Context())
+		assert.Equal(t, params["tenant"], Param(r, "tenant"))
+	}))
+	require.NoError(t, m.HandleFunc(`Host("example.com") && Path("/")`, func(_ http.ResponseWriter, r *http.Request) {
+		params = ParamsFromContext(r.Context())
+	}))
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/", host: "ACME.example.com:8080"}))
+	assert.Equal(t, Params{"tenant": "acme"}, params)
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/", host: "example.com"}))
+	assert.Nil(t, params)
+}
+
+func TestTypedParams(t *testing.T) {
+	r := makeReq(req{url: "/"})
+	r = r.WithContext(context.WithValue(context.Background(), paramsKey{}, Params{
+		"id":   "42",
+		"name": "bob",
+		"uuid": "123e4567-E89B-12d3-a456-426614174000",
+		"day":  "2024-02-29",
+	}))
+
+	id, err := ParamInt(r, "id")
+	require.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	_, err = ParamInt(r, "name")
+	var perr *ParamError
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, "name", perr.Name)
+	assert.Equal(t, "bob", perr.Value)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+
+	_, err = ParamInt(r, "missing")
+	assert.ErrorIs(t, err, ErrParamMissing)
+	assert.EqualError(t, err, "param missing: missing param")
+
+	uuid, err := ParamUUID(r, "uuid")
+	require.NoError(t, err)
+	assert.Equal(t, [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}, uuid)
+	_, err = ParamUUID(r, "id")
+	require.ErrorAs(t, err, &perr)
+	_, err = ParamUUID(r, "missing")
+	assert.ErrorIs(t, err, ErrParamMissing)
+
+	day, err := ParamTime(r, "day", time.DateOnly)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), day)
+	_, err = ParamTime(r, "name", time.DateOnly)
+	require.ErrorAs(t, err, &perr)
+	assert.Contains(t, err.Error(), `param name: bad value "bob"`)
+}
+
+func TestPathParams(t *testing.T) {
+	var params Params
+	handler := func(_ http.ResponseWriter, r *http.Request) {
+		params = ParamsFromContext(r.Context())
+	}
+	m := NewMux()
+	require.NoError(t, m.HandleFunc(`Host("<tenant>.example.com") && Path("/users/<int:id>/files/<path:file>")`, handler))
+	require.NoError(t, m.HandleFunc(`PathRegexp("^/orders/(?P<order>[0-9]+)/items/(?<item>[a-z]+)$")`, handler))
+	require.NoError(t, m.HandleFunc(`PathRegexp("^/static/.*")`, handler))
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/users/42/files/a/b%20c.txt", host: "acme.example.com"}))
+	assert.Equal(t, Params{"tenant": "acme", "id": "42", "file": "a/b c.txt"}, params)
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/orders/7/items/book"}))
+	assert.Equal(t, Params{"order": "7", "item": "book"}, params)
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/static/app.js"}))
+	assert.Nil(t, params)
+}
+
+func TestAliasParams(t *testing.T) {
+	var params Params
+	m := NewMux()
+	m.AddAlias(`Path("/v1/`, `Path("/api/v1/`)
+	m.AddAlias(`Host("<tenant>.example.com")`, `Host("<tenant>.<region>.example.com")`)
+	require.NoError(t, m.HandleFunc(`Path("/v1/users/<id>")`, func(_ http.ResponseWriter, r *http.Request) {
+		params = ParamsFromContext(r.Context())
+	}))
+	require.NoError(t, m.HandleFunc(`Host("<tenant>.example.com") && Path("/")`, func(_ http.ResponseWriter, r *http.Request) {
+		params = ParamsFromContext(r.Context())
+	}))
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/v1/users/42"}))
+	assert.Equal(t, Params{"id": "42"}, params)
+
+	// the params of the requests matched by the alias are captured by the patterns of the alias
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/api/v1/users/42"}))
+	assert.Equal(t, Params{"id": "42"}, params)
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/", host: "acme.example.com"}))
+	assert.Equal(t, Params{"tenant": "acme"}, params)
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/", host: "acme.eu.example.com"}))
+	assert.Equal(t, Params{"tenant": "acme", "region": "eu"}, params)
+}
@@ -2,6 +2,9 @@ package route
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/vulcand/predicate"
 )
@@ -14,31 +17,24 @@ func IsValid(expr string) bool {
 
 func parse(expression string, result *match) (matcher, error) {
 	p, err := predicate.NewParser(predicate.Def{
-		Functions: map[string]interface{}{
-			"Host":       hostTrieMatcher,
-			"HostRegexp": hostRegexpMatcher,
-
-			"Path":       pathTrieMatcher,
-			"PathRegexp": pathRegexpMatcher,
-
-			"Method":       methodTrieMatcher,
-			"MethodRegexp": methodRegexpMatcher,
-
-			"Header":       headerTrieMatcher,
-			"HeaderRegexp": headerRegexpMatcher,
-		},
+		Functions: matcherFuncs(),
 		Operators: predicate.Operators{
 			AND: newAndMatcher,
+			OR:  newOrMatcher,
+			NOT: newNotMatcher,
 		},
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	out, err := p.Parse(expression)
+	out, err := p.Parse(normalizeExpr(expression))
 	if err != nil {
 		return nil, err
 	}
+	if _, err := signedWith(expression); err != nil {
+		return nil, err
+	}
 
 	m, ok := out.(matcher)
 	if !ok {
@@ -46,6 +42,134 @@ func parse(expression string, result *match) (matcher, error) {
 	}
 
 	m.setMatch(result)
-	
+
 	return m, nil
 }
+
+// matcherFuncs returns the functions of the expression language, the built-in matchers
+// and the matchers registered with RegisterMatcher
+func matcherFuncs() map[string]interface{} {
+	funcs := map[string]interface{}{
+		"Host":       hostTrieMatcher,
+		"HostRegexp": hostRegexpMatcher,
+
+		"Path":       pathTrieMatcher,
+		"PathRegexp": pathRegexpMatcher,
+
+		"PathSegment": pathSegmentMatcher,
+		"PathGlob":    pathGlobMatcher,
+
+		"Method":       methodTrieMatcher,
+		"MethodRegexp": methodRegexpMatcher,
+
+		"Header":       headerTrieMatcher,
+		"HeaderRegexp": headerRegexpMatcher,
+
+		"HeaderContainsToken": headerTokenMatcher,
+
+		"Query":       queryTrieMatcher,
+		"QueryRegexp": queryRegexpMatcher,
+
+		"Proto": protoMatcher,
+
+		"Region": regionMatcher,
+
+		"Flag": flagMatcher,
+
+		"Version": versionMatcher,
+
+		"ClientIP": clientIPMatcher,
+
+		"QueryParamsOver": queryParamsOverMatcher,
+		"URLLengthOver":   urlLengthOverMatcher,
+		"HeaderCountOver": headerCountOverMatcher,
+		"HeaderBytesOver": headerBytesOverMatcher,
+
+		"Stale":      staleMatcher,
+		"SignedWith": signedWithMatcher,
+	}
+	addCustomMatchers(funcs)
+	return funcs
+}
+
+// CanonicalExpr returns the canonical form of the expression: comments and whitespace outside of
+// string literals are removed and every string literal is double-quoted,
+// so `Path( "/v1" )` and "Path(`/v1`)" have the same canonical form.
+func CanonicalExpr(expr string) string {
+	expr = normalizeExpr(expr)
+
+	var b strings.Builder
+	b.Grow(len(expr))
+
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == '"' || c == '`':
+			end := literalEnd(expr, i)
+			value, err := strconv.Unquote(expr[i:end])
+			if err != nil {
+				// leave malformed literals untouched, the parser will report them
+				b.WriteString(expr[i:end])
+			} else {
+				b.WriteString(strconv.Quote(value))
+			}
+			i = end - 1
+		case unicode.IsSpace(rune(c)):
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// normalizeExpr removes the comments and the line breaks from the expression so that
+// expressions can span several lines, e.g. in YAML block scalars:
+//
+//	Host("api.example.com") # public API
+//	  && Path("/v1")        # legacy clients
+//
+// A '#' outside of string literals starts a comment running to the end of the line.
+func normalizeExpr(expr string) string {
+	if !strings.ContainsAny(expr, "#\r\n") {
+		return expr
+	}
+
+	var b strings.Builder
+	b.Grow(len(expr))
+
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '"', '`':
+			end := literalEnd(expr, i)
+			b.WriteString(expr[i:end])
+			i = end - 1
+		case '#':
+			end := strings.IndexByte(expr[i:], '\n')
+			if end == -1 {
+				return b.String()
+			}
+			i += end - 1
+		case '\r', '\n':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(expr[i])
+		}
+	}
+	return b.String()
+}
+
+// literalEnd returns the offset just past the string literal starting at offset start
+func literalEnd(expr string, start int) int {
+	quote := expr[start]
+	for i := start + 1; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+	return len(expr)
+}
@@ -139,6 +139,67 @@ func TestParseAndMatchSuccess(t *testing.T) {
 			Host:       "a.b.localhost",
 			Headers:    map[string][]string{"Content-Type": {"application/json"}},
 		},
+		{
+			Expression: `Query("version", "2") && Path("/helloworld")`,
+			Url:        `http://google.com/helloworld?debug=1&version=2`,
+			Method:     http.MethodGet,
+		},
+		{
+			Expression: `Method("GET") && QueryRegexp("tag", "^beta-.*")`,
+			Url:        `http://google.com/helloworld?tag=stable&tag=beta-1`,
+			Method:     http.MethodGet,
+		},
+		// Function cases
+		{
+			Expression: `Method("GET") && PathSegment(2, "admin")`,
+			Url:        `http://google.com/v1/admin/users`,
+			Method:     http.MethodGet,
+			Host:       "localhost",
+		},
+		{
+			Expression: `HeaderContainsToken("X-Features", "beta") && Path("/helloworld")`,
+			Url:        `http://google.com/helloworld`,
+			Method:     http.MethodGet,
+			Headers:    map[string][]string{"X-Features": {"alpha, beta"}},
+		},
+		// Comment and multi-line cases
+		{
+			Expression: "Host(\"localhost\")\r\n  && Method(\"GET\")\n  && Path(`/helloworld`)",
+			Url:        `http://google.com/helloworld`,
+			Method:     http.MethodGet,
+			Host:       "localhost",
+		},
+		{
+			Expression: "Host(\"localhost\") # internal API\n  && Path(\"/helloworld\")",
+			Url:        `http://google.com/helloworld`,
+			Method:     http.MethodGet,
+			Host:       "localhost",
+		},
+		{
+			Expression: "Host(\"localhost\") && # public API\nHeader(\"X-Tag\", \"#1\") # literals are kept",
+			Url:        `http://google.com/helloworld`,
+			Method:     http.MethodGet,
+			Host:       "localhost",
+			Headers:    map[string][]string{"X-Tag": {"#1"}},
+		},
+		// Negation and || cases
+		{
+			Expression: `Path("/helloworld") && !Header("X-Internal", "true")`,
+			Url:        `http://google.com/helloworld`,
+			Method:     http.MethodGet,
+			Headers:    map[string][]string{"X-Internal": {"false"}},
+		},
+		{
+			Expression: `Host("localhost") && (Path("/hello") || Path("/helloworld"))`,
+			Url:        `http://google.com/helloworld`,
+			Method:     http.MethodGet,
+			Host:       "localhost",
+		},
+		{
+			Expression: `!(Method("POST") || Method("PUT")) && PathRegexp("/hello.*")`,
+			Url:        `http://google.com/helloworld`,
+			Method:     http.MethodGet,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.Expression, func(t *testing.T) {
@@ -174,7 +235,7 @@ func TestParseFailures(t *testing.T) {
 		},
 		{
 			desc: "unsupported operator",
-			expr: `Path("/path") || Path("/path2")`,
+			expr: `Path("/path") == Path("/path2")`,
 		},
 		{
 			desc: "unsupported statements",
@@ -229,3 +290,22 @@ func TestParseFailures(t *testing.T) {
 		})
 	}
 }
+
+func TestCanonicalExpr(t *testing.T) {
+	testCases := []struct {
+		expr     string
+		expected string
+	}{
+		{expr: `Path("/v1")`, expected: `Path("/v1")`},
+		{expr: ` Host( "localhost" )  &&  Path("/v1")`, expected: `Host("localhost")&&Path("/v1")`},
+		{expr: "Path(`/v1`)", expected: `Path("/v1")`},
+		{expr: `Path("/hello world")`, expected: `Path("/hello world")`},
+		{expr: `Header("X-Quote", "a\"b c")`, expected: `Header("X-Quote","a\"b c")`},
+		{expr: "Path(`/a#b`) # comment", expected: `Path("/a#b")`},
+		{expr: "Host(\"a\") # host\n  && Path(\"/v1\")", expected: `Host("a")&&Path("/v1")`},
+	}
+
+	for _, test := range testCases {
+		assert.Equal(t, test.expected, CanonicalExpr(test.expr), test.expr)
+	}
+}
@@ -0,0 +1,79 @@
+package route
+
+import (
+	"sync"
+)
+
+// CompilePool is a cache of compiled matchers that can be shared by many Muxes in a process,
+// e.g. one Mux per listener or per tenant, so that an expression used by several of them is parsed
+// and compiled only once. The matchers are keyed by the canonical form of their expression, see CanonicalExpr,
+// and every router gets its own copy of them to merge into its tries, the copies share the compiled
+// regular expressions which are safe for concurrent use.
+type CompilePool struct {
+	mutex    sync.RWMutex
+	size     int
+	matchers map[string]matcher
+}
+
+// NewCompilePool returns a pool caching the matchers of up to size expressions,
+// once the pool is full other expressions are parsed without being cached.
+func NewCompilePool(size int) *CompilePool {
+	return &CompilePool{
+		size:     size,
+		matchers: make(map[string]matcher),
+	}
+}
+
+// Len returns the number of cached expressions
+func (p *CompilePool) Len() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return len(p.matchers)
+}
+
+// parse returns a copy of the matcher of the expression bound to the result, the expression is only parsed
+// if the pool has no matcher for its canonical form. A nil pool parses without caching.
+func (p *CompilePool) parse(expr string, result *match) (matcher, error) {
+	if p == nil {
+		return parse(expr, result)
+	}
+
+	key := CanonicalExpr(expr)
+	p.mutex.RLock()
+	cached, ok := p.matchers[key]
+	p.mutex.RUnlock()
+
+	if !ok {
+		// the cached matcher is never merged nor bound to a route, only its copies are
+		m, err := parse(expr, &match{})
+		if err != nil {
+			return nil, err
+		}
+
+		p.mutex.Lock()
+		if cached, ok = p.matchers[key]; !ok {
+			cached = m
+			if len(p.matchers) < p.size {
+				p.matchers[key] = m
+			}
+		}
+		p.mutex.Unlock()
+	}
+
+	m := cached.clone()
+	m.setMatch(result)
+	return m, nil
+}
+
+// SetCompilePool makes the Mux compile its routes through the pool shared with other Muxes,
+// it should be called before any routes are added.
+func (m *Mux) SetCompilePool(pool *CompilePool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_ = m.update(func(r *router) error {
+		r.pool = pool
+		return nil
+	})
+}
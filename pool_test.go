@@ -0,0 +1,109 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompilePool(t *testing.T) {
+	pool := NewCompilePool(2)
+
+	a, b := &match{val: "a"}, &match{val: "b"}
+	ma, err := pool.parse(`PathRegexp("^/a")`, a)
+	require.NoError(t, err)
+	// the spellings of an expression share the cached matcher
+	mb, err := pool.parse("PathRegexp( `^/a` )", b)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.Len())
+
+	// every copy is bound to its own result and shares the compiled regular expression
+	r := makeReq(req{url: "/a"})
+	assert.Equal(t, a, ma.match(r))
+	assert.Equal(t, b, mb.match(r))
+	assert.Same(t, ma.(*regexpMatcher).expr, mb.(*regexpMatcher).expr)
+
+	_, err = pool.parse(`PathRegexp("[[")`, &match{})
+	require.Error(t, err)
+	assert.Equal(t, 1, pool.Len())
+
+	_, err = pool.parse(`Path("/b")`, &match{})
+	require.NoError(t, err)
+	_, err = pool.parse(`Path("/c")`, &match{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, pool.Len())
+
+	var nilPool *CompilePool
+	_, err = nilPool.parse(`Path("/a")`, &match{})
+	require.NoError(t, err)
+}
+
+func TestCompilePoolTrieCopies(t *testing.T) {
+	pool := NewCompilePool(10)
+
+	exprs := []string{`Host("<tenant>.example.com") && Path("/users/<id>")`, `Host("<tenant>.example.com") && Path("/users")`}
+	merge := func(results ...*match) matcher {
+		var merged matcher
+		for i, expr := range exprs {
+			m, err := pool.parse(expr, results[i])
+			require.NoError(t, err)
+			if merged == nil {
+				merged = m
+				continue
+			}
+			require.True(t, merged.canMerge(m))
+			merged, err = merged.merge(m)
+			require.NoError(t, err)
+		}
+		return merged
+	}
+
+	first := merge(&match{val: 1}, &match{val: 2})
+	second := merge(&match{val: 3}, &match{val: 4})
+
+	// merging the copies of the cached tries does not change the cached tries nor the other copies
+	r := makeReq(req{host: "acme.example.com", url: "/users/1"})
+	assert.Equal(t, 1, first.match(r).val)
+	assert.Equal(t, 3, second.match(r).val)
+	r = makeReq(req{host: "acme.example.com", url: "/users"})
+	assert.Equal(t, 2, first.match(r).val)
+	assert.Equal(t, 4, second.match(r).val)
+}
+
+func TestSharedCompilePool(t *testing.T) {
+	pool := NewCompilePool(100)
+
+	muxes := []*Mux{NewMux(), NewMux()}
+	for i, m := range muxes {
+		m.SetCompilePool(pool)
+		require.NoError(t, m.Handle(`PathRegexp("/v[0-9]+/users")`, newStatusHandler(http.StatusOK+i)))
+		require.NoError(t, m.Handle(`HostRegexp(".*\\.example\\.com") && Path("/status")`, newStatusHandler(http.StatusOK)))
+		require.NoError(t, m.Handle(`Path("/a")`, newStatusHandler(http.StatusAccepted+i)))
+		require.NoError(t, m.Handle(`Path("/b")`, newStatusHandler(http.StatusAccepted+i)))
+		require.NoError(t, m.Handle(`Stale("Date", "1m") && Path("/stale")`, newStatusHandler(http.StatusBadRequest)))
+	}
+	assert.Equal(t, 5, pool.Len())
+
+	// the copies of the Stale matcher read the clock of their mux
+	c := newFakeClock()
+	muxes[1].SetClock(c)
+	date := http.Header{"Date": {c.Now().Format(http.TimeFormat)}}
+
+	for i, m := range muxes {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/v2/users"}))
+		assert.Equal(t, http.StatusOK+i, w.header)
+
+		for _, path := range []string{"/a", "/b"} {
+			w = newWriter()
+			m.ServeHTTP(w, makeReq(req{url: path}))
+			assert.Equal(t, http.StatusAccepted+i, w.header)
+		}
+
+		w = newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/stale", headers: date}))
+		assert.Equal(t, []int{http.StatusBadRequest, http.StatusNotFound}[i], w.header)
+	}
+}
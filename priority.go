@@ -0,0 +1,37 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// SetPriority sets the RFC 9218 Priority header of the requests of the route registered for the expression
+// before the handler is called, so backends and queues behind the router can prioritize the traffic by route class,
+// e.g. checkout before analytics. Urgency ranges from 0, the highest priority, to 7, 3 being the default of the RFC.
+// The header set by the client is overridden.
+func (m *Mux) SetPriority(expr string, urgency int, incremental bool) error {
+	if urgency < 0 || urgency > 7 {
+		return fmt.Errorf("urgency should be in [0, 7], got: %d", urgency)
+	}
+	priority := fmt.Sprintf("u=%d", urgency)
+	if incremental {
+		priority += ", i"
+	}
+	return m.setOptions(expr, func(o *routeOptions) {
+		o.priority = priority
+	})
+}
+
+// ClearPriority stops setting the Priority header of the requests of the route registered for the expression
+func (m *Mux) ClearPriority(expr string) error {
+	return m.setOptions(expr, func(o *routeOptions) {
+		o.priority = ""
+	})
+}
+
+func setPriority(r *http.Request, priority string) {
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	r.Header.Set("Priority", priority)
+}
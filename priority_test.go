@@ -0,0 +1,39 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPriority(t *testing.T) {
+	var priority string
+	m := NewMux()
+	require.NoError(t, m.HandleFunc(`Path("/checkout")`, func(_ http.ResponseWriter, r *http.Request) {
+		priority = r.Header.Get("Priority")
+	}))
+
+	serve := func(headers http.Header) string {
+		m.ServeHTTP(newWriter(), makeReq(req{url: "/checkout", headers: headers}))
+		return priority
+	}
+
+	assert.Empty(t, serve(nil))
+
+	require.NoError(t, m.SetPriority(`Path("/checkout")`, 0, false))
+	assert.Equal(t, "u=0", serve(nil))
+	assert.Equal(t, "u=0", serve(http.Header{"Priority": {"u=7"}}))
+
+	require.NoError(t, m.SetPriority(`Path("/checkout")`, 5, true))
+	assert.Equal(t, "u=5, i", serve(nil))
+
+	require.NoError(t, m.ClearPriority(`Path("/checkout")`))
+	assert.Equal(t, "u=7", serve(http.Header{"Priority": {"u=7"}}))
+
+	assert.Error(t, m.SetPriority(`Path("/checkout")`, 8, false))
+	assert.Error(t, m.SetPriority(`Path("/checkout")`, -1, false))
+	assert.Error(t, m.SetPriority(`Path("/analytics")`, 7, false))
+	assert.Error(t, m.ClearPriority(`Path("/analytics")`))
+}
@@ -0,0 +1,172 @@
+package route
+
+import (
+	"bufio"
+	"cmp"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the latency histogram buckets of the
+// PrometheusObserver, the default buckets of the Prometheus clients
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// PrometheusObserver is an Observer counting the requests and measuring their latency per route.
+// It serves the metrics in the Prometheus text format:
+//
+//	route_requests_total{route="Path(\"/users\")",method="GET",code="200"} 42
+//	route_request_duration_seconds_bucket{route="Path(\"/users\")",method="GET",le="0.005"} 40
+//
+// The route label is the expression of the matched route, empty for the requests that did not match
+// any route, and the methods other than the standard ones are reported as OTHER, so the number of
+// series is bounded by the route table.
+type PrometheusObserver struct {
+	buckets []float64
+
+	// requests and latencies hold the series, the request counters keyed by requestSeries and the histograms
+	// keyed by latencySeries. The series are counted with atomics and looked up without locks,
+	// so the requests and the scrapes don't wait for each other.
+	requests  sync.Map
+	latencies sync.Map
+}
+
+type requestSeries struct {
+	route, method string
+	code          int
+}
+
+type latencySeries struct {
+	route, method string
+}
+
+type histogram struct {
+	// counts are the number of observations per bucket, not cumulated, the last one counting
+	// the observations above the upper bound of the last bucket, so the count is their sum
+	counts []atomic.Uint64
+	// sum holds the bits of the float64 sum of the observations
+	sum atomic.Uint64
+}
+
+// observe counts the observation in the bucket and adds it to the sum
+func (h *histogram) observe(bucket int, value float64) {
+	h.counts[bucket].Add(1)
+	for {
+		old := h.sum.Load()
+		if h.sum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+value)) {
+			return
+		}
+	}
+}
+
+// NewPrometheusObserver returns an observer measuring the latency with the buckets, in seconds,
+// DefaultLatencyBuckets if none are given
+func NewPrometheusObserver(buckets ...float64) *PrometheusObserver {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &PrometheusObserver{buckets: slices.Compact(buckets)}
+}
+
+// ObserveRequest implements Observer
+func (p *PrometheusObserver) ObserveRequest(r *http.Request, expr string, status int, duration time.Duration) {
+	method := r.Method
+	if !slices.Contains(standardMethods, method) {
+		method = "OTHER"
+	}
+	seconds := duration.Seconds()
+
+	p.requestCounter(requestSeries{route: expr, method: method, code: status}).Add(1)
+	i, _ := slices.BinarySearch(p.buckets, seconds)
+	p.latencyHistogram(latencySeries{route: expr, method: method}).observe(i, seconds)
+}
+
+// requestCounter returns the counter of the series, added if it is new
+func (p *PrometheusObserver) requestCounter(s requestSeries) *atomic.Uint64 {
+	if c, ok := p.requests.Load(s); ok {
+		return c.(*atomic.Uint64)
+	}
+	c, _ := p.requests.LoadOrStore(s, &atomic.Uint64{})
+	return c.(*atomic.Uint64)
+}
+
+// latencyHistogram returns the histogram of the series, added if it is new
+func (p *PrometheusObserver) latencyHistogram(s latencySeries) *histogram {
+	if h, ok := p.latencies.Load(s); ok {
+		return h.(*histogram)
+	}
+	h, _ := p.latencies.LoadOrStore(s, &histogram{counts: make([]atomic.Uint64, len(p.buckets)+1)})
+	return h.(*histogram)
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (p *PrometheusObserver) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	b := bufio.NewWriter(w)
+	p.write(b)
+	_ = b.Flush()
+}
+
+// write writes the metrics sorted by labels, so the output is stable. The counters are read
+// while the requests are observed.
+func (p *PrometheusObserver) write(b *bufio.Writer) {
+	var requests []requestSeries
+	counters := make(map[requestSeries]*atomic.Uint64)
+	p.requests.Range(func(s, c any) bool {
+		requests = append(requests, s.(requestSeries))
+		counters[s.(requestSeries)] = c.(*atomic.Uint64)
+		return true
+	})
+	var latencies []latencySeries
+	histograms := make(map[latencySeries]*histogram)
+	p.latencies.Range(func(s, h any) bool {
+		latencies = append(latencies, s.(latencySeries))
+		histograms[s.(latencySeries)] = h.(*histogram)
+		return true
+	})
+
+	slices.SortFunc(requests, func(a, b requestSeries) int {
+		return cmp.Or(cmp.Compare(a.route, b.route), cmp.Compare(a.method, b.method), cmp.Compare(a.code, b.code))
+	})
+	b.WriteString("# HELP route_requests_total Requests served by the mux per route, method and status code.\n")
+	b.WriteString("# TYPE route_requests_total counter\n")
+	for _, s := range requests {
+		b.WriteString("route_requests_total{route=" + promLabel(s.route) + ",method=" + promLabel(s.method) +
+			",code=" + promLabel(strconv.Itoa(s.code)) + "} " + strconv.FormatUint(counters[s].Load(), 10) + "\n")
+	}
+
+	slices.SortFunc(latencies, func(a, b latencySeries) int {
+		return cmp.Or(cmp.Compare(a.route, b.route), cmp.Compare(a.method, b.method))
+	})
+	b.WriteString("# HELP route_request_duration_seconds Latency of the requests served by the mux per route and method.\n")
+	b.WriteString("# TYPE route_request_duration_seconds histogram\n")
+	for _, s := range latencies {
+		h := histograms[s]
+		labels := "route=" + promLabel(s.route) + ",method=" + promLabel(s.method)
+		var cumulated uint64
+		for i, bound := range p.buckets {
+			cumulated += h.counts[i].Load()
+			b.WriteString("route_request_duration_seconds_bucket{" + labels + ",le=" +
+				promLabel(strconv.FormatFloat(bound, 'g', -1, 64)) + "} " + strconv.FormatUint(cumulated, 10) + "\n")
+		}
+		// the count is cumulated from the buckets so that it is consistent with them
+		count := strconv.FormatUint(cumulated+h.counts[len(p.buckets)].Load(), 10)
+		b.WriteString("route_request_duration_seconds_bucket{" + labels + ",le=\"+Inf\"} " + count + "\n")
+		b.WriteString("route_request_duration_seconds_sum{" + labels + "} " + strconv.FormatFloat(math.Float64frombits(h.sum.Load()), 'g', -1, 64) + "\n")
+		b.WriteString("route_request_duration_seconds_count{" + labels + "} " + count + "\n")
+	}
+}
+
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promLabel quotes the label value, escaping the backslashes, the double quotes and the line feeds
+func promLabel(value string) string {
+	return `"` + promEscaper.Replace(value) + `"`
+}
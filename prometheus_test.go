@@ -0,0 +1,98 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusObserver(t *testing.T) {
+	p := NewPrometheusObserver(0.1, 0.01, 0.1)
+	get := makeReq(req{url: "/", method: http.MethodGet})
+	p.ObserveRequest(get, `Path("/users")`, http.StatusOK, 5*time.Millisecond)
+	p.ObserveRequest(get, `Path("/users")`, http.StatusOK, 50*time.Millisecond)
+	p.ObserveRequest(get, `Path("/users")`, http.StatusInternalServerError, time.Second)
+	p.ObserveRequest(makeReq(req{url: "/", method: "PURGE"}), "", http.StatusNotFound, 10*time.Millisecond)
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `# HELP route_requests_total Requests served by the mux per route, method and status code.
+# TYPE route_requests_total counter
+route_requests_total{route="",method="OTHER",code="404"} 1
+route_requests_total{route="Path(\"/users\")",method="GET",code="200"} 2
+route_requests_total{route="Path(\"/users\")",method="GET",code="500"} 1
+# HELP route_request_duration_seconds Latency of the requests served by the mux per route and method.
+# TYPE route_request_duration_seconds histogram
+route_request_duration_seconds_bucket{route="",method="OTHER",le="0.01"} 1
+route_request_duration_seconds_bucket{route="",method="OTHER",le="0.1"} 1
+route_request_duration_seconds_bucket{route="",method="OTHER",le="+Inf"} 1
+route_request_duration_seconds_sum{route="",method="OTHER"} 0.01
+route_request_duration_seconds_count{route="",method="OTHER"} 1
+route_request_duration_seconds_bucket{route="Path(\"/users\")",method="GET",le="0.01"} 1
+route_request_duration_seconds_bucket{route="Path(\"/users\")",method="GET",le="0.1"} 2
+route_request_duration_seconds_bucket{route="Path(\"/users\")",method="GET",le="+Inf"} 3
+route_request_duration_seconds_sum{route="Path(\"/users\")",method="GET"} 1.055
+route_request_duration_seconds_count{route="Path(\"/users\")",method="GET"} 3
+`, w.Body.String())
+}
+
+func TestPrometheusObserverConcurrent(t *testing.T) {
+	p := NewPrometheusObserver(0.01)
+	get := makeReq(req{url: "/", method: http.MethodGet})
+
+	// the requests are observed while the metrics are scraped
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				p.ObserveRequest(get, `Path("/users")`, http.StatusOK, time.Millisecond)
+				if j%10 == 0 {
+					p.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, w.Body.String(), `route_requests_total{route="Path(\"/users\")",method="GET",code="200"} 800`)
+	assert.Contains(t, w.Body.String(), `route_request_duration_seconds_count{route="Path(\"/users\")",method="GET"} 800`)
+}
+
+func BenchmarkPrometheusObserver(b *testing.B) {
+	p := NewPrometheusObserver()
+	get := makeReq(req{url: "/", method: http.MethodGet})
+	var routes atomic.Int32
+	b.RunParallel(func(pb *testing.PB) {
+		// the goroutines observe the requests of different routes
+		expr := fmt.Sprintf(`Path("/users/%d")`, routes.Add(1))
+		for pb.Next() {
+			p.ObserveRequest(get, expr, http.StatusOK, time.Millisecond)
+		}
+	})
+}
+
+func TestPrometheusObserverMux(t *testing.T) {
+	p := NewPrometheusObserver()
+	m := NewMux()
+	m.SetObserver(p)
+	require.NoError(t, m.Handle(`Path("/users")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path("/metrics")`, p))
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/users", method: http.MethodGet}))
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, w.Body.String(), `route_requests_total{route="Path(\"/users\")",method="GET",code="200"} 1`)
+	assert.Contains(t, w.Body.String(), `route_request_duration_seconds_count{route="Path(\"/users\")",method="GET"} 1`)
+}
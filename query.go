@@ -0,0 +1,142 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// QueryType is the type of the values of a query parameter
+type QueryType int
+
+const (
+	// QueryString accepts any value
+	QueryString QueryType = iota
+	// QueryInt accepts integers
+	QueryInt
+	// QueryFloat accepts floating point numbers
+	QueryFloat
+	// QueryBool accepts the values parsed by strconv.ParseBool
+	QueryBool
+)
+
+func (t QueryType) String() string {
+	switch t {
+	case QueryString:
+		return "string"
+	case QueryInt:
+		return "int"
+	case QueryFloat:
+		return "float"
+	case QueryBool:
+		return "bool"
+	}
+	return "unknown"
+}
+
+// QueryParam declares a query parameter of a route, see Mux.SetQuerySchema
+type QueryParam struct {
+	Name     string
+	Required bool
+	Type     QueryType
+	// Allowed are the allowed values, any value of the type is allowed when empty
+	Allowed []string
+}
+
+// QueryError lists the query parameters of a request violating the query schema of the route
+type QueryError struct {
+	Params []*ParamError
+}
+
+func (e *QueryError) Error() string {
+	msgs := make([]string, 0, len(e.Params))
+	for _, p := range e.Params {
+		msgs = append(msgs, p.Error())
+	}
+	return "invalid query: " + strings.Join(msgs, "; ")
+}
+
+// QueryErrorRenderer writes the response to requests violating the query schema of their route
+type QueryErrorRenderer func(w http.ResponseWriter, r *http.Request, err *QueryError)
+
+// SetQuerySchema sets the query parameters expected by the route registered for the expression.
+// Requests violating the schema are answered by the query error renderer, the handler is not called.
+// Calling it without params removes the schema.
+func (m *Mux) SetQuerySchema(expr string, params ...QueryParam) error {
+	for _, p := range params {
+		if p.Name == "" {
+			return errors.New("query param name cannot be empty: operation rejected")
+		}
+		for _, value := range p.Allowed {
+			if err := p.Type.check(value); err != nil {
+				return fmt.Errorf("allowed value %q of query param %s: %w", value, p.Name, err)
+			}
+		}
+	}
+	return m.setOptions(expr, func(o *routeOptions) {
+		o.query = params
+	})
+}
+
+// SetQueryErrorRenderer sets the renderer of the query schema violations,
+// nil restores the default renderer answering with a plain text 400 Bad Request.
+func (m *Mux) SetQueryErrorRenderer(renderer QueryErrorRenderer) {
+	m.queryErrorRenderer = renderer
+}
+
+func (m *Mux) renderQueryError(w http.ResponseWriter, r *http.Request, err *QueryError) {
+	if m.queryErrorRenderer != nil {
+		m.queryErrorRenderer(w, r, err)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// validateQuery validates the query of the request against the params
+func validateQuery(r *http.Request, params []QueryParam) *QueryError {
+	query := r.URL.Query()
+
+	var errs []*ParamError
+	for _, p := range params {
+		values, ok := query[p.Name]
+		if !ok {
+			if p.Required {
+				errs = append(errs, &ParamError{Name: p.Name, Err: ErrParamMissing})
+			}
+			continue
+		}
+		for _, value := range values {
+			if err := p.Type.check(value); err != nil {
+				errs = append(errs, &ParamError{Name: p.Name, Value: value, Err: err})
+				continue
+			}
+			if len(p.Allowed) != 0 && !slices.Contains(p.Allowed, value) {
+				errs = append(errs, &ParamError{Name: p.Name, Value: value, Err: errors.New("value not allowed")})
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &QueryError{Params: errs}
+}
+
+// check checks that the value is of the type
+func (t QueryType) check(value string) error {
+	var err error
+	switch t {
+	case QueryInt:
+		_, err = strconv.Atoi(value)
+	case QueryFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case QueryBool:
+		_, err = strconv.ParseBool(value)
+	}
+	if err != nil {
+		return fmt.Errorf("expected %s", t)
+	}
+	return nil
+}
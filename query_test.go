@@ -0,0 +1,59 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuerySchema(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/search")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.SetQuerySchema(`Path("/search")`,
+		QueryParam{Name: "q", Required: true},
+		QueryParam{Name: "limit", Type: QueryInt},
+		QueryParam{Name: "sort", Allowed: []string{"asc", "desc"}},
+		QueryParam{Name: "exact", Type: QueryBool},
+	))
+
+	serve := func(url string) *testWriter {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: url}))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/search?q=go&limit=10&sort=asc&exact=true").header)
+	assert.Equal(t, http.StatusOK, serve("/search?q=go").header)
+
+	w := serve("/search?limit=ten&sort=up&exact=maybe")
+	assert.Equal(t, http.StatusBadRequest, w.header)
+	assert.Equal(t, `invalid query: param q: missing param; param limit: bad value "ten": expected int; `+
+		`param sort: bad value "up": value not allowed; param exact: bad value "maybe": expected bool`+"\n", w.buf.String())
+
+	var reported *QueryError
+	m.SetQueryErrorRenderer(func(w http.ResponseWriter, _ *http.Request, err *QueryError) {
+		reported = err
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, serve("/search?q=go&limit=1&limit=x").header)
+	require.Len(t, reported.Params, 1)
+	assert.Equal(t, "limit", reported.Params[0].Name)
+	assert.Equal(t, "x", reported.Params[0].Value)
+
+	require.NoError(t, m.SetQuerySchema(`Path("/search")`))
+	assert.Equal(t, http.StatusOK, serve("/search").header)
+
+	assert.Error(t, m.SetQuerySchema(`Path("/search")`, QueryParam{}))
+	assert.Error(t, m.SetQuerySchema(`Path("/search")`, QueryParam{Name: "n", Type: QueryInt, Allowed: []string{"one"}}))
+	assert.Error(t, m.SetQuerySchema(`Path("/other")`, QueryParam{Name: "q"}))
+}
+
+func TestQueryType(t *testing.T) {
+	assert.Equal(t, "string", QueryString.String())
+	assert.Equal(t, "float", QueryFloat.String())
+	assert.NoError(t, QueryFloat.check("1.5"))
+	assert.Error(t, QueryFloat.check("x"))
+	assert.Equal(t, "unknown", QueryType(42).String())
+}
@@ -0,0 +1,52 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RegionResolver returns the region of the client of the request, e.g. from its IP address
+// or from a header set by the CDN, false if the region is unknown
+type RegionResolver func(r *http.Request) (string, bool)
+
+// SetRegionResolver sets the resolver used by the Region matchers of the mux,
+// by default the region is unknown and Region matchers never match.
+func (m *Mux) SetRegionResolver(resolver RegionResolver) {
+	m.settings.region = resolver
+}
+
+// HeaderRegion returns a resolver reading the region from the header, e.g. a header set by the CDN.
+// The header must not be forwarded from the clients.
+func HeaderRegion(name string) RegionResolver {
+	return func(r *http.Request) (string, bool) {
+		region := r.Header.Get(name)
+		return region, region != ""
+	}
+}
+
+// regionMatcher matches requests whose client region, as returned by the region resolver,
+// is one of the regions. Regions are compared case-insensitively.
+func regionMatcher(names ...string) (matcher, error) {
+	if len(names) == 0 {
+		return nil, errors.New("at least one region is required")
+	}
+	for _, name := range names {
+		if name == "" {
+			return nil, errors.New("region cannot be empty")
+		}
+	}
+	return newSettingsMatcher(fmt.Sprintf("Region(%s)", strings.Join(names, ", ")), func(req *http.Request, s *settings) bool {
+		region, ok := s.getRegion(req)
+		if !ok {
+			return false
+		}
+		for _, name := range names {
+			if strings.EqualFold(region, name) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
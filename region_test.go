@@ -0,0 +1,50 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionMatcher(t *testing.T) {
+	m, err := regionMatcher("eu-west", "eu-central")
+	require.NoError(t, err)
+
+	r := makeReq(req{url: "/", headers: http.Header{"X-Cdn-Region": {"EU-West"}}})
+	assert.Nil(t, m.match(r))
+
+	m.setSettings(&settings{region: HeaderRegion("X-Cdn-Region")})
+	assert.NotNil(t, m.match(r))
+	assert.Nil(t, m.match(makeReq(req{url: "/", headers: http.Header{"X-Cdn-Region": {"us-east"}}})))
+	assert.Nil(t, m.match(makeReq(req{url: "/", headers: http.Header{}})))
+
+	_, err = regionMatcher()
+	assert.Error(t, err)
+	_, err = regionMatcher("eu-west", "")
+	assert.Error(t, err)
+}
+
+func TestRegionRoutes(t *testing.T) {
+	mux := NewMux()
+	require.NoError(t, mux.Handle(`Region("eu-west") && Path("/api")`, newStatusHandler(http.StatusAccepted)))
+	require.NoError(t, mux.Handle(`Path("/api")`, newStatusHandler(http.StatusOK)))
+	mux.SetRegionResolver(func(r *http.Request) (string, bool) {
+		return r.Header.Get("X-Region"), true
+	})
+
+	// the resolver is set per mux, e.g. per tenant
+	other := NewMux()
+	require.NoError(t, other.Handle(`Region("eu-west") && Path("/api")`, newStatusHandler(http.StatusAccepted)))
+	require.NoError(t, other.Handle(`Path("/api")`, newStatusHandler(http.StatusOK)))
+
+	serve := func(m *Mux, region string) int {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/api", headers: http.Header{"X-Region": {region}}}))
+		return w.header
+	}
+	assert.Equal(t, http.StatusAccepted, serve(mux, "eu-west"))
+	assert.Equal(t, http.StatusOK, serve(mux, "us-east"))
+	assert.Equal(t, http.StatusOK, serve(other, "eu-west"))
+}
@@ -0,0 +1,135 @@
+package route
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MissResolver is called when a request for a host without any Host or HostRegexp matcher matching it
+// does not match any route.
+// It can synchronously fetch and register the routes of the host on the Mux, the request
+// is matched again once it returns. Concurrent misses for the same host share a single call,
+// misses for different hosts are resolved concurrently.
+type MissResolver func(m *Mux, host string) error
+
+// SetMissResolver sets the resolver called on misses for unknown hosts, nil disables it
+func (m *Mux) SetMissResolver(resolver MissResolver) {
+	m.resolver = resolver
+}
+
+// missResolution holds the state of the miss resolver
+type missResolution struct {
+	// flights share the resolution of a host between its concurrent misses
+	flights flightGroup
+}
+
+// knownHost returns true if a route has a Host matcher for the host or a Host pattern, a Host wildcard
+// or a HostRegexp matcher matching the host
+func (m *Mux) knownHost(host string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if _, ok := m.hosts[host]; ok {
+		return true
+	}
+	if len(m.hostPatterns) == 0 {
+		return false
+	}
+	patterns := m.hostRouter.Load()
+	if patterns == nil {
+		// the routers are rebuilt on the first miss following a change of the host patterns
+		patterns = New().(*router)
+		routes := make(map[string]interface{}, len(m.hostPatterns))
+		for expr := range m.hostPatterns {
+			routes[expr] = true
+		}
+		if err := patterns.InitRoutes(routes); err != nil {
+			return false
+		}
+		m.hostRouter.Store(patterns)
+	}
+	return patterns.route(&http.Request{Host: host, URL: &url.URL{}, Header: http.Header{}}) != nil
+}
+
+// resolveMiss calls the miss resolver for unknown hosts and matches the request again
+func (m *Mux) resolveMiss(r *http.Request) (interface{}, bool) {
+	if m.resolver == nil {
+		return nil, false
+	}
+	host := (&hostMapper{}).mapRequest(r)
+	if host == "" {
+		return nil, false
+	}
+
+	misses := m.misses
+	if m.knownHost(host) || (misses != nil && misses.contains(host)) {
+		return nil, false
+	}
+
+	_, err, _ := m.resolution.flights.do(host, func() (interface{}, error) {
+		return nil, m.resolver(m, host)
+	})
+	if err != nil {
+		if misses != nil {
+			misses.add(host, misses.errorTTL)
+		}
+		return nil, false
+	}
+
+	res := m.router.Load().route(r)
+	if res == nil {
+		if misses != nil {
+			misses.add(host, misses.ttl)
+		}
+		return nil, false
+	}
+	return res, true
+}
+
+// trackHost counts the routes with a Host matcher for the host, and the routes with a Host pattern,
+// a Host wildcard or a HostRegexp matcher by the expression of their host matcher
+func (m *Mux) trackHost(e *entry, delta int) {
+	if e.host != "" && !strings.ContainsAny(e.host, "<*") {
+		host := hostPattern(e.host)
+		if delta > 0 && m.misses != nil {
+			m.misses.remove(host)
+		}
+		m.hosts[host] += delta
+		if m.hosts[host] <= 0 {
+			delete(m.hosts, host)
+		}
+		return
+	}
+
+	expr := hostMatcherExpr(e)
+	if expr == "" {
+		return
+	}
+	if delta > 0 && m.misses != nil {
+		// the hosts matched by the pattern are not known
+		m.misses.clear()
+	}
+	m.hostPatterns[expr] += delta
+	if m.hostPatterns[expr] <= 0 {
+		delete(m.hostPatterns, expr)
+	}
+	m.hostRouter.Store(nil)
+}
+
+// hostMatcherExpr returns the Host matcher of the route if it has a pattern or a wildcard,
+// its HostRegexp matcher otherwise, empty if the route has neither
+func hostMatcherExpr(e *entry) string {
+	if e.host != "" {
+		return "Host(" + strconv.Quote(e.host) + ")"
+	}
+	ts, err := terms(e.expr)
+	if err != nil {
+		return ""
+	}
+	if expr := termArg(ts, "HostRegexp"); expr != "" {
+		return "HostRegexp(" + strconv.Quote(expr) + ")"
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissResolver(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Host("known.example.com") && Path("/a")`, newStatusHandler(http.StatusOK)))
+
+	var resolved []string
+	m.SetMissResolver(func(mux *Mux, host string) error {
+		resolved = append(resolved, host)
+		if host == "broken.example.com" {
+			return errors.New("control plane unavailable")
+		}
+		if host != "tenant.example.com" {
+			return nil
+		}
+		return mux.Handle(`Host("tenant.example.com") && Path("/a")`, newStatusHandler(http.StatusCreated))
+	})
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/a", host: "Tenant.example.com:8080"}))
+	assert.Equal(t, http.StatusCreated, w.header)
+
+	// the host is known now
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/b", host: "tenant.example.com"}))
+	assert.Equal(t, http.StatusNotFound, w.header)
+
+	// known hosts are not resolved
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/b", host: "known.example.com"}))
+	assert.Equal(t, http.StatusNotFound, w.header)
+
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/a", host: "broken.example.com"}))
+	assert.Equal(t, http.StatusNotFound, w.header)
+
+	assert.Equal(t, []string{"tenant.example.com", "broken.example.com"}, resolved)
+}
+
+func TestMissResolverConcurrent(t *testing.T) {
+	m := NewMux()
+
+	var calls atomic.Int32
+	m.SetMissResolver(func(mux *Mux, host string) error {
+		calls.Add(1)
+		if mux.knownHost(host) {
+			return nil
+		}
+		return mux.Handle(`Host("`+host+`")`, newStatusHandler(http.StatusOK))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := newWriter()
+			m.ServeHTTP(w, makeReq(req{url: "/", host: "tenant"}))
+			assert.Equal(t, http.StatusOK, w.header)
+		}()
+	}
+	wg.Wait()
+
+	assert.GreaterOrEqual(t, calls.Load(), int32(1))
+}
+
+func TestMissResolverUnrelatedHosts(t *testing.T) {
+	m := NewMux()
+
+	fastResolved := make(chan struct{})
+	m.SetMissResolver(func(mux *Mux, host string) error {
+		if host == "slow" {
+			// a slow resolution does not delay the resolution of other hosts
+			select {
+			case <-fastResolved:
+			case <-time.After(time.Second):
+				return errors.New("timeout")
+			}
+		}
+		return mux.Handle(`Host("`+host+`")`, newStatusHandler(http.StatusOK))
+	})
+
+	done := make(chan int)
+	go func() {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/", host: "slow"}))
+		done <- w.header
+	}()
+	require.Eventually(t, func() bool {
+		m.resolution.flights.mutex.Lock()
+		defer m.resolution.flights.mutex.Unlock()
+		_, ok := m.resolution.flights.calls["slow"]
+		return ok
+	}, time.Second, time.Millisecond)
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/", host: "fast"}))
+	assert.Equal(t, http.StatusOK, w.header)
+	close(fastResolved)
+	assert.Equal(t, http.StatusOK, <-done)
+}
+
+func TestMissResolverHostPatterns(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Host("*.static.example.com") && Path("/a")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Host("<tenant>.api.example.com") && Path("/a")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`HostRegexp("^[a-z]+\\.legacy\\.example\\.com$") && Path("/a")`, newStatusHandler(http.StatusOK)))
+
+	var resolved []string
+	m.SetMissResolver(func(mux *Mux, host string) error {
+		resolved = append(resolved, host)
+		return nil
+	})
+
+	// the hosts matched by the Host patterns, the Host wildcards and the HostRegexp matchers are known
+	for _, host := range []string{"cdn.static.example.com", "acme.api.example.com", "old.legacy.example.com", "other.example.com"} {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/b", host: host}))
+		assert.Equal(t, http.StatusNotFound, w.header)
+	}
+	assert.Equal(t, []string{"other.example.com"}, resolved)
+
+	// the removed patterns are forgotten
+	require.NoError(t, m.Remove(`Host("*.static.example.com") && Path("/a")`))
+	assert.False(t, m.knownHost("cdn.static.example.com"))
+	assert.True(t, m.knownHost("acme.api.example.com"))
+}
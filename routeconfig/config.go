@@ -0,0 +1,131 @@
+// Package routeconfig loads route tables from declarative YAML or JSON files mapping route expressions
+// to named backends, so the route tables of gateways can be kept in version control:
+//
+//	routes:
+//	  - expr: Host("api.example.com") && Path("/v1/users")
+//	    backend: users
+//	  - expr: |
+//	      Host("api.example.com") # public API
+//	        && PathRegexp("/v1/orders/.*")
+//	    backend: orders
+//
+// The backends are the handlers of the application, registered under their names.
+// With Options.ExpandEnv, the ${NAME} references in the expressions and the backends are replaced
+// with the values of the environment variables, e.g. Host("${API_HOST}"), see route.ExpandEnv.
+//
+// The package is a module of its own so that the route package does not depend on gopkg.in/yaml.v3.
+package routeconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/vulcand/route"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a declarative route table
+type Config struct {
+	Routes []Route `yaml:"routes" json:"routes"`
+}
+
+// Route maps a route expression to a backend
+type Route struct {
+	// Expr is the route expression
+	Expr string `yaml:"expr" json:"expr"`
+	// Backend is the name of the backend serving the route
+	Backend string `yaml:"backend" json:"backend"`
+}
+
+// Backends are the handlers the routes are mapped to, by name
+type Backends map[string]http.Handler
+
+// Options are the options of the configuration parsing
+type Options struct {
+	// ExpandEnv replaces the ${NAME} references in the expressions and the backends with the values of the
+	// environment variables, a reference to a variable that is not set is an error. See route.ExpandEnv.
+	ExpandEnv bool
+}
+
+// Parse parses the YAML or JSON configuration, unknown fields are rejected
+func Parse(data []byte, options Options) (*Config, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	c := &Config{}
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid route configuration: %w", err)
+	}
+	for i, r := range c.Routes {
+		if options.ExpandEnv {
+			var err error
+			if r.Expr, err = route.ExpandEnv(r.Expr); err != nil {
+				return nil, fmt.Errorf("route %d: expression: %w", i, err)
+			}
+			if r.Backend, err = route.ExpandEnv(r.Backend); err != nil {
+				return nil, fmt.Errorf("route %d: backend: %w", i, err)
+			}
+			c.Routes[i] = r
+		}
+		if r.Expr == "" {
+			return nil, fmt.Errorf("route %d: expression cannot be empty", i)
+		}
+		if r.Backend == "" {
+			return nil, fmt.Errorf("route %d: backend cannot be empty", i)
+		}
+	}
+	return c, nil
+}
+
+// Load reads and parses the configuration file
+func Load(path string, options Options) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, options)
+}
+
+// Handlers returns the handlers of the routes keyed by expression, as expected by Mux.InitHandlers.
+// It returns an error if a route refers to an unknown backend or if an expression is declared twice,
+// the spellings of an expression with the same canonical form being the same expression, see route.CanonicalExpr.
+func (c *Config) Handlers(backends Backends) (map[string]interface{}, error) {
+	handlers := make(map[string]interface{}, len(c.Routes))
+	declared := make(map[string]int, len(c.Routes))
+	for i, r := range c.Routes {
+		h, ok := backends[r.Backend]
+		if !ok || h == nil {
+			return nil, fmt.Errorf("route %d '%s': unknown backend %s", i, r.Expr, r.Backend)
+		}
+		canonical := route.CanonicalExpr(r.Expr)
+		if first, ok := declared[canonical]; ok {
+			return nil, fmt.Errorf("route %d '%s': expression is already declared by route %d", i, r.Expr, first)
+		}
+		declared[canonical] = i
+		handlers[r.Expr] = h
+	}
+	return handlers, nil
+}
+
+// Apply replaces the routes of the mux with the routes of the configuration, see Mux.InitHandlers.
+// When the expressions are invalid, the returned error is a *route.ValidationError.
+func (c *Config) Apply(m *route.Mux, backends Backends) error {
+	handlers, err := c.Handlers(backends)
+	if err != nil {
+		return err
+	}
+	return m.InitHandlers(handlers)
+}
+
+// LoadFile loads the configuration file and applies it to the mux
+func LoadFile(m *route.Mux, path string, backends Backends, options Options) error {
+	c, err := Load(path, options)
+	if err != nil {
+		return err
+	}
+	return c.Apply(m, backends)
+}
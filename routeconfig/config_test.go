@@ -0,0 +1,135 @@
+package routeconfig
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/route"
+)
+
+func statusHandler(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+}
+
+func serve(m *route.Mux, host, path string) int {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r.Host = host
+	m.ServeHTTP(w, r)
+	return w.Code
+}
+
+const yamlConfig = `
+routes:
+  - expr: Host("api.example.com") && Path("/v1/users")
+    backend: users
+  - expr: |
+      Host("api.example.com") # public API
+        && Path("/v1/orders")
+    backend: orders
+`
+
+func TestParse(t *testing.T) {
+	c, err := Parse([]byte(yamlConfig), Options{})
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Routes: []Route{
+		{Expr: `Host("api.example.com") && Path("/v1/users")`, Backend: "users"},
+		{Expr: "Host(\"api.example.com\") # public API\n  && Path(\"/v1/orders\")\n", Backend: "orders"},
+	}}, c)
+
+	c, err = Parse([]byte(`{"routes": [{"expr": "Path(\"/health\")", "backend": "health"}]}`), Options{})
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Routes: []Route{{Expr: `Path("/health")`, Backend: "health"}}}, c)
+
+	c, err = Parse(nil, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, c.Routes)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, data := range []string{
+		`routes: [{expr: Path("/"), backend: users, weight: 2}]`,
+		`routes: [{backend: users}]`,
+		`routes: [{expr: Path("/")}]`,
+		`routes: {}`,
+	} {
+		_, err := Parse([]byte(data), Options{})
+		assert.Error(t, err, data)
+	}
+}
+
+func TestApply(t *testing.T) {
+	c, err := Parse([]byte(yamlConfig), Options{})
+	require.NoError(t, err)
+	backends := Backends{
+		"users":  statusHandler(http.StatusOK),
+		"orders": statusHandler(http.StatusAccepted),
+	}
+
+	m := route.NewMux()
+	require.NoError(t, c.Apply(m, backends))
+	assert.Equal(t, http.StatusOK, serve(m, "api.example.com", "/v1/users"))
+	assert.Equal(t, http.StatusAccepted, serve(m, "api.example.com", "/v1/orders"))
+	assert.Equal(t, http.StatusNotFound, serve(m, "example.com", "/v1/users"))
+
+	delete(backends, "orders")
+	assert.Error(t, c.Apply(m, backends))
+
+	c.Routes = append(c.Routes, Route{Expr: c.Routes[0].Expr, Backend: "users"})
+	assert.Error(t, c.Apply(m, Backends{"users": statusHandler(http.StatusOK), "orders": statusHandler(http.StatusOK)}))
+
+	// the spellings of an expression are the same expression
+	c.Routes[2].Expr = "Host( `api.example.com` ) && Path(\"/v1/users\") # users"
+	assert.Error(t, c.Apply(m, Backends{"users": statusHandler(http.StatusOK), "orders": statusHandler(http.StatusOK)}))
+}
+
+func TestParseExpandEnv(t *testing.T) {
+	t.Setenv("API_HOST", "api.example.com")
+	t.Setenv("USERS_BACKEND", "users")
+
+	data := []byte(`
+routes:
+  - expr: Host("${API_HOST}") && PathRegexp("/v1/users$")
+    backend: ${USERS_BACKEND}
+`)
+	c, err := Parse(data, Options{ExpandEnv: true})
+	require.NoError(t, err)
+	assert.Equal(t, []Route{{Expr: `Host("api.example.com") && PathRegexp("/v1/users$")`, Backend: "users"}}, c.Routes)
+
+	// the references are left untouched without the option
+	c, err = Parse(data, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []Route{{Expr: `Host("${API_HOST}") && PathRegexp("/v1/users$")`, Backend: "${USERS_BACKEND}"}}, c.Routes)
+
+	_, err = Parse([]byte(`routes: [{expr: 'Host("${MISSING_HOST}")', backend: users}]`), Options{ExpandEnv: true})
+	assert.ErrorContains(t, err, "MISSING_HOST")
+}
+
+func TestApplyInvalidExpression(t *testing.T) {
+	c := &Config{Routes: []Route{{Expr: `Pth("/")`, Backend: "users"}}}
+	err := c.Apply(route.NewMux(), Backends{"users": statusHandler(http.StatusOK)})
+	var validation *route.ValidationError
+	assert.True(t, errors.As(err, &validation))
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(yamlConfig), 0o600))
+
+	m := route.NewMux()
+	require.NoError(t, LoadFile(m, path, Backends{
+		"users":  statusHandler(http.StatusOK),
+		"orders": statusHandler(http.StatusAccepted),
+	}, Options{}))
+	assert.Equal(t, http.StatusAccepted, serve(m, "api.example.com", "/v1/orders"))
+
+	assert.Error(t, LoadFile(m, filepath.Join(t.TempDir(), "missing.yaml"), nil, Options{}))
+}
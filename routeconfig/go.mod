@@ -0,0 +1,22 @@
+module github.com/vulcand/route/routeconfig
+
+go 1.24.0
+
+require (
+	github.com/stretchr/testify v1.11.1
+	github.com/vulcand/route v0.0.0-00010101000000-000000000000
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gravitational/trace v1.5.1 // indirect
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/vulcand/predicate v1.3.0 // indirect
+	golang.org/x/net v0.46.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
+)
+
+// the package is developed with the route package of the repository
+replace github.com/vulcand/route => ../
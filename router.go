@@ -10,12 +10,18 @@ Route defines simple language for matching requests based on Go syntax. Route pr
 Host matcher:
 
 	Host("<subdomain>.localhost") // trie-based matcher for a.localhost, b.localhost, etc.
+	Host("*.localhost")           // matches the subdomains at any depth, e.g. a.localhost and a.b.localhost
 	HostRegexp(".*localhost")     // regexp based matcher
 
 Path matcher:
 
 	Path("/hello/<value>")   // trie-based matcher for raw request path
 	PathRegexp("/hello/.*")  // regexp-based matcher for raw request path
+	PathSegment(2, "admin")  // matches the second path segment, e.g. /v1/admin/users
+	PathGlob("/img/*.png")   // shell-style glob, a '**' segment matches any number of segments
+
+The values captured by Host and Path patterns and by PathRegexp named groups, e.g. "/users/(?P<id>[0-9]+)",
+are available to the Mux handlers with ParamsFromContext or Param(r, "subdomain").
 
 Method matcher:
 
@@ -25,12 +31,78 @@ Method matcher:
 Header matcher:
 
 	Header("Content-Type", "application/<subtype>") // trie-based matcher for headers
-	HeaderRegexp("Content-Type", "application/.*")  // regexp based matcher for headers
+	HeaderRegexp("Content-Type", "application/.*")  // regexp based matcher for headers, matching any of the header values
+	HeaderContainsToken("X-Features", "beta")       // matches comma-separated header lists containing the token
+
+Query matcher:
+
+	Query("version", "2")         // trie-based matcher for the first value of the query parameter
+	QueryRegexp("version", "2|3") // regexp based matcher, matching any of the query parameter values
+
+Protocol matcher:
+
+	Proto("HTTP/3")   // matches HTTP/3 requests, e.g. served by a QUIC server
+	Proto("HTTP/1.1") // matches HTTP/1.1 requests only
+
+Region matcher:
+
+	Region("eu-west")               // matches clients in the region returned by the resolver set with Mux.SetRegionResolver
+	Region("eu-west", "eu-central") // matches clients in any of the regions
+
+Feature flag matcher:
+
+	Flag("new-checkout") // matches requests for which the flag of the provider set with Mux.SetFlagProvider is on
+
+API version matcher:
+
+	Version(">=2.1 <3") // matches requests whose version, e.g. Accept-Version: 2.4, is in the semver range
+	Version("^1", "2")  // matches the versions 1.x and 2.x, see Mux.SetVersionSource to read the version elsewhere
+
+Client address matcher:
+
+	ClientIP("10.0.0.0/8", "2001:db8::/32", "192.0.2.1") // matches the remote address of the connection
+
+Request limit matchers, e.g. to route abusive requests to a rejection handler:
+
+	QueryParamsOver(50)   // matches requests with more than 50 query parameters
+	URLLengthOver(2048)   // matches requests whose request URI is longer than 2048 bytes
+	HeaderCountOver(64)   // matches requests with more than 64 header fields
+	HeaderBytesOver(8192) // matches requests whose header fields are larger than 8192 bytes
+
+Request age matcher:
+
+	Stale("Date", "5m")                      // matches requests with a missing, malformed or skewed Date header
+	Stale("X-Slack-Request-Timestamp", "5m") // timestamp headers can also hold unix seconds
+
+Webhook signature matcher:
+
+	SignedWith("github", "GITHUB_SECRET") // matches requests signed with the secret from the GITHUB_SECRET variable
+
+SignedWith matchers can't be negated nor combined with ||. The Mux routes the requests carrying a signature
+and verifies it after the match, answering 401 Unauthorized if it is invalid, and the timestamps of the stripe
+and slack signatures must be within the tolerance set with Mux.SetSignatureTolerance.
+
+Custom matchers registered with RegisterMatcher take string arguments:
+
+	GeoCountry("DE", "AT") // matches the requests for which the registered predicate returns true
 
 Matchers can be combined using && operator:
 
 	Host("localhost") && Method("POST") && Path("/v1")
 
+Matchers can be negated using ! operator and combined using || operator, with parentheses for grouping:
+
+	Path("/api") && !Header("X-Internal", "true")
+	Host("localhost") && (Path("/v1") || Path("/v2"))
+
+Negated and || combined matchers are matched separately, they are never joined into the tries,
+and the params are only captured by the patterns of the matchers required by the expression.
+
+Expressions can span several lines, a '#' outside of string literals starts a comment running to the end of the line:
+
+	Host("localhost") # internal API
+	  && Path("/v1")
+
 Route library will join the trie-based matchers into one trie matcher when possible, for example:
 
 	Host("localhost") && Method("POST") && Path("/v1")
@@ -46,6 +118,7 @@ package route
 
 import (
 	"fmt"
+	"maps"
 	"net/http"
 	"sort"
 	"sync"
@@ -80,7 +153,15 @@ type Router interface {
 type router struct {
 	mutex    *sync.RWMutex
 	matchers []matcher
-	routes   map[string]*match
+	// firsts are the positions in the route table of the first route of each matcher, see route
+	firsts []int
+	// positions are the positions of the routes in the route table
+	positions map[*match]int
+	routes    map[string]*match
+	// pool is the optional compilation cache shared with other routers
+	pool *CompilePool
+	// settings are the settings of the Mux read by the matchers, nil for the defaults
+	settings *settings
 }
 
 // New creates a new Router instance
@@ -91,6 +172,22 @@ func New() Router {
 	}
 }
 
+// clone returns a copy of the router sharing the compiled matchers until the copy is compiled again
+func (r *router) clone() *router {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return &router{
+		mutex:     &sync.RWMutex{},
+		matchers:  r.matchers,
+		firsts:    r.firsts,
+		positions: r.positions,
+		routes:    maps.Clone(r.routes),
+		pool:      r.pool,
+		settings:  r.settings,
+	}
+}
+
 func (r *router) GetRoute(expr string) interface{} {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
@@ -109,7 +206,7 @@ func (r *router) InitRoutes(routes map[string]interface{}) error {
 	r.routes = make(map[string]*match, len(routes))
 	for expr, val := range routes {
 		result := &match{val: val}
-		if _, err := parse(expr, result); err != nil {
+		if _, err := r.parse(expr, result); err != nil {
 			return err
 		}
 		r.routes[expr] = result
@@ -130,7 +227,7 @@ func (r *router) AddRoute(expr string, val interface{}) error {
 		return fmt.Errorf("expression '%s' already exists", expr)
 	}
 	result := &match{val: val}
-	if _, err := parse(expr, result); err != nil {
+	if _, err := r.parse(expr, result); err != nil {
 		return err
 	}
 	r.routes[expr] = result
@@ -146,7 +243,7 @@ func (r *router) UpsertRoute(expr string, val interface{}) error {
 	defer r.mutex.Unlock()
 
 	result := &match{val: val}
-	if _, err := parse(expr, result); err != nil {
+	if _, err := r.parse(expr, result); err != nil {
 		return err
 	}
 	prev, existed := r.routes[expr]
@@ -163,44 +260,140 @@ func (r *router) UpsertRoute(expr string, val interface{}) error {
 	return nil
 }
 
+// parse parses the expression into a matcher reading the settings of the router,
+// or copies the matcher of the expression cached in the pool
+func (r *router) parse(expr string, result *match) (matcher, error) {
+	m, err := r.pool.parse(expr, result)
+	if err != nil {
+		return nil, err
+	}
+	m.setSettings(r.settings)
+	return m, nil
+}
+
 func (r *router) compile() error {
 	var exprs []string
 	for expr := range r.routes {
 		exprs = append(exprs, expr)
 	}
 	sort.Sort(sort.Reverse(sort.StringSlice(exprs)))
+	// routes with an explicit priority come first, the routes of the same priority keep their order
+	sort.SliceStable(exprs, func(i, j int) bool {
+		return routePriority(r.routes[exprs[i]]) > routePriority(r.routes[exprs[j]])
+	})
+
+	hosts := make([]string, len(exprs))
+	priorities := make([]int, len(exprs))
+	positions := make(map[*match]int, len(exprs))
+	for i, expr := range exprs {
+		hosts[i], _ = literalHost(expr)
+		priorities[i] = routePriority(r.routes[expr])
+		positions[r.routes[expr]] = i
+	}
 
 	var matchers []matcher
-	i := 0
-	for _, expr := range exprs {
-		result := r.routes[expr]
-		matcher, err := parse(expr, result)
-		if err != nil {
-			return err
+	var firsts []int
+	// tries are the matchers of the current priority the next tries can be merged into
+	var tries []int
+	for start := 0; start < len(exprs); {
+		if start > 0 && priorities[start] != priorities[start-1] {
+			// matchers of different priorities are never merged as the merged tries prefer the most specific routes
+			tries = tries[:0]
+		}
+
+		end := start + 1
+		for hosts[start] != "" && end < len(exprs) && hosts[end] != "" && priorities[end] == priorities[start] {
+			end++
 		}
 
-		// Merge the previous and new matcher if that's possible
-		if i > 0 && matchers[i-1].canMerge(matcher) {
-			m, err := matchers[i-1].merge(matcher)
+		// Dispatch the large runs of routes with a literal Host matcher through a host index
+		if end-start >= minIndexedHosts && distinct(hosts[start:end]) >= minIndexedHosts {
+			index := newHostIndex()
+			for i := start; i < end; i++ {
+				matcher, err := r.parse(exprs[i], r.routes[exprs[i]])
+				if err != nil {
+					return err
+				}
+				if err := index.add(hosts[i], matcher); err != nil {
+					return err
+				}
+			}
+			matchers = append(matchers, index)
+			firsts = append(firsts, start)
+			start = end
+			continue
+		}
+
+		for i := start; i < end; i++ {
+			matcher, err := r.parse(exprs[i], r.routes[exprs[i]])
 			if err != nil {
 				return err
 			}
-			matchers[i-1] = m
-		} else {
+
+			// Merge the new matcher into a trie of the same priority if that's possible, even across
+			// the routes that can't be merged so that they don't split the tries, see route
+			merged := false
+			for _, j := range tries {
+				if matchers[j].canMerge(matcher) {
+					if matchers[j], err = matchers[j].merge(matcher); err != nil {
+						return err
+					}
+					merged = true
+					break
+				}
+			}
+			if merged {
+				continue
+			}
+			if _, ok := matcher.(*trie); ok {
+				tries = append(tries, len(matchers))
+			}
 			matchers = append(matchers, matcher)
-			i += 1
+			firsts = append(firsts, i)
 		}
+		start = end
 	}
 
+	r.firsts = firsts
+	r.positions = positions
 	r.matchers = matchers
 	return nil
 }
 
+// prioritized is implemented by the route values with an explicit priority, see Mux.HandleWithPriority
+type prioritized interface {
+	routePriority() int
+}
+
+// routePriority returns the priority of the route, 0 if the route value has no explicit priority
+func routePriority(m *match) int {
+	if p, ok := m.val.(prioritized); ok {
+		return p.routePriority()
+	}
+	return 0
+}
+
+// distinct returns the number of distinct values
+func distinct(values []string) int {
+	seen := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		seen[v] = struct{}{}
+	}
+	return len(seen)
+}
+
 func (r *router) RemoveRoute(expr string) error {
+	return r.removeRoutes(expr)
+}
+
+// removeRoutes removes the routes for the given expressions compiling the matchers only once
+func (r *router) removeRoutes(exprs ...string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	delete(r.routes, expr)
+	for _, expr := range exprs {
+		delete(r.routes, expr)
+	}
 	return r.compile()
 }
 
@@ -208,14 +401,28 @@ func (r *router) Route(req *http.Request) (interface{}, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	if len(r.matchers) == 0 {
-		return nil, nil
-	}
+	return r.route(req), nil
+}
 
-	for _, m := range r.matchers {
+// route matches the request against the matchers, the caller holds the lock. A merged trie matches
+// the most specific of its routes, which is served unless a route before it in the route table
+// matches the request too, so the matchers are evaluated until the ones left only hold routes after
+// the best match found.
+func (r *router) route(req *http.Request) interface{} {
+	var best *match
+	var position int
+	for i, m := range r.matchers {
+		if best != nil && r.firsts[i] >= position {
+			break
+		}
 		if l := m.match(req); l != nil {
-			return l.val, nil
+			if p := r.positions[l]; best == nil || p < position {
+				best, position = l, p
+			}
 		}
 	}
-	return nil, nil
+	if best == nil {
+		return nil
+	}
+	return best.val
 }
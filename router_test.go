@@ -392,6 +392,34 @@ func (s *RouteSuite) TestMatchCases() {
 				},
 			},
 		},
+		{
+			name: "Tries are merged across the routes sorting between them",
+			routes: []route{
+				{expr: `Path("/users/x")`, match: "m1"},
+				{expr: `Path("/users/me") || Path("/me")`, match: "m2"},
+				{expr: `Path("/users/<id>")`, match: "m3"},
+			},
+			expected: 2,
+			tries: []try{
+				{
+					r:     req{url: "http://google.com/users/x"},
+					match: "m1",
+				},
+				{
+					// the route before the trie route in the route table is served
+					r:     req{url: "http://google.com/users/me"},
+					match: "m2",
+				},
+				{
+					r:     req{url: "http://google.com/me"},
+					match: "m2",
+				},
+				{
+					r:     req{url: "http://google.com/users/42"},
+					match: "m3",
+				},
+			},
+		},
 	}
 	for _, test := range tc {
 		comment := fmt.Sprintf("%v", test.name)
@@ -714,3 +742,54 @@ var githubAPI = []spec{
 	// {http.MethodPatch, "/user/keys/:id"},
 	{http.MethodDelete, "/user/keys/:id"},
 }
+
+func BenchmarkRouteLargeTable(b *testing.B) {
+	for _, size := range []int{1000, 20000} {
+		b.Run(fmt.Sprintf("%d routes", size), func(b *testing.B) {
+			routes := make(map[string]interface{}, size)
+			for i := 0; i < size; i++ {
+				routes[fmt.Sprintf(`Method("GET") && Path("/api/v%d/resource%d/<id>")`, i%10, i)] = i
+			}
+			r := New()
+			if err := r.InitRoutes(routes); err != nil {
+				b.Fatal(err)
+			}
+
+			rq := makeReq(req{url: fmt.Sprintf("http://localhost/api/v%d/resource%d/42", (size-1)%10, size-1), method: http.MethodGet})
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if v, _ := r.Route(rq); v != size-1 {
+					b.Fatalf("expected %d, got %v", size-1, v)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRouteLargeTableInterleaved(b *testing.B) {
+	for _, size := range []int{1000, 20000} {
+		b.Run(fmt.Sprintf("%d routes", size), func(b *testing.B) {
+			routes := make(map[string]interface{}, size)
+			for i := 0; i < size; i++ {
+				routes[fmt.Sprintf(`Method("GET") && Path("/api/v%d/resource%d/<id>")`, i%10, i)] = i
+				// one route in a hundred can't be merged into a trie and sorts between the trie routes
+				if i%100 == 0 {
+					routes[fmt.Sprintf(`Method("GET") && Path("/api/v%d/resource%d/<id>/export") || Path("/export/%d")`, i%10, i, i)] = -i
+				}
+			}
+			r := New()
+			if err := r.InitRoutes(routes); err != nil {
+				b.Fatal(err)
+			}
+
+			// the route sorting last in the route table
+			rq := makeReq(req{url: "http://localhost/api/v0/resource0/42", method: http.MethodGet})
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if v, _ := r.Route(rq); v != 0 {
+					b.Fatalf("expected 0, got %v", v)
+				}
+			}
+		})
+	}
+}
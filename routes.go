@@ -0,0 +1,172 @@
+package route
+
+import (
+	"cmp"
+	"iter"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// RouteInfo describes a route registered in the Mux
+type RouteInfo struct {
+	// Expr is the expression the route was registered with
+	Expr string
+	// Key is the identity of the route computed by the key function of the mux, see Mux.SetKeyFunc
+	Key string
+	// Host is the argument of the Host matcher of the expression, empty if there is none
+	Host string
+	// Path is the argument of the Path matcher of the expression, empty if there is none
+	Path string
+	// Handler is the handler serving the route, it is not encoded so snapshots can be persisted as JSON
+	Handler http.Handler `json:"-"`
+	// Owner is the owner of the route, empty if the route is not owned
+	Owner string
+	// Alias is the expression rewritten by the aliases the route is also registered for, see Mux.AddAlias,
+	// empty if no alias applies
+	Alias string
+	// Priority is the explicit priority of the route, see Mux.HandleWithPriority
+	Priority int
+	// Meta is the metadata of the route, see Mux.HandleMeta
+	Meta map[string]string
+}
+
+// String returns the canonical form of the expression matched by the router for the route,
+// the expression rewritten by the aliases if any apply, see Expr.String
+func (i RouteInfo) String() string {
+	expr := i.Expr
+	if i.Alias != "" {
+		expr = i.Alias
+	}
+	parsed, err := Parse(expr)
+	if err != nil {
+		return expr
+	}
+	return parsed.String()
+}
+
+func (e *entry) info() RouteInfo {
+	return RouteInfo{Expr: e.expr, Key: e.key, Host: e.host, Path: e.path, Handler: e.handler, Owner: e.owner, Alias: e.alias, Priority: e.priority, Meta: maps.Clone(e.meta)}
+}
+
+// Routes returns an iterator over the registered routes in trie order: by host, then by path.
+// Routes are produced one by one, so the caller can stop early without paying for the whole table.
+func (m *Mux) Routes() iter.Seq[RouteInfo] {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return seqOf(slices.Clone(m.sorted))
+}
+
+// Walk calls fn for every registered route in trie order until fn returns false
+func (m *Mux) Walk(fn func(RouteInfo) bool) {
+	for info := range m.Routes() {
+		if !fn(info) {
+			return
+		}
+	}
+}
+
+// RoutesUnder returns an iterator over the routes under the prefix in trie order.
+// A prefix starting with '/' selects the routes with a Path matcher under that path on any host,
+// matching whole segments: /api/v1 selects /api/v1 and /api/v1/users but not /api/v10.
+// Otherwise the prefix selects the routes with a Host matcher starting with the prefix.
+func (m *Mux) RoutesUnder(prefix string) iter.Seq[RouteInfo] {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return seqOf(m.entriesUnder(prefix))
+}
+
+// RemoveUnder removes all routes under the prefix, see RoutesUnder for the prefix semantics.
+// It returns the number of removed routes, nothing is removed if any of the routes is owned.
+func (m *Mux) RemoveUnder(prefix string) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	entries := m.entriesUnder(prefix)
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	for _, e := range entries {
+		if e.owner != "" {
+			return 0, ownedError(e)
+		}
+	}
+
+	exprs := make([]string, len(entries))
+	for i, e := range entries {
+		exprs[i] = e.expr
+	}
+	err := m.update(func(r *router) error {
+		return m.remove(r, exprs...)
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range entries {
+		m.forget(m.keyFunc(e.expr))
+		m.notify(MutationRemove, e.expr, "")
+	}
+	return len(entries), nil
+}
+
+// entriesUnder uses binary search over the sorted routes to find the routes under the prefix
+func (m *Mux) entriesUnder(prefix string) []*entry {
+	var out []*entry
+	if !strings.HasPrefix(prefix, "/") {
+		i, _ := slices.BinarySearchFunc(m.sorted, prefix, func(e *entry, p string) int {
+			return cmp.Compare(e.host, p)
+		})
+		for ; i < len(m.sorted) && strings.HasPrefix(m.sorted[i].host, prefix); i++ {
+			out = append(out, m.sorted[i])
+		}
+		return out
+	}
+
+	// Routes are sorted by host first, so look for the path prefix in every host group
+	for i := 0; i < len(m.sorted); {
+		host := m.sorted[i].host
+		j, _ := slices.BinarySearchFunc(m.sorted[i:], prefix, func(e *entry, p string) int {
+			return cmp.Or(cmp.Compare(e.host, host), cmp.Compare(e.path, p))
+		})
+		for j += i; j < len(m.sorted) && m.sorted[j].host == host && strings.HasPrefix(m.sorted[j].path, prefix); j++ {
+			if pathUnder(m.sorted[j].path, prefix) {
+				out = append(out, m.sorted[j])
+			}
+		}
+		// skip to the next host group
+		next, _ := slices.BinarySearchFunc(m.sorted[j:], host, func(e *entry, h string) int {
+			if e.host <= h {
+				return -1
+			}
+			return 1
+		})
+		i = j + next
+	}
+	return out
+}
+
+// pathUnder returns true if the path starting with the prefix is under it, the prefix ending on a segment boundary
+func pathUnder(path, prefix string) bool {
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+func seqOf(entries []*entry) iter.Seq[RouteInfo] {
+	return func(yield func(RouteInfo) bool) {
+		for _, e := range entries {
+			if !yield(e.info()) {
+				return
+			}
+		}
+	}
+}
+
+func compareEntries(a, b *entry) int {
+	return cmp.Or(
+		cmp.Compare(a.host, b.host),
+		cmp.Compare(a.path, b.path),
+		cmp.Compare(a.expr, b.expr),
+	)
+}
@@ -0,0 +1,121 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutes(t *testing.T) {
+	m := NewMux()
+	m.AddAlias(`Host("localhost")`, `Host("example.com")`)
+
+	require.NoError(t, m.Handle(`Path("/b")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Host("localhost") && Path("/a")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Host("api") && Path("/c")`, newStatusHandler(http.StatusOK)))
+
+	var exprs, aliases []string
+	for info := range m.Routes() {
+		assert.NotNil(t, info.Handler)
+		exprs = append(exprs, info.Expr)
+		aliases = append(aliases, info.Alias)
+	}
+	assert.Equal(t, []string{`Path("/b")`, `Host("api") && Path("/c")`, `Host("localhost") && Path("/a")`}, exprs)
+	assert.Equal(t, []string{"", "", `Host("example.com") && Path("/a")`}, aliases)
+
+	var printed []string
+	for info := range m.Routes() {
+		printed = append(printed, info.String())
+	}
+	assert.Equal(t, []string{`Path("/b")`, `Host("api") && Path("/c")`, `Host("example.com") && Path("/a")`}, printed)
+
+	exprs = nil
+	for info := range m.Routes() {
+		exprs = append(exprs, info.Expr)
+		break
+	}
+	assert.Len(t, exprs, 1)
+}
+
+func TestWalk(t *testing.T) {
+	m := NewMux()
+
+	require.NoError(t, m.Handle(`Host("a") && Path("/v1/users")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Host("a") && Path("/v1/groups")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Host("b") && Path("/v1")`, newStatusHandler(http.StatusOK)))
+
+	var visited []RouteInfo
+	m.Walk(func(info RouteInfo) bool {
+		visited = append(visited, info)
+		return info.Host == "a"
+	})
+
+	require.Len(t, visited, 3)
+	assert.Equal(t, "/v1/groups", visited[0].Path)
+	assert.Equal(t, "/v1/users", visited[1].Path)
+	assert.Equal(t, "b", visited[2].Host)
+}
+
+func TestRoutesUnder(t *testing.T) {
+	m := NewMux()
+
+	exprs := []string{
+		`Host("api.a") && Path("/v1/users")`,
+		`Host("api.a") && Path("/v2/users")`,
+		`Host("api.b") && Path("/v1/groups")`,
+		`Host("web") && Path("/v1")`,
+		`Path("/v1/status")`,
+		`Path("/v10/status")`,
+		`Path("/v1-beta/status")`,
+		`Method("GET")`,
+	}
+	for _, expr := range exprs {
+		require.NoError(t, m.Handle(expr, newStatusHandler(http.StatusOK)))
+	}
+
+	collect := func(prefix string) []string {
+		var out []string
+		for info := range m.RoutesUnder(prefix) {
+			out = append(out, info.Expr)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{
+		`Path("/v1/status")`,
+		`Host("api.a") && Path("/v1/users")`,
+		`Host("api.b") && Path("/v1/groups")`,
+		`Host("web") && Path("/v1")`,
+	}, collect("/v1"))
+	assert.Equal(t, []string{`Host("api.a") && Path("/v1/users")`, `Host("api.a") && Path("/v2/users")`}, collect("api.a"))
+	assert.Len(t, collect("api."), 3)
+	assert.Empty(t, collect("/v3"))
+
+	n, err := m.RemoveUnder("/v1/")
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	// the routes of /v10 and /v1-beta are not under /v1
+	assert.Equal(t, []string{`Host("web") && Path("/v1")`}, collect("/v1"))
+	n, err = m.RemoveUnder("/v1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.Equal(t, []string{
+		`Method("GET")`, `Path("/v1-beta/status")`, `Path("/v10/status")`, `Host("api.a") && Path("/v2/users")`,
+	}, collectAll(m))
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/v1/users", host: "api.a", method: http.MethodPost}))
+	assert.Equal(t, http.StatusNotFound, w.header)
+}
+
+func collectAll(m *Mux) []string {
+	var out []string
+	for info := range m.Routes() {
+		out = append(out, info.Expr)
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// BackgroundFunc is a background component of the mux, e.g. a health checker or a configuration watcher.
+// It runs until the context is canceled, an error stops the other components, see Mux.Run.
+type BackgroundFunc func(ctx context.Context) error
+
+type background struct {
+	name string
+	fn   BackgroundFunc
+}
+
+// backgroundState holds the background components of the mux
+type backgroundState struct {
+	funcs   []background
+	running bool
+	// compactions signals that the automatic compaction is due, see SetAutoCompact
+	compactions chan struct{}
+}
+
+// AddBackground adds a background component run by Run under the name, it can't be added while Run is running
+func (m *Mux) AddBackground(name string, fn BackgroundFunc) error {
+	if fn == nil {
+		return errors.New("background function cannot be nil: operation rejected")
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.background.running {
+		return fmt.Errorf("background %s cannot be added while the mux is running", name)
+	}
+	m.background.funcs = append(m.background.funcs, background{name: name, fn: fn})
+	return nil
+}
+
+// Run runs the background components of the mux until the context is canceled: the automatic compaction,
+// see SetAutoCompact, and the components added with AddBackground. When a component fails, the other
+// components are canceled and Run returns the error once they all returned. Run returns nil when the
+// context is canceled and the components shut down cleanly. It can't be called again while running.
+func (m *Mux) Run(ctx context.Context) error {
+	m.mutex.Lock()
+	if m.background.running {
+		m.mutex.Unlock()
+		return errors.New("mux is already running")
+	}
+	m.background.running = true
+	funcs := append([]background{{name: "compaction", fn: m.runCompaction}}, m.background.funcs...)
+	m.mutex.Unlock()
+
+	defer func() {
+		m.mutex.Lock()
+		m.background.running = false
+		m.mutex.Unlock()
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for _, b := range funcs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := b.fn(ctx)
+			if err == nil || (ctx.Err() != nil && errors.Is(err, ctx.Err())) {
+				return
+			}
+			once.Do(func() {
+				firstErr = fmt.Errorf("background %s: %w", b.name, err)
+				cancel()
+			})
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// runCompaction compacts the mux when the automatic compaction is due
+func (m *Mux) runCompaction(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-m.background.compactions:
+			if err := m.Compact(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	m := NewMux()
+	var stopped atomic.Int32
+	started := make(chan struct{}, 2)
+	for _, name := range []string{"watcher", "health"} {
+		require.NoError(t, m.AddBackground(name, func(ctx context.Context) error {
+			started <- struct{}{}
+			<-ctx.Done()
+			stopped.Add(1)
+			return ctx.Err()
+		}))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- m.Run(ctx) }()
+	<-started
+	<-started
+
+	assert.Error(t, m.Run(ctx))
+	assert.Error(t, m.AddBackground("late", func(context.Context) error { return nil }))
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, int32(2), stopped.Load())
+
+	// the mux can run again once stopped
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	go func() {
+		<-started
+		<-started
+	}()
+	assert.NoError(t, m.Run(ctx))
+}
+
+func TestRunError(t *testing.T) {
+	m := NewMux()
+	var canceled atomic.Bool
+	require.NoError(t, m.AddBackground("watcher", func(ctx context.Context) error {
+		<-ctx.Done()
+		canceled.Store(true)
+		return nil
+	}))
+	require.NoError(t, m.AddBackground("health", func(context.Context) error {
+		return errors.New("probe failed")
+	}))
+
+	err := m.Run(context.Background())
+	assert.EqualError(t, err, "background health: probe failed")
+	assert.True(t, canceled.Load())
+
+	assert.Error(t, m.AddBackground("nil", nil))
+}
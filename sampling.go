@@ -0,0 +1,123 @@
+package route
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxCaptureBody is the maximum size of the request and response bodies kept in captures
+const maxCaptureBody = 64 << 10
+
+// Capture is a request and its response captured by the sampling of a route
+type Capture struct {
+	// Expr is the expression of the route
+	Expr    string
+	Request *http.Request
+	// RequestBody holds the first 64KB of the request body read by the handler
+	RequestBody []byte
+	Status      int
+	Header      http.Header
+	// ResponseBody holds the first 64KB of the response body
+	ResponseBody []byte
+	// Truncated is true if one of the bodies was larger than the captured part
+	Truncated bool
+	Duration  time.Duration
+}
+
+// sampling hands a share of the requests of a route to a callback
+type sampling struct {
+	expr string
+	rate float64
+	fn   func(Capture)
+}
+
+// SetSampling captures a share of the requests of the route registered for the expression, from 0 to 1,
+// with their responses and hands them to the callback once the handler returns, e.g. to debug production
+// issues on a route without capturing all the traffic. The callback is called synchronously, so it should
+// not block. A zero rate disables the sampling.
+func (m *Mux) SetSampling(expr string, rate float64, fn func(Capture)) error {
+	if rate < 0 || rate > 1 {
+		return errors.New("sampling rate should be in [0, 1]")
+	}
+	if rate > 0 && fn == nil {
+		return errors.New("sampling callback cannot be nil")
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	e, err := m.route(expr)
+	if err != nil {
+		return err
+	}
+	e.updateOptions(func(o *routeOptions) {
+		if rate == 0 {
+			o.sampling = nil
+			return
+		}
+		o.sampling = &sampling{expr: e.expr, rate: rate, fn: fn}
+	})
+	return nil
+}
+
+func (s *sampling) serve(w http.ResponseWriter, r *http.Request, h http.Handler) {
+	clock := clockFor(r)
+	if clock.Float64() >= s.rate {
+		h.ServeHTTP(w, r)
+		return
+	}
+
+	c := Capture{Expr: s.expr, Request: r}
+	var reqBody *captureBuffer
+	if r.Body != nil && r.Body != http.NoBody {
+		reqBody = &captureBuffer{}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(r.Body, reqBody), r.Body}
+	}
+	cw := &captureWriter{statusWriter: newStatusWriter(w)}
+
+	start := clock.Now()
+	h.ServeHTTP(cw, r)
+	c.Duration = clock.Now().Sub(start)
+
+	c.Status = cw.Status()
+	c.Header = w.Header().Clone()
+	c.ResponseBody = cw.body.Bytes()
+	c.Truncated = cw.body.truncated
+	if reqBody != nil {
+		c.RequestBody = reqBody.Bytes()
+		c.Truncated = c.Truncated || reqBody.truncated
+	}
+	s.fn(c)
+}
+
+// captureBuffer keeps the first maxCaptureBody bytes written to it
+type captureBuffer struct {
+	bytes.Buffer
+	truncated bool
+}
+
+func (b *captureBuffer) Write(p []byte) (int, error) {
+	if room := maxCaptureBody - b.Len(); len(p) > room {
+		b.truncated = true
+		b.Buffer.Write(p[:room])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// captureWriter captures the response body
+type captureWriter struct {
+	*statusWriter
+	body captureBuffer
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	n, err := w.statusWriter.Write(p)
+	_, _ = w.body.Write(p[:n])
+	return n, err
+}
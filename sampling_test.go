@@ -0,0 +1,62 @@
+package route
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampling(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleFunc(`Path("/echo")`, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	}))
+
+	var captures []Capture
+	require.NoError(t, m.SetSampling(`Path("/echo")`, 1, func(c Capture) {
+		captures = append(captures, c)
+	}))
+
+	r := makeReq(req{url: "/echo", method: http.MethodPost})
+	r.Body = io.NopCloser(strings.NewReader("hello"))
+	w := newWriter()
+	m.ServeHTTP(w, r)
+	assert.Equal(t, "hello", w.buf.String())
+
+	require.Len(t, captures, 1)
+	c := captures[0]
+	assert.Equal(t, `Path("/echo")`, c.Expr)
+	assert.Equal(t, http.MethodPost, c.Request.Method)
+	assert.Equal(t, "hello", string(c.RequestBody))
+	assert.Equal(t, http.StatusCreated, c.Status)
+	assert.Equal(t, "text/plain", c.Header.Get("Content-Type"))
+	assert.Equal(t, "hello", string(c.ResponseBody))
+	assert.False(t, c.Truncated)
+
+	// bodies are bounded
+	r = makeReq(req{url: "/echo", method: http.MethodPost})
+	r.Body = io.NopCloser(strings.NewReader(strings.Repeat("a", maxCaptureBody+1)))
+	w = newWriter()
+	m.ServeHTTP(w, r)
+	assert.Equal(t, maxCaptureBody+1, w.buf.Len())
+	require.Len(t, captures, 2)
+	assert.Len(t, captures[1].RequestBody, maxCaptureBody)
+	assert.Len(t, captures[1].ResponseBody, maxCaptureBody)
+	assert.True(t, captures[1].Truncated)
+
+	require.NoError(t, m.SetSampling(`Path("/echo")`, 0, nil))
+	r.Body = http.NoBody
+	m.ServeHTTP(newWriter(), r)
+	assert.Len(t, captures, 2)
+
+	assert.Error(t, m.SetSampling(`Path("/echo")`, 1.5, func(Capture) {}))
+	assert.Error(t, m.SetSampling(`Path("/echo")`, 0.5, nil))
+	assert.Error(t, m.SetSampling(`Path("/other")`, 0.5, func(Capture) {}))
+}
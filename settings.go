@@ -0,0 +1,88 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// settings are the dependencies of a Mux read while serving requests, by its matchers, e.g. the clock
+// of the Stale matchers, and by the handlers of its routes. They are shared by the Mux, its routers and
+// its matchers, and like the other Mux settings they should be set before serving requests.
+// A nil settings holds the defaults, e.g. for the routers created with New.
+type settings struct {
+	// clock is the clock set with SetClock, nil for the system clock
+	clock Clock
+	// region is the resolver set with SetRegionResolver, nil if the region is unknown
+	region RegionResolver
+	// flags and flagContext are the provider and the context function set with SetFlagProvider,
+	// nil if the flags are off and for the default context
+	flags       FlagProvider
+	flagContext FlagContextFunc
+	// version is the source set with SetVersionSource, nil for the default source
+	version *versionSource
+	// signatureTolerance is the tolerance set with SetSignatureTolerance, zero for the default tolerance
+	signatureTolerance time.Duration
+}
+
+// getClock returns the clock, the system clock by default
+func (s *settings) getClock() Clock {
+	if s == nil || s.clock == nil {
+		return systemClock{}
+	}
+	return s.clock
+}
+
+// getRegion returns the region of the client of the request, false if it is unknown
+func (s *settings) getRegion(r *http.Request) (string, bool) {
+	if s == nil || s.region == nil {
+		return "", false
+	}
+	return s.region(r)
+}
+
+// flag evaluates the feature flag for the request, the flags are off by default
+func (s *settings) flag(r *http.Request, name string) bool {
+	if s == nil || s.flags == nil {
+		return false
+	}
+	contextFunc := s.flagContext
+	if contextFunc == nil {
+		contextFunc = requestFlagContext
+	}
+	return s.flags(r.Context(), name, false, contextFunc(r))
+}
+
+// getVersionSource returns where the Version matchers read the version of the request
+func (s *settings) getVersionSource() versionSource {
+	if s == nil || s.version == nil {
+		return defaultVersionSource
+	}
+	return *s.version
+}
+
+// getSignatureTolerance returns how far the timestamp of a signed request can be from the current time
+func (s *settings) getSignatureTolerance() time.Duration {
+	if s == nil || s.signatureTolerance == 0 {
+		return DefaultSignatureTolerance
+	}
+	return s.signatureTolerance
+}
+
+type clockKey struct{}
+
+// withClock passes the clock set with SetClock to the handlers of the routes, see clockFor
+func (s *settings) withClock(r *http.Request) *http.Request {
+	if s == nil || s.clock == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), clockKey{}, s.clock))
+}
+
+// clockFor returns the clock of the Mux serving the request, the system clock outside of a Mux
+func clockFor(r *http.Request) Clock {
+	if c, ok := r.Context().Value(clockKey{}).(Clock); ok {
+		return c
+	}
+	return systemClock{}
+}
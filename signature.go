@@ -0,0 +1,328 @@
+package route
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSignedBody is the maximum size of a request body verified by SignedWith matchers,
+// requests with larger bodies never match
+const maxSignedBody = 1 << 20
+
+// DefaultSignatureTolerance is how far the timestamp of a signed request can be from the current time,
+// by default, for the schemes signing a timestamp, see Mux.SetSignatureTolerance
+const DefaultSignatureTolerance = 5 * time.Minute
+
+// SignatureScheme verifies the signature of a request, e.g. the HMAC of a webhook payload
+type SignatureScheme interface {
+	// Verify returns true if the request signature is valid for the body and the secret
+	Verify(r *http.Request, body, secret []byte) bool
+}
+
+// SignatureSchemeFunc is a function implementing SignatureScheme
+type SignatureSchemeFunc func(r *http.Request, body, secret []byte) bool
+
+// Verify calls f(r, body, secret)
+func (f SignatureSchemeFunc) Verify(r *http.Request, body, secret []byte) bool {
+	return f(r, body, secret)
+}
+
+// SecretResolver returns the secret for a secret reference used in SignedWith matchers
+type SecretResolver func(ref string) ([]byte, error)
+
+var signatures = struct {
+	mutex    sync.RWMutex
+	schemes  map[string]SignatureScheme
+	resolver SecretResolver
+}{
+	schemes: map[string]SignatureScheme{
+		"github": webhookScheme{header: "X-Hub-Signature-256", verify: verifyGitHub},
+		"stripe": webhookScheme{header: "Stripe-Signature", verify: verifyStripe, timestamp: stripeTimestamp},
+		"slack":  webhookScheme{header: "X-Slack-Signature", verify: verifySlack, timestamp: slackTimestamp},
+	},
+	resolver: envSecret,
+}
+
+// RegisterSignatureScheme makes the scheme available to SignedWith matchers under the name,
+// replacing any scheme previously registered with that name.
+// Schemes "github", "stripe" and "slack" are registered by default.
+func RegisterSignatureScheme(name string, scheme SignatureScheme) {
+	signatures.mutex.Lock()
+	defer signatures.mutex.Unlock()
+
+	signatures.schemes[name] = scheme
+}
+
+// SetSecretResolver sets the resolver used by SignedWith matchers to look up secrets,
+// by default the secret reference is the name of an environment variable holding the secret.
+func SetSecretResolver(resolver SecretResolver) {
+	signatures.mutex.Lock()
+	defer signatures.mutex.Unlock()
+
+	if resolver == nil {
+		resolver = envSecret
+	}
+	signatures.resolver = resolver
+}
+
+func envSecret(ref string) ([]byte, error) {
+	secret := os.Getenv(ref)
+	if secret == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return []byte(secret), nil
+}
+
+// webhookScheme is a built-in scheme, the signature is sent in the header and the signed timestamp,
+// if any, is checked against the signature tolerance
+type webhookScheme struct {
+	header    string
+	verify    SignatureSchemeFunc
+	timestamp func(r *http.Request) (time.Time, bool)
+}
+
+func (s webhookScheme) Verify(r *http.Request, body, secret []byte) bool {
+	return s.verify(r, body, secret)
+}
+
+// signedWithMatcher matches requests with a valid signature for the scheme,
+// the secret is resolved on every request so it can be rotated without updating routes.
+// The routers of a Mux only check that the request carries a signature, the Mux verifies it
+// after the match so that the body is only read for the matched route, see Mux.verifySignatures.
+func signedWithMatcher(scheme, secretRef string) (matcher, error) {
+	v, err := newSignatureVerifier(scheme, secretRef)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSettingsMatcher(fmt.Sprintf("SignedWith(%s)", scheme), func(req *http.Request, s *settings) bool {
+		if s != nil {
+			return v.signed(req)
+		}
+		return v.verify(req, s) == http.StatusOK
+	}), nil
+}
+
+// signatureVerifier verifies the signatures of the requests for a SignedWith matcher
+type signatureVerifier struct {
+	scheme    SignatureScheme
+	secretRef string
+}
+
+func newSignatureVerifier(scheme, secretRef string) (signatureVerifier, error) {
+	signatures.mutex.RLock()
+	s, ok := signatures.schemes[scheme]
+	signatures.mutex.RUnlock()
+	if !ok {
+		return signatureVerifier{}, fmt.Errorf("unsupported signature scheme: %s", scheme)
+	}
+	return signatureVerifier{scheme: s, secretRef: secretRef}, nil
+}
+
+// signed returns true if the request carries a signature, the requests are assumed signed for the custom schemes
+func (v signatureVerifier) signed(req *http.Request) bool {
+	if s, ok := v.scheme.(webhookScheme); ok {
+		return req.Header.Get(s.header) != ""
+	}
+	return true
+}
+
+// verify verifies the signature of the request and the freshness of its timestamp, it returns
+// the status answering the request: 200 OK if the signature is valid, 401 Unauthorized if it is not,
+// 413 Request Entity Too Large if the body is too large to be verified and 500 Internal Server Error
+// if the secret can't be resolved.
+func (v signatureVerifier) verify(req *http.Request, s *settings) int {
+	signatures.mutex.RLock()
+	resolver := signatures.resolver
+	signatures.mutex.RUnlock()
+
+	secret, err := resolver(v.secretRef)
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	if ws, ok := v.scheme.(webhookScheme); ok && ws.timestamp != nil {
+		t, ok := ws.timestamp(req)
+		if !ok || absDuration(s.getClock().Now().Sub(t)) > s.getSignatureTolerance() {
+			return http.StatusUnauthorized
+		}
+	}
+	body, ok := peekBody(req)
+	if !ok {
+		return http.StatusRequestEntityTooLarge
+	}
+	if !v.scheme.Verify(req, body, secret) {
+		return http.StatusUnauthorized
+	}
+	return http.StatusOK
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// signedWith returns the verifiers of the SignedWith matchers of the expression, an error if a SignedWith
+// matcher is under ! or || as the Mux only verifies the signatures required by the expression
+func signedWith(expr string) ([]signatureVerifier, error) {
+	if !strings.Contains(expr, "SignedWith") {
+		return nil, nil
+	}
+	node, err := parser.ParseExpr(normalizeExpr(expr))
+	if err != nil {
+		return nil, err
+	}
+	calls := 0
+	ast.Inspect(node, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if id, ok := call.Fun.(*ast.Ident); ok && id.Name == "SignedWith" {
+				calls++
+			}
+		}
+		return true
+	})
+
+	ts, err := terms(expr)
+	if err != nil {
+		return nil, err
+	}
+	var verifiers []signatureVerifier
+	for _, t := range ts {
+		if t.name != "SignedWith" || len(t.args) != 2 {
+			continue
+		}
+		v, err := newSignatureVerifier(t.args[0], t.args[1])
+		if err != nil {
+			return nil, err
+		}
+		verifiers = append(verifiers, v)
+	}
+	if len(verifiers) != calls {
+		return nil, errors.New("SignedWith must be required by the expression, it can't be used under ! or ||")
+	}
+	return verifiers, nil
+}
+
+// verifySignatures verifies the signatures of the request for the SignedWith matchers of the route,
+// it answers the request and returns false if a signature is invalid
+func (m *Mux) verifySignatures(w http.ResponseWriter, r *http.Request, e *entry) bool {
+	for _, v := range e.signatures {
+		if status := v.verify(r, m.settings); status != http.StatusOK {
+			http.Error(w, http.StatusText(status), status)
+			return false
+		}
+	}
+	return true
+}
+
+// SetSignatureTolerance sets how far the timestamp of a signed request can be from the current time
+// for the schemes signing a timestamp, e.g. stripe and slack, so that old requests can't be replayed.
+// Zero restores DefaultSignatureTolerance.
+func (m *Mux) SetSignatureTolerance(tolerance time.Duration) {
+	m.settings.signatureTolerance = tolerance
+}
+
+// peekBody reads the request body and restores it so the handler can read it again
+func peekBody(req *http.Request) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxSignedBody+1))
+	req.Body = &restoredBody{Reader: io.MultiReader(bytes.NewReader(body), req.Body), Closer: req.Body}
+	if err != nil || len(body) > maxSignedBody {
+		return nil, false
+	}
+	return body, true
+}
+
+type restoredBody struct {
+	io.Reader
+	io.Closer
+}
+
+func verifyHMAC(secret []byte, signature string, parts ...[]byte) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	for _, p := range parts {
+		mac.Write(p)
+	}
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// verifyGitHub checks the X-Hub-Signature-256 header: sha256=hex(hmac(body))
+func verifyGitHub(r *http.Request, body, secret []byte) bool {
+	signature, ok := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256=")
+	return ok && verifyHMAC(secret, signature, body)
+}
+
+// verifyStripe checks the Stripe-Signature header: t=timestamp,v1=hex(hmac(timestamp.body))
+func verifyStripe(r *http.Request, body, secret []byte) bool {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(r.Header.Get("Stripe-Signature"), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" {
+		return false
+	}
+	for _, s := range signatures {
+		if verifyHMAC(secret, s, []byte(timestamp), []byte("."), body) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripeTimestamp returns the timestamp of the Stripe-Signature header
+func stripeTimestamp(r *http.Request) (time.Time, bool) {
+	for _, part := range strings.Split(r.Header.Get("Stripe-Signature"), ",") {
+		if key, value, _ := strings.Cut(strings.TrimSpace(part), "="); key == "t" {
+			return unixTimestamp(value)
+		}
+	}
+	return time.Time{}, false
+}
+
+// slackTimestamp returns the timestamp of the X-Slack-Request-Timestamp header
+func slackTimestamp(r *http.Request) (time.Time, bool) {
+	return unixTimestamp(r.Header.Get("X-Slack-Request-Timestamp"))
+}
+
+func unixTimestamp(value string) (time.Time, bool) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
+// verifySlack checks the X-Slack-Signature header: v0=hex(hmac(v0:timestamp:body))
+// with the timestamp from the X-Slack-Request-Timestamp header
+func verifySlack(r *http.Request, body, secret []byte) bool {
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	signature, ok := strings.CutPrefix(r.Header.Get("X-Slack-Signature"), "v0=")
+	return ok && timestamp != "" && verifyHMAC(secret, signature, []byte("v0:"+timestamp+":"), body)
+}
@@ -0,0 +1,233 @@
+package route
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(secret string, parts ...string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	for _, p := range parts {
+		mac.Write([]byte(p))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func signedReq(body string, headers http.Header) *http.Request {
+	r := makeReq(req{url: "/hook", headers: headers})
+	r.Body = io.NopCloser(strings.NewReader(body))
+	return r
+}
+
+func TestSignedWith(t *testing.T) {
+	t.Setenv("TEST_WEBHOOK_SECRET", "s3cr3t")
+
+	body := `{"event":"push"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	testCases := []struct {
+		scheme  string
+		valid   http.Header
+		invalid http.Header
+	}{
+		{
+			scheme:  "github",
+			valid:   http.Header{"X-Hub-Signature-256": []string{"sha256=" + sign("s3cr3t", body)}},
+			invalid: http.Header{"X-Hub-Signature-256": []string{"sha256=" + sign("other", body)}},
+		},
+		{
+			scheme:  "stripe",
+			valid:   http.Header{"Stripe-Signature": []string{"t=" + now + ",v1=" + sign("other", now+".", body) + ",v1=" + sign("s3cr3t", now+".", body)}},
+			invalid: http.Header{"Stripe-Signature": []string{"v1=" + sign("s3cr3t", now+".", body)}},
+		},
+		{
+			scheme: "slack",
+			valid: http.Header{
+				"X-Slack-Request-Timestamp": []string{now},
+				"X-Slack-Signature":         []string{"v0=" + sign("s3cr3t", "v0:"+now+":", body)},
+			},
+			invalid: http.Header{
+				"X-Slack-Request-Timestamp": []string{now + "0"},
+				"X-Slack-Signature":         []string{"v0=" + sign("s3cr3t", "v0:"+now+":", body)},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		m, err := signedWithMatcher(test.scheme, "TEST_WEBHOOK_SECRET")
+		require.NoError(t, err)
+
+		r := signedReq(body, test.valid)
+		assert.NotNil(t, m.match(r), test.scheme)
+
+		// the handler can still read the body
+		read, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(read))
+
+		assert.Nil(t, m.match(signedReq(body, test.invalid)), test.scheme)
+		assert.Nil(t, m.match(signedReq(body, http.Header{})), test.scheme)
+	}
+}
+
+func TestSignedWithSecretResolver(t *testing.T) {
+	body := "payload"
+	headers := http.Header{"X-Hub-Signature-256": []string{"sha256=" + sign("vault", body)}}
+
+	m, err := signedWithMatcher("github", "vault/webhook")
+	require.NoError(t, err)
+	assert.Nil(t, m.match(signedReq(body, headers)))
+
+	SetSecretResolver(func(ref string) ([]byte, error) {
+		if ref != "vault/webhook" {
+			return nil, errors.New("not found")
+		}
+		return []byte("vault"), nil
+	})
+	defer SetSecretResolver(nil)
+
+	assert.NotNil(t, m.match(signedReq(body, headers)))
+}
+
+func TestSignedWithCustomScheme(t *testing.T) {
+	t.Setenv("TEST_TOKEN", "token")
+
+	_, err := signedWithMatcher("token", "TEST_TOKEN")
+	require.Error(t, err)
+
+	RegisterSignatureScheme("token", SignatureSchemeFunc(func(r *http.Request, _, secret []byte) bool {
+		return hmac.Equal([]byte(r.Header.Get("X-Token")), secret)
+	}))
+
+	r := New()
+	require.NoError(t, r.AddRoute(`SignedWith("token", "TEST_TOKEN") && Path("/hook")`, "hook"))
+
+	out, err := r.Route(signedReq("", http.Header{"X-Token": []string{"token"}}))
+	require.NoError(t, err)
+	assert.Equal(t, "hook", out)
+
+	out, err = r.Route(signedReq("", http.Header{"X-Token": []string{"guess"}}))
+	require.NoError(t, err)
+	assert.Nil(t, out)
+}
+
+func TestSignedWithBodyLimit(t *testing.T) {
+	t.Setenv("TEST_WEBHOOK_SECRET", "s3cr3t")
+
+	body := strings.Repeat("a", maxSignedBody+1)
+	m, err := signedWithMatcher("github", "TEST_WEBHOOK_SECRET")
+	require.NoError(t, err)
+
+	r := signedReq(body, http.Header{"X-Hub-Signature-256": []string{"sha256=" + sign("s3cr3t", body)}})
+	assert.Nil(t, m.match(r))
+
+	read, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	assert.Len(t, read, len(body))
+}
+
+func TestSignedWithTolerance(t *testing.T) {
+	t.Setenv("TEST_WEBHOOK_SECRET", "s3cr3t")
+
+	body := "payload"
+	stripe := func(at time.Time) http.Header {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		return http.Header{"Stripe-Signature": []string{"t=" + timestamp + ",v1=" + sign("s3cr3t", timestamp+".", body)}}
+	}
+
+	m, err := signedWithMatcher("stripe", "TEST_WEBHOOK_SECRET")
+	require.NoError(t, err)
+
+	// the requests signed too long ago or in the future are replays
+	assert.NotNil(t, m.match(signedReq(body, stripe(time.Now().Add(-time.Minute)))))
+	assert.Nil(t, m.match(signedReq(body, stripe(time.Now().Add(-DefaultSignatureTolerance-time.Minute)))))
+	assert.Nil(t, m.match(signedReq(body, stripe(time.Now().Add(DefaultSignatureTolerance+time.Minute)))))
+}
+
+func TestSignedWithRequired(t *testing.T) {
+	assert.True(t, IsValid(`SignedWith("github", "SECRET") && Path("/hook")`))
+	assert.False(t, IsValid(`!SignedWith("github", "SECRET")`))
+	assert.False(t, IsValid(`SignedWith("github", "SECRET") || Path("/hook")`))
+	assert.False(t, IsValid(`Path("/hook") && (SignedWith("github", "SECRET") || SignedWith("slack", "SECRET"))`))
+}
+
+func TestMuxSignedWith(t *testing.T) {
+	t.Setenv("TEST_WEBHOOK_SECRET", "s3cr3t")
+
+	c := newFakeClock()
+	m := NewMux()
+	m.SetClock(c)
+
+	var read string
+	require.NoError(t, m.Handle(`SignedWith("stripe", "TEST_WEBHOOK_SECRET") && Path("/hook")`, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		read = string(body)
+		w.WriteHeader(http.StatusOK)
+	})))
+	require.NoError(t, m.Handle(`Path("/hook")`, newStatusHandler(http.StatusNoContent)))
+
+	body := "payload"
+	stripe := func(at time.Time, secret, body string) http.Header {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		return http.Header{"Stripe-Signature": []string{"t=" + timestamp + ",v1=" + sign(secret, timestamp+".", body)}}
+	}
+
+	w := newWriter()
+	m.ServeHTTP(w, signedReq(body, stripe(c.Now(), "s3cr3t", body)))
+	assert.Equal(t, http.StatusOK, w.header)
+	assert.Equal(t, body, read)
+
+	// the signature is verified after the match, so an invalid signature is answered instead of falling through
+	w = newWriter()
+	m.ServeHTTP(w, signedReq(body, stripe(c.Now(), "other", body)))
+	assert.Equal(t, http.StatusUnauthorized, w.header)
+
+	w = newWriter()
+	m.ServeHTTP(w, signedReq(body, nil))
+	assert.Equal(t, http.StatusNoContent, w.header)
+
+	large := strings.Repeat("a", maxSignedBody+1)
+	w = newWriter()
+	m.ServeHTTP(w, signedReq(large, stripe(c.Now(), "s3cr3t", large)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.header)
+
+	// the timestamp is checked against the clock and the tolerance of the mux
+	old := stripe(c.Now().Add(-time.Hour), "s3cr3t", body)
+	w = newWriter()
+	m.ServeHTTP(w, signedReq(body, old))
+	assert.Equal(t, http.StatusUnauthorized, w.header)
+
+	m.SetSignatureTolerance(2 * time.Hour)
+	w = newWriter()
+	m.ServeHTTP(w, signedReq(body, old))
+	assert.Equal(t, http.StatusOK, w.header)
+}
+
+func TestMuxSignedWithBodyUnread(t *testing.T) {
+	t.Setenv("TEST_WEBHOOK_SECRET", "s3cr3t")
+
+	m := NewMux()
+	require.NoError(t, m.Handle(`SignedWith("github", "TEST_WEBHOOK_SECRET") && Path("/hook")`, newStatusHandler(http.StatusOK)))
+
+	// the body of the requests matched by other routes is not read by the SignedWith matchers
+	var body io.ReadCloser
+	require.NoError(t, m.Handle(`Path("/other")`, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = r.Body
+	})))
+
+	r := signedReq("payload", http.Header{"X-Hub-Signature-256": []string{"sha256=" + sign("s3cr3t", "payload")}})
+	r.URL.Path = "/other"
+	original := r.Body
+	m.ServeHTTP(newWriter(), r)
+	assert.True(t, original == body)
+}
@@ -0,0 +1,121 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// SyntheticRequest describes a request replayed against route tables by Simulate
+type SyntheticRequest struct {
+	// Method is the request method, GET by default
+	Method string
+	// URL is the request URL, e.g. http://example.com/v1/users or /v1/users
+	URL string
+	// Host overrides the host of the URL when set
+	Host string
+	// Header holds the request headers
+	Header http.Header
+}
+
+// SimulationResult describes how a synthetic request is routed by the current and the proposed route tables
+type SimulationResult struct {
+	Request SyntheticRequest
+	// Current and Proposed are the expressions of the matched routes, empty when no route matches
+	Current  string
+	Proposed string
+	// currentKey and proposedKey are the keys of the matched routes
+	currentKey  string
+	proposedKey string
+}
+
+// Changed returns true if the request is routed differently by the proposed route table.
+// Routes are compared by their keys, see Mux.SetKeyFunc, or by their canonical expressions
+// when the snapshots have no keys.
+func (r SimulationResult) Changed() bool {
+	return simulatedKey(r.Current, r.currentKey) != simulatedKey(r.Proposed, r.proposedKey)
+}
+
+// simulatedKey returns the key of the route, the canonical expression if it has no key
+func simulatedKey(expr, key string) string {
+	if key == "" {
+		return CanonicalExpr(expr)
+	}
+	return key
+}
+
+// Simulate routes the requests with the current and the proposed route tables, e.g. a snapshot of the running mux
+// and the snapshot of a mux loaded with the new configuration, so routing changes can be reviewed before they are deployed.
+func Simulate(current, proposed Snapshot, requests []SyntheticRequest) ([]SimulationResult, error) {
+	cur, err := simulationRouter(current)
+	if err != nil {
+		return nil, fmt.Errorf("current route table: %w", err)
+	}
+	next, err := simulationRouter(proposed)
+	if err != nil {
+		return nil, fmt.Errorf("proposed route table: %w", err)
+	}
+
+	results := make([]SimulationResult, 0, len(requests))
+	for _, sr := range requests {
+		req, err := sr.request()
+		if err != nil {
+			return nil, err
+		}
+		result := SimulationResult{Request: sr}
+		if route, err := cur.Route(req); err == nil && route != nil {
+			result.Current, result.currentKey = route.(simulatedRoute).expr, route.(simulatedRoute).key
+		}
+		if route, err := next.Route(req); err == nil && route != nil {
+			result.Proposed, result.proposedKey = route.(simulatedRoute).expr, route.(simulatedRoute).key
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// simulatedRoute is a snapshot route ordered by its priority like in the mux
+type simulatedRoute struct {
+	expr     string
+	key      string
+	priority int
+}
+
+func (r simulatedRoute) routePriority() int {
+	return r.priority
+}
+
+// simulationRouter returns a router resolving the requests to the snapshot routes,
+// the routes are registered for both their expression and their alias like in the mux
+func simulationRouter(s Snapshot) (Router, error) {
+	routes := make(map[string]interface{}, len(s.Routes))
+	for _, r := range s.Routes {
+		route := simulatedRoute{expr: r.Expr, key: r.Key, priority: r.Priority}
+		if r.Alias != "" {
+			routes[r.Alias] = route
+		}
+		routes[r.Expr] = route
+	}
+	r := New()
+	if err := r.InitRoutes(routes); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (sr SyntheticRequest) request() (*http.Request, error) {
+	method := sr.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequest(method, sr.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("bad synthetic request %s %s: %w", method, sr.URL, err)
+	}
+	if sr.Host != "" {
+		req.Host = sr.Host
+	}
+	if sr.Header != nil {
+		req.Header = sr.Header
+	}
+	return req, nil
+}
@@ -0,0 +1,87 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulate(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/v1/<path:rest>")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path("/legacy")`, newStatusHandler(http.StatusOK)))
+	current := m.Snapshot()
+
+	require.NoError(t, m.Remove(`Path("/legacy")`))
+	require.NoError(t, m.Handle(`Method("POST") && Path("/v2/users")`, newStatusHandler(http.StatusOK)))
+	proposed := m.Snapshot()
+
+	results, err := Simulate(current, proposed, []SyntheticRequest{
+		{URL: "http://example.com/v1/users"},
+		{Method: http.MethodPost, URL: "/v2/users", Host: "example.com"},
+		{URL: "/legacy"},
+		{URL: "/unknown"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.Equal(t, `Path("/v1/<path:rest>")`, results[0].Current)
+	assert.Equal(t, `Path("/v1/<path:rest>")`, results[0].Proposed)
+	assert.False(t, results[0].Changed())
+
+	assert.Empty(t, results[1].Current)
+	assert.Equal(t, `Method("POST") && Path("/v2/users")`, results[1].Proposed)
+	assert.True(t, results[1].Changed())
+
+	assert.Equal(t, `Path("/legacy")`, results[2].Current)
+	assert.Empty(t, results[2].Proposed)
+	assert.True(t, results[2].Changed())
+
+	assert.False(t, results[3].Changed())
+}
+
+func TestSimulateErrors(t *testing.T) {
+	_, err := Simulate(Snapshot{Routes: []RouteInfo{{Expr: `Path(`}}}, Snapshot{}, nil)
+	assert.Error(t, err)
+
+	_, err = Simulate(Snapshot{}, Snapshot{}, []SyntheticRequest{{Method: "BAD METHOD", URL: "/"}})
+	assert.Error(t, err)
+}
+
+func TestSimulateAliases(t *testing.T) {
+	m := NewMux()
+	m.AddAlias(`Host("api")`, `Host("api.example.com")`)
+	require.NoError(t, m.Handle(`Host("api") && Path("/users")`, newStatusHandler(http.StatusOK)))
+
+	requests := []SyntheticRequest{{URL: "http://api.example.com/users"}}
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{host: "api.example.com", url: "/users"}))
+	require.Equal(t, http.StatusOK, w.header)
+
+	results, err := Simulate(Snapshot{}, m.Snapshot(), requests)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, `Host("api") && Path("/users")`, results[0].Proposed)
+	assert.True(t, results[0].Changed())
+}
+
+func TestSimulateKeys(t *testing.T) {
+	m := NewMux()
+	// the routes are identified by their path
+	require.NoError(t, m.SetKeyFunc(func(expr string) string {
+		ts, _ := terms(expr)
+		return termArg(ts, "Path")
+	}))
+	require.NoError(t, m.Handle(`Path("/v1")`, newStatusHandler(http.StatusOK)))
+	current := m.Snapshot()
+
+	require.NoError(t, m.Handle(`Method("GET") && Path("/v1")`, newStatusHandler(http.StatusOK)))
+	results, err := Simulate(current, m.Snapshot(), []SyntheticRequest{{URL: "/v1"}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, `Path("/v1")`, results[0].Current)
+	assert.Equal(t, `Method("GET") && Path("/v1")`, results[0].Proposed)
+	assert.False(t, results[0].Changed())
+}
@@ -0,0 +1,73 @@
+package route
+
+import (
+	"fmt"
+	"sync"
+)
+
+// flightGroup makes sure that only one call for a given key is in flight,
+// concurrent callers for the same key wait for that call and share its result
+type flightGroup struct {
+	mutex sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	wg  sync.WaitGroup
+	val interface{}
+	err error
+	// dups is the number of callers waiting for the call
+	dups int
+}
+
+// panicError is the error of the callers sharing a call which panicked
+type panicError struct {
+	value interface{}
+}
+
+func (p *panicError) Error() string {
+	return fmt.Sprintf("shared call panicked: %v", p.value)
+}
+
+// do calls fn once for all concurrent callers with the same key,
+// shared is true for callers which did not execute fn themselves.
+// If fn panics, the panic is raised again in the caller which executed it
+// and the other callers get a *panicError.
+func (g *flightGroup) do(key string, fn func() (interface{}, error)) (val interface{}, err error, shared bool) {
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mutex.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+	c := &flightCall{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mutex.Unlock()
+
+	defer func() {
+		g.mutex.Lock()
+		delete(g.calls, key)
+		g.mutex.Unlock()
+		c.wg.Done()
+	}()
+
+	var panicked *panicError
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicked = &panicError{value: p}
+				c.val, c.err = nil, panicked
+			}
+		}()
+		c.val, c.err = fn()
+	}()
+	if panicked != nil {
+		panic(panicked.value)
+	}
+	return c.val, c.err, false
+}
@@ -0,0 +1,97 @@
+package route
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlightGroup(t *testing.T) {
+	var g flightGroup
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	var wg sync.WaitGroup
+	shared := make([]bool, 5)
+	results := make([]interface{}, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _, shared[i] = g.do("key", func() (interface{}, error) {
+				close(started)
+				<-release
+				return "value", nil
+			})
+		}(i)
+		if i == 0 {
+			<-started
+		}
+	}
+
+	// wait for the other callers to join the call in flight
+	for {
+		g.mutex.Lock()
+		dups := g.calls["key"].dups
+		g.mutex.Unlock()
+		if dups == 4 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, []bool{false, true, true, true, true}, shared)
+	for _, r := range results {
+		assert.Equal(t, "value", r)
+	}
+	assert.Empty(t, g.calls)
+}
+
+func TestFlightGroupPanic(t *testing.T) {
+	var g flightGroup
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	leader := make(chan interface{})
+	go func() {
+		defer func() { leader <- recover() }()
+		_, _, _ = g.do("key", func() (interface{}, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err, _ = g.do("key", func() (interface{}, error) {
+			return "value", nil
+		})
+	}()
+	for {
+		g.mutex.Lock()
+		dups := g.calls["key"].dups
+		g.mutex.Unlock()
+		if dups == 1 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(release)
+
+	// the panic is raised in the caller executing the call only
+	assert.Equal(t, "boom", <-leader)
+	<-done
+	var perr *panicError
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, "boom", perr.value)
+	assert.Empty(t, g.calls)
+}
@@ -0,0 +1,156 @@
+package route
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Snapshot is a point in time copy of the route table
+type Snapshot struct {
+	// Routes are the registered routes in trie order
+	Routes []RouteInfo
+	// Hash is the hash of the route table, see Mux.Hash
+	Hash uint64
+}
+
+// Snapshot returns a copy of the current route table
+func (m *Mux) Snapshot() Snapshot {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	s := Snapshot{Routes: make([]RouteInfo, 0, len(m.sorted)), Hash: m.hash}
+	for _, e := range m.sorted {
+		s.Routes = append(s.Routes, e.info())
+	}
+	return s
+}
+
+// Restore replaces the route table with the routes of the snapshot, so a mux booting from a persisted
+// snapshot serves the last known routes right away instead of answering 404 until the control plane is reached.
+// The resolver returns the handler of each route, e.g. by its metadata, as the handlers are not persisted;
+// the handlers of the snapshot are used if the resolver is nil. The owned routes of the mux are replaced too,
+// and owners are not restored: the mux is reconciled by a later InitHandlers call, which notifies MutationReset
+// to the subscribers and clears Restored. The route table is left untouched if the snapshot is invalid.
+func (m *Mux) Restore(s Snapshot, resolver func(RouteInfo) (http.Handler, error)) error {
+	handlers := make(map[string]interface{}, len(s.Routes))
+	registrations := make(map[string]registration, len(s.Routes))
+	for _, r := range s.Routes {
+		h := r.Handler
+		if resolver != nil {
+			var err error
+			if h, err = resolver(r); err != nil {
+				return fmt.Errorf("route '%s': %w", r.Expr, err)
+			}
+		}
+		handlers[r.Expr] = h
+		registrations[r.Expr] = registration{priority: r.Priority, meta: r.Meta}
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.initHandlers(handlers, registrations, true); err != nil {
+		return err
+	}
+	m.restored = true
+	return nil
+}
+
+// Restored returns true if the route table was restored from a snapshot and not reconciled since
+func (m *Mux) Restored() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.restored
+}
+
+// DiffSnapshots returns the routes added, removed, changed and updated between the snapshots.
+// Routes are identified by their canonical expression: they are changed when their expression differs
+// and updated when their owner, priority or metadata differs.
+func DiffSnapshots(old, updated Snapshot) Diff {
+	var d Diff
+	prev := indexRoutes(old)
+	next := indexRoutes(updated)
+
+	for _, r := range updated.Routes {
+		o, ok := prev[CanonicalExpr(r.Expr)]
+		if !ok {
+			d.Added = append(d.Added, r.Expr)
+			continue
+		}
+		if o.Expr != r.Expr {
+			d.Changed = append(d.Changed, r.Expr)
+		}
+		if len(metadataChanges(o, r)) != 0 {
+			d.Updated = append(d.Updated, r.Expr)
+		}
+	}
+	for _, r := range old.Routes {
+		if _, ok := next[CanonicalExpr(r.Expr)]; !ok {
+			d.Removed = append(d.Removed, r.Expr)
+		}
+	}
+	return d
+}
+
+// RenderDiff renders a human-readable diff of the snapshots: added routes are prefixed with '+',
+// removed routes with '-' and changed or updated routes with '~' followed by their changes.
+// Routes are rendered in trie order, so the output is stable.
+func RenderDiff(old, updated Snapshot) string {
+	d := DiffSnapshots(old, updated)
+	if d.Empty() {
+		return ""
+	}
+
+	prev := indexRoutes(old)
+	b := &strings.Builder{}
+	for _, expr := range d.Removed {
+		_, _ = fmt.Fprintf(b, "- %s\n", expr)
+	}
+	for _, expr := range d.Added {
+		_, _ = fmt.Fprintf(b, "+ %s\n", expr)
+	}
+	changed := make(map[string]bool, len(d.Changed)+len(d.Updated))
+	for _, expr := range append(slices.Clone(d.Changed), d.Updated...) {
+		changed[expr] = true
+	}
+	for _, r := range updated.Routes {
+		if !changed[r.Expr] {
+			continue
+		}
+		o := prev[CanonicalExpr(r.Expr)]
+		_, _ = fmt.Fprintf(b, "~ %s\n", r.Expr)
+		if o.Expr != r.Expr {
+			_, _ = fmt.Fprintf(b, "    expr: %s -> %s\n", o.Expr, r.Expr)
+		}
+		for _, change := range metadataChanges(o, r) {
+			_, _ = fmt.Fprintf(b, "    %s\n", change)
+		}
+	}
+	return b.String()
+}
+
+func indexRoutes(s Snapshot) map[string]RouteInfo {
+	out := make(map[string]RouteInfo, len(s.Routes))
+	for _, r := range s.Routes {
+		out[CanonicalExpr(r.Expr)] = r
+	}
+	return out
+}
+
+// metadataChanges describes the metadata differences between the two versions of a route
+func metadataChanges(old, updated RouteInfo) []string {
+	var out []string
+	if old.Owner != updated.Owner {
+		out = append(out, fmt.Sprintf("owner: %q -> %q", old.Owner, updated.Owner))
+	}
+	if old.Priority != updated.Priority {
+		out = append(out, fmt.Sprintf("priority: %d -> %d", old.Priority, updated.Priority))
+	}
+	if !maps.Equal(old.Meta, updated.Meta) {
+		out = append(out, fmt.Sprintf("meta: %v -> %v", old.Meta, updated.Meta))
+	}
+	return out
+}
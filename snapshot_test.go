@@ -0,0 +1,128 @@
+package route
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Host("b") && Path("/b")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.HandleOwned("infra", `Host("a") && Path("/a")`, newStatusHandler(http.StatusOK)))
+
+	s := m.Snapshot()
+	assert.Equal(t, m.Hash(), s.Hash)
+	require.Len(t, s.Routes, 2)
+	assert.Equal(t, `Host("a") && Path("/a")`, s.Routes[0].Expr)
+	assert.Equal(t, "infra", s.Routes[0].Owner)
+
+	// snapshots are copies
+	require.NoError(t, m.Remove(`Host("b") && Path("/b")`))
+	assert.Len(t, s.Routes, 2)
+}
+
+func TestRenderDiff(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/kept")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path("/removed")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path("/owned")`, newStatusHandler(http.StatusOK)))
+	old := m.Snapshot()
+
+	require.NoError(t, m.Remove(`Path("/removed")`))
+	require.NoError(t, m.Handle(`Path( "/kept" )`, newStatusHandler(http.StatusAccepted)))
+	require.NoError(t, m.Handle(`Host("new") && Path("/added")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.SetOwner(`Path("/owned")`, "infra"))
+	updated := m.Snapshot()
+
+	assert.Equal(t, Diff{
+		Added:   []string{`Host("new") && Path("/added")`},
+		Removed: []string{`Path("/removed")`},
+		Changed: []string{`Path( "/kept" )`},
+		Updated: []string{`Path("/owned")`},
+	}, DiffSnapshots(old, updated))
+
+	expected := `- Path("/removed")
++ Host("new") && Path("/added")
+~ Path( "/kept" )
+    expr: Path("/kept") -> Path( "/kept" )
+~ Path("/owned")
+    owner: "" -> "infra"
+`
+	assert.Equal(t, expected, RenderDiff(old, updated))
+	assert.Empty(t, RenderDiff(updated, updated))
+}
+
+func TestRestore(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleOwned("infra", `Path("/a")`, newStatusHandler(http.StatusAccepted)))
+	s := m.Snapshot()
+
+	booted := NewMux()
+	require.NoError(t, booted.Restore(s, nil))
+	assert.True(t, booted.Restored())
+	assert.Equal(t, s.Hash, booted.Hash())
+
+	w := newWriter()
+	booted.ServeHTTP(w, makeReq(req{url: "/a"}))
+	assert.Equal(t, http.StatusAccepted, w.header)
+
+	ch := booted.Subscribe()
+	require.NoError(t, booted.InitHandlers(map[string]interface{}{
+		`Path("/b")`: newStatusHandler(http.StatusOK),
+	}))
+	assert.False(t, booted.Restored())
+	assert.Equal(t, MutationReset, (<-ch).Type)
+}
+
+func TestRestoreInvalid(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleOwned("infra", `Path("/healthz")`, newStatusHandler(http.StatusOK)))
+
+	s := Snapshot{Routes: []RouteInfo{{Expr: `Path("/a"`, Handler: newStatusHandler(http.StatusOK)}}}
+	require.Error(t, m.Restore(s, nil))
+	assert.False(t, m.Restored())
+
+	// the owners are kept when the snapshot is rejected
+	assert.Equal(t, "infra", m.Owner(`Path("/healthz")`))
+	assert.ErrorIs(t, m.HandleOwned("tenant-sync", `Path("/healthz")`, newStatusHandler(http.StatusOK)), ErrRouteOwned)
+
+	// so are the routes when the resolver fails
+	s = Snapshot{Routes: []RouteInfo{{Expr: `Path("/a")`}}}
+	err := m.Restore(s, func(RouteInfo) (http.Handler, error) { return nil, errors.New("unknown route") })
+	require.EqualError(t, err, `route 'Path("/a")': unknown route`)
+	assert.Equal(t, "infra", m.Owner(`Path("/healthz")`))
+
+	// a valid snapshot replaces the owned routes
+	require.NoError(t, m.Restore(Snapshot{Routes: []RouteInfo{{Expr: `Path("/a")`, Handler: newStatusHandler(http.StatusOK)}}}, nil))
+	assert.Empty(t, m.Owner(`Path("/healthz")`))
+	assert.Len(t, m.Snapshot().Routes, 1)
+}
+
+func TestRestorePersisted(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleMeta(`Path("/a")`, newStatusHandler(http.StatusAccepted), map[string]string{"backend": "a"}))
+	data, err := json.Marshal(m.Snapshot())
+	require.NoError(t, err)
+
+	var s Snapshot
+	require.NoError(t, json.Unmarshal(data, &s))
+	booted := NewMux()
+	require.NoError(t, booted.Restore(s, func(r RouteInfo) (http.Handler, error) {
+		if r.Meta["backend"] != "a" {
+			return nil, fmt.Errorf("unknown backend %q", r.Meta["backend"])
+		}
+		return newStatusHandler(http.StatusAccepted), nil
+	}))
+	assert.True(t, booted.Restored())
+	assert.Equal(t, m.Hash(), booted.Hash())
+
+	w := newWriter()
+	booted.ServeHTTP(w, makeReq(req{url: "/a"}))
+	assert.Equal(t, http.StatusAccepted, w.header)
+}
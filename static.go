@@ -0,0 +1,151 @@
+package route
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	templateparse "text/template/parse"
+)
+
+// StaticData is the data the StaticResponse templates are executed with
+type StaticData struct {
+	// Params are the params captured by the route, see Params
+	Params Params
+	// Meta is the metadata of the route, see HandleMeta
+	Meta   map[string]string
+	Method string
+	Host   string
+	Path   string
+	Query  url.Values
+}
+
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// StaticResponse returns a handler answering with the status and the body rendered from the template,
+// e.g. a maintenance page or a deprecation notice for a retired route. The template is executed with
+// StaticData, e.g. `tenant {{.Params.tenant}} is under maintenance, contact {{.Meta.team}}`.
+// The interpolated values are escaped for the content type: HTML content types use html/template and
+// JSON content types write every value as a JSON value, e.g. `{"tenant": {{.Params.tenant}}}` writes
+// {"tenant": "acme"}. Templates interpolating values are only supported for these content types and
+// text/plain, the default.
+func StaticResponse(status int, contentType, text string) (http.Handler, error) {
+	mediaType := "text/plain"
+	if contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return nil, err
+		}
+	}
+
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		tmpl, err := htmltemplate.New("static").Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		return &staticResponse{status: status, contentType: contentType, tmpl: tmpl}, nil
+	}
+
+	tmpl, err := template.New("static").Funcs(template.FuncMap{"json": jsonValue}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	actions := writingActions(tmpl.Tree.Root, nil)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		// the values are piped to json like html/template pipes them to its escapers
+		for _, a := range actions {
+			if a == nil {
+				return nil, fmt.Errorf("nested templates are not supported for %s, their values can't be escaped", mediaType)
+			}
+			a.Pipe.Cmds = append(a.Pipe.Cmds, &templateparse.CommandNode{
+				NodeType: templateparse.NodeCommand,
+				Pos:      a.Pos,
+				Args:     []templateparse.Node{templateparse.NewIdentifier("json").SetTree(tmpl.Tree).SetPos(a.Pos)},
+			})
+		}
+	case len(actions) == 0:
+	case mediaType == "text/plain":
+		if contentType == "" {
+			// the rendered values are not sniffed as another content type
+			contentType = "text/plain; charset=utf-8"
+		}
+	default:
+		return nil, fmt.Errorf("templates are not supported for %s, the values can't be escaped", mediaType)
+	}
+	return &staticResponse{status: status, contentType: contentType, tmpl: tmpl}, nil
+}
+
+// jsonValue returns the value encoded as a JSON value
+func jsonValue(value any) (string, error) {
+	b, err := json.Marshal(value)
+	return string(b), err
+}
+
+// writingActions appends the actions of the template writing a value to the actions
+func writingActions(node templateparse.Node, actions []*templateparse.ActionNode) []*templateparse.ActionNode {
+	switch n := node.(type) {
+	case *templateparse.ListNode:
+		if n == nil {
+			return actions
+		}
+		for _, c := range n.Nodes {
+			actions = writingActions(c, actions)
+		}
+	case *templateparse.ActionNode:
+		// the actions declaring variables write nothing
+		if len(n.Pipe.Decl) == 0 {
+			actions = append(actions, n)
+		}
+	case *templateparse.IfNode:
+		actions = writingActions(n.ElseList, writingActions(n.List, actions))
+	case *templateparse.RangeNode:
+		actions = writingActions(n.ElseList, writingActions(n.List, actions))
+	case *templateparse.WithNode:
+		actions = writingActions(n.ElseList, writingActions(n.List, actions))
+	case *templateparse.TemplateNode:
+		// the values written by the nested templates can't be escaped, they are reported as nil
+		actions = append(actions, nil)
+	}
+	return actions
+}
+
+type staticResponse struct {
+	status      int
+	contentType string
+	tmpl        executor
+}
+
+func (s *staticResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data := StaticData{
+		Params: ParamsFromContext(r.Context()),
+		Method: r.Method,
+		Host:   r.Host,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+	}
+	if route, ok := RouteFromContext(r.Context()); ok {
+		data.Meta = route.Meta
+	}
+	b := &bytes.Buffer{}
+	if err := s.tmpl.Execute(b, data); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if s.contentType != "" {
+		w.Header().Set("Content-Type", s.contentType)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+	w.WriteHeader(s.status)
+	_, _ = w.Write(b.Bytes())
+}
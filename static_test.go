@@ -0,0 +1,95 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticResponse(t *testing.T) {
+	text, err := StaticResponse(http.StatusServiceUnavailable, "text/plain", `{{.Method}} {{.Path}}: tenant {{.Params.tenant}} is under maintenance, retry {{.Query.Get "retry"}}`)
+	require.NoError(t, err)
+	html, err := StaticResponse(http.StatusGone, "text/html; charset=utf-8", `<p>{{.Params.tenant}} is gone</p>`)
+	require.NoError(t, err)
+
+	m := NewMux()
+	require.NoError(t, m.Handle(`Host("<tenant>.example.com") && Path("/text")`, text))
+	require.NoError(t, m.Handle(`Host("<tenant>.example.com") && Path("/html")`, html))
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/text?retry=later", host: "acme.example.com", method: http.MethodGet}))
+	assert.Equal(t, http.StatusServiceUnavailable, w.header)
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "GET /text: tenant acme is under maintenance, retry later", w.buf.String())
+	assert.Equal(t, "56", w.Header().Get("Content-Length"))
+
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/html", host: "a<b>.example.com"}))
+	assert.Equal(t, http.StatusGone, w.header)
+	assert.Equal(t, "<p>a&lt;b&gt; is gone</p>", w.buf.String())
+
+	broken, err := StaticResponse(http.StatusOK, "", `{{.Missing.Field}}`)
+	require.NoError(t, err)
+	w = newWriter()
+	broken.ServeHTTP(w, makeReq(req{url: "/"}))
+	assert.Equal(t, http.StatusInternalServerError, w.header)
+
+	_, err = StaticResponse(http.StatusOK, "text/plain", `{{`)
+	assert.Error(t, err)
+}
+
+func TestStaticResponseMeta(t *testing.T) {
+	notice, err := StaticResponse(http.StatusGone, "text/plain", `{{.Path}} was retired, contact {{.Meta.team}}`)
+	require.NoError(t, err)
+
+	m := NewMux()
+	require.NoError(t, m.HandleMeta(`Path("/v1/users")`, notice, map[string]string{"team": "identity"}))
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/v1/users", method: http.MethodGet}))
+	assert.Equal(t, http.StatusGone, w.header)
+	assert.Equal(t, "/v1/users was retired, contact identity", w.buf.String())
+
+	// outside of a mux the metadata is empty
+	w = newWriter()
+	notice.ServeHTTP(w, makeReq(req{url: "/v1/users"}))
+	assert.Equal(t, "/v1/users was retired, contact <no value>", w.buf.String())
+}
+
+func TestStaticResponseEscaping(t *testing.T) {
+	json, err := StaticResponse(http.StatusServiceUnavailable, "application/problem+json", `{"tenant": {{.Params.tenant}}, "retry": {{.Query.Get "retry"}}{{if .Meta}}, "team": {{.Meta.team}}{{end}}}`)
+	require.NoError(t, err)
+	xhtml, err := StaticResponse(http.StatusGone, "application/xhtml+xml", `<p>{{.Params.tenant}} is gone</p>`)
+	require.NoError(t, err)
+
+	m := NewMux()
+	require.NoError(t, m.HandleMeta(`Host("<tenant>.example.com") && Path("/json")`, json, map[string]string{"team": `"ops"`}))
+	require.NoError(t, m.Handle(`Host("<tenant>.example.com") && Path("/xhtml")`, xhtml))
+
+	// the values are JSON-encoded, so they can't inject fields
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: `/json?retry=","admin":"true`, host: "acme.example.com"}))
+	assert.Equal(t, http.StatusServiceUnavailable, w.header)
+	assert.JSONEq(t, `{"tenant": "acme", "retry": "\",\"admin\":\"true", "team": "\"ops\""}`, w.buf.String())
+
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/xhtml", host: "a<b>.example.com"}))
+	assert.Equal(t, "<p>a&lt;b&gt; is gone</p>", w.buf.String())
+
+	// the content types the values can't be escaped for only serve static bodies
+	_, err = StaticResponse(http.StatusOK, "application/xml", `<tenant>{{.Params.tenant}}</tenant>`)
+	assert.Error(t, err)
+	_, err = StaticResponse(http.StatusOK, "application/xml", `<status>maintenance</status>`)
+	assert.NoError(t, err)
+	_, err = StaticResponse(http.StatusOK, "application/json", `{{define "t"}}{{.Path}}{{end}}{"path": {{template "t" .}}}`)
+	assert.Error(t, err)
+
+	// the templated bodies without content type are not sniffed
+	text, err := StaticResponse(http.StatusOK, "", `{{.Path}}`)
+	require.NoError(t, err)
+	w = newWriter()
+	text.ServeHTTP(w, makeReq(req{url: "/<html>"}))
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+}
@@ -0,0 +1,73 @@
+package route
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// RouteStats are the traffic statistics of a route
+type RouteStats struct {
+	// Requests is the number of requests served by the route
+	Requests int64
+	// BytesIn is the number of request body bytes read by the handler
+	BytesIn int64
+	// BytesOut is the number of response body bytes written by the handler
+	BytesOut int64
+}
+
+type routeStats struct {
+	requests atomic.Int64
+	bytesIn  atomic.Int64
+	bytesOut atomic.Int64
+}
+
+// SetAccounting enables or disables the traffic accounting of the routes, see Mux.Stats.
+// Accounting wraps the request body and the response writer, so it is disabled by default.
+func (m *Mux) SetAccounting(enabled bool) {
+	m.accounting = enabled
+}
+
+// Stats returns the traffic statistics of the route registered for the expression,
+// statistics are kept when the route handler is replaced.
+func (m *Mux) Stats(expr string) (RouteStats, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	e, ok := m.keys[m.keyFunc(expr)]
+	if !ok {
+		return RouteStats{}, false
+	}
+	return RouteStats{
+		Requests: e.stats.requests.Load(),
+		BytesIn:  e.stats.bytesIn.Load(),
+		BytesOut: e.stats.bytesOut.Load(),
+	}, true
+}
+
+// serveAccounted serves the request counting the bytes going through the route
+func (e *entry) serveAccounted(w http.ResponseWriter, r *http.Request, h http.Handler) {
+	e.stats.requests.Add(1)
+
+	if r.Body != nil && r.Body != http.NoBody {
+		body := &countingBody{ReadCloser: r.Body}
+		defer func() { e.stats.bytesIn.Add(body.read) }()
+		r.Body = body
+	}
+
+	sw := newStatusWriter(w)
+	defer func() { e.stats.bytesOut.Add(sw.written) }()
+	h.ServeHTTP(sw, r)
+}
+
+// countingBody counts the bytes read from the request body
+type countingBody struct {
+	io.ReadCloser
+	read int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	return n, err
+}
@@ -0,0 +1,55 @@
+package route
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	m := NewMux()
+	m.SetAccounting(true)
+
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+		_, _ = w.Write([]byte("!"))
+	})
+	require.NoError(t, m.Handle(`Path("/echo")`, echo))
+
+	for _, body := range []string{"hello", "world!"} {
+		r := makeReq(req{url: "/echo"})
+		r.Body = io.NopCloser(strings.NewReader(body))
+		m.ServeHTTP(newWriter(), r)
+	}
+	r := makeReq(req{url: "/echo"})
+	r.Body = http.NoBody
+	m.ServeHTTP(newWriter(), r)
+
+	stats, ok := m.Stats(`Path( "/echo" )`)
+	require.True(t, ok)
+	assert.Equal(t, RouteStats{Requests: 3, BytesIn: 11, BytesOut: 14}, stats)
+
+	// statistics survive handler replacement
+	require.NoError(t, m.Handle(`Path("/echo")`, echo))
+	stats, ok = m.Stats(`Path("/echo")`)
+	require.True(t, ok)
+	assert.Equal(t, int64(3), stats.Requests)
+
+	_, ok = m.Stats(`Path("/missing")`)
+	assert.False(t, ok)
+}
+
+func TestStatsDisabled(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/a")`, newStatusHandler(http.StatusOK)))
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/a"}))
+
+	stats, ok := m.Stats(`Path("/a")`)
+	require.True(t, ok)
+	assert.Equal(t, RouteStats{}, stats)
+}
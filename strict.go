@@ -0,0 +1,24 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrRouteExists is returned when adding a route whose key is already registered in strict mode,
+// or when InitHandlers is given several expressions with the same key
+var ErrRouteExists = errors.New("route already exists")
+
+func existsError(registered *entry, expr string) error {
+	if registered.expr == expr {
+		return fmt.Errorf("expression '%s' is already registered: %w", expr, ErrRouteExists)
+	}
+	return fmt.Errorf("expressions '%s' and '%s' define the same route: %w", registered.expr, expr, ErrRouteExists)
+}
+
+// SetStrict enables the strict mode: Handle and HandleOwned return ErrRouteExists instead of replacing
+// the route when an expression with the same key is already registered, so that teams sharing a mux
+// can't silently replace each other's routes. Routes have to be removed before being replaced.
+func (m *Mux) SetStrict(enabled bool) {
+	m.strict = enabled
+}
@@ -0,0 +1,34 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrict(t *testing.T) {
+	m := NewMux()
+	m.SetStrict(true)
+	require.NoError(t, m.Handle(`Path("/a")`, newStatusHandler(http.StatusOK)))
+
+	assert.ErrorIs(t, m.Handle(`Path("/a")`, newStatusHandler(http.StatusAccepted)), ErrRouteExists)
+	assert.ErrorIs(t, m.Handle(`Path( "/a" )`, newStatusHandler(http.StatusAccepted)), ErrRouteExists)
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/a"}))
+	assert.Equal(t, http.StatusOK, w.header)
+
+	require.NoError(t, m.Remove(`Path("/a")`))
+	require.NoError(t, m.Handle(`Path("/a")`, newStatusHandler(http.StatusAccepted)))
+
+	m.SetStrict(false)
+	require.NoError(t, m.Handle(`Path("/a")`, newStatusHandler(http.StatusOK)))
+
+	err := m.InitHandlers(map[string]interface{}{
+		`Path("/b")`:   newStatusHandler(http.StatusOK),
+		`Path( "/b" )`: newStatusHandler(http.StatusOK),
+	})
+	assert.ErrorIs(t, err, ErrRouteExists)
+}
@@ -0,0 +1,91 @@
+package route
+
+import (
+	"sync"
+)
+
+// MutationType is the type of route table change
+type MutationType int
+
+const (
+	// MutationAdd means a new route was added
+	MutationAdd MutationType = iota
+	// MutationRemove means a route was removed
+	MutationRemove
+	// MutationReplace means a route was replaced by a route with the same key
+	MutationReplace
+	// MutationReset means the whole route table was replaced, e.g. by InitHandlers
+	MutationReset
+)
+
+func (t MutationType) String() string {
+	switch t {
+	case MutationAdd:
+		return "add"
+	case MutationRemove:
+		return "remove"
+	case MutationReplace:
+		return "replace"
+	case MutationReset:
+		return "reset"
+	}
+	return "unknown"
+}
+
+// Mutation describes a change of the route table
+type Mutation struct {
+	Type MutationType
+	// Expr is the expression of the added or removed route, or of the new route for replacements
+	Expr string
+	// Prev is the expression of the replaced route
+	Prev string
+	// Hash is the hash of the route table after the mutation
+	Hash uint64
+}
+
+// subscriptionBuffer is the capacity of subscription channels
+const subscriptionBuffer = 256
+
+type subscribers struct {
+	mutex    sync.Mutex
+	channels []chan Mutation
+}
+
+// Subscribe returns a channel receiving the mutations of the route table.
+// Mutations are never blocking: if the subscriber does not keep up, mutations are dropped,
+// subscribers can compare Mutation.Hash with Mux.Hash to detect that and resync using Routes.
+func (m *Mux) Subscribe() <-chan Mutation {
+	m.subscribers.mutex.Lock()
+	defer m.subscribers.mutex.Unlock()
+
+	ch := make(chan Mutation, subscriptionBuffer)
+	m.subscribers.channels = append(m.subscribers.channels, ch)
+	return ch
+}
+
+// Unsubscribe stops sending mutations to the channel and closes it
+func (m *Mux) Unsubscribe(sub <-chan Mutation) {
+	m.subscribers.mutex.Lock()
+	defer m.subscribers.mutex.Unlock()
+
+	for i, ch := range m.subscribers.channels {
+		if ch == sub {
+			m.subscribers.channels = append(m.subscribers.channels[:i], m.subscribers.channels[i+1:]...)
+			close(ch)
+			return
+		}
+	}
+}
+
+func (m *Mux) notify(t MutationType, expr, prev string) {
+	m.subscribers.mutex.Lock()
+	defer m.subscribers.mutex.Unlock()
+
+	mutation := Mutation{Type: t, Expr: expr, Prev: prev, Hash: m.hash}
+	for _, ch := range m.subscribers.channels {
+		select {
+		case ch <- mutation:
+		default:
+		}
+	}
+}
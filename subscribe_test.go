@@ -0,0 +1,39 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe(t *testing.T) {
+	m := NewMux()
+	sub := m.Subscribe()
+
+	require.NoError(t, m.Handle(`Path("/a")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path( "/a" )`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Remove(`Path("/a")`))
+	require.NoError(t, m.Remove(`Path("/missing")`))
+	require.NoError(t, m.InitHandlers(map[string]interface{}{`Path("/b")`: newStatusHandler(http.StatusOK)}))
+
+	assert.Equal(t, Mutation{Type: MutationAdd, Expr: `Path("/a")`, Hash: HashExprs(`Path("/a")`)}, <-sub)
+	assert.Equal(t, Mutation{Type: MutationReplace, Expr: `Path( "/a" )`, Prev: `Path("/a")`, Hash: HashExprs(`Path( "/a" )`)}, <-sub)
+	assert.Equal(t, Mutation{Type: MutationRemove, Expr: `Path( "/a" )`}, <-sub)
+	assert.Equal(t, Mutation{Type: MutationReset, Hash: m.Hash()}, <-sub)
+
+	m.Unsubscribe(sub)
+	_, ok := <-sub
+	assert.False(t, ok)
+}
+
+func TestSubscribeSlowSubscriber(t *testing.T) {
+	m := NewMux()
+	sub := m.Subscribe()
+
+	for i := 0; i < subscriptionBuffer+1; i++ {
+		require.NoError(t, m.Handle(`Path("/a")`, newStatusHandler(http.StatusOK)))
+	}
+	assert.Len(t, sub, subscriptionBuffer)
+}
@@ -0,0 +1,55 @@
+package route
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer starts a span for every request served by the Mux, so the spans can be named after the matched
+// route, which is only known to the router. An OpenTelemetry tracer can be adapted like this:
+//
+//	type otelTracer struct{ tracer trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, r *http.Request) (context.Context, route.Span) {
+//		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+//		ctx, span := t.tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer))
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ span trace.Span }
+//
+//	func (s otelSpan) End(result route.TraceResult) {
+//		if result.Matched {
+//			s.span.SetName(result.Expr)
+//			s.span.SetAttributes(attribute.String("http.route", result.Expr))
+//		}
+//		s.span.SetAttributes(attribute.Bool("route.matched", result.Matched), attribute.Int("http.response.status_code", result.Status))
+//		s.span.End()
+//	}
+type Tracer interface {
+	// Start starts the span of the request before it is routed, the returned context holds the span
+	// and is passed to the handler, so the spans of the handler are children of the span
+	Start(ctx context.Context, r *http.Request) (context.Context, Span)
+}
+
+// Span is the span of a request started by a Tracer
+type Span interface {
+	// End ends the span once the request is served
+	End(result TraceResult)
+}
+
+// TraceResult describes how a traced request was routed and served
+type TraceResult struct {
+	// Expr is the expression of the matched route, empty if no route matched. Unlike the request path,
+	// it has a low cardinality and is suitable as span name.
+	Expr string
+	// Matched is true if a route matched the request
+	Matched bool
+	// Status is the status of the response
+	Status int
+}
+
+// SetTracer sets the tracer starting a span for every request served by the mux, nil removes it
+func (m *Mux) SetTracer(t Tracer) {
+	m.tracer = t
+}
@@ -0,0 +1,73 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type spanKey struct{}
+
+type testSpan struct {
+	parent string
+	result *TraceResult
+}
+
+func (s *testSpan) End(result TraceResult) {
+	s.result = &result
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, r *http.Request) (context.Context, Span) {
+	span := &testSpan{parent: r.Header.Get("Traceparent")}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func TestTracer(t *testing.T) {
+	tracer := &testTracer{}
+	m := NewMux()
+	m.SetTracer(tracer)
+	var inHandler Span
+	require.NoError(t, m.HandleFunc(`Path("/users/<id>")`, func(w http.ResponseWriter, r *http.Request) {
+		inHandler, _ = r.Context().Value(spanKey{}).(Span)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/users/42", headers: http.Header{"Traceparent": {"00-abc-def-01"}}}))
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/missing"}))
+
+	require.Len(t, tracer.spans, 2)
+	// the handler runs within the span of the request
+	assert.Same(t, tracer.spans[0], inHandler)
+	assert.Equal(t, "00-abc-def-01", tracer.spans[0].parent)
+	assert.Equal(t, &TraceResult{Expr: `Path("/users/<id>")`, Matched: true, Status: http.StatusAccepted}, tracer.spans[0].result)
+	assert.Equal(t, &TraceResult{Status: http.StatusNotFound}, tracer.spans[1].result)
+
+	m.SetTracer(nil)
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/users/42"}))
+	assert.Len(t, tracer.spans, 2)
+}
+
+func TestTracerWithObserver(t *testing.T) {
+	tracer := &testTracer{}
+	observed := &recordingObserver{}
+	m := NewMux()
+	m.SetTracer(tracer)
+	m.SetObserver(observed)
+	require.NoError(t, m.Handle(`Path("/")`, newStatusHandler(http.StatusOK)))
+
+	m.ServeHTTP(newWriter(), makeReq(req{url: "/"}))
+	require.Len(t, tracer.spans, 1)
+	assert.Equal(t, &TraceResult{Expr: `Path("/")`, Matched: true, Status: http.StatusOK}, tracer.spans[0].result)
+	require.Len(t, *observed, 1)
+	assert.Equal(t, `Path("/")`, (*observed)[0].expr)
+	assert.GreaterOrEqual(t, (*observed)[0].duration, time.Duration(0))
+}
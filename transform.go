@@ -0,0 +1,91 @@
+package route
+
+import (
+	"io"
+	"net/http"
+)
+
+// ResponseTransformer modifies the responses of a route, e.g. to rewrite links of mounted prefixes
+// or to redact JSON fields at the edge, see Mux.SetTransformers
+type ResponseTransformer interface {
+	// TransformHeader is called before the response header is written,
+	// it can modify the header and returns the status to write
+	TransformHeader(status int, header http.Header) int
+	// TransformBody returns the writer the response body is written to, writing the transformed body to w.
+	// It is closed once the handler returns. Transformers changing the size of the body should remove
+	// the Content-Length header in TransformHeader.
+	TransformBody(w io.Writer) io.WriteCloser
+}
+
+// SetTransformers sets the transformers of the responses of the route registered for the expression,
+// the first transformer receives the response written by the handler. Calling it without transformers
+// removes them.
+func (m *Mux) SetTransformers(expr string, transformers ...ResponseTransformer) error {
+	return m.setOptions(expr, func(o *routeOptions) {
+		o.transformers = transformers
+	})
+}
+
+// transformWriter applies the transformers to the response written by the handler
+type transformWriter struct {
+	http.ResponseWriter
+	transformers []ResponseTransformer
+	wroteHeader  bool
+	body         io.Writer
+	// closers are the body writers, from the closest to the handler to the closest to the client
+	closers []io.Closer
+}
+
+func serveTransformed(w http.ResponseWriter, r *http.Request, h http.Handler, transformers []ResponseTransformer) {
+	tw := &transformWriter{ResponseWriter: w, transformers: transformers}
+	h.ServeHTTP(tw, r)
+	tw.close()
+}
+
+func (w *transformWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	// informational responses are sent as is
+	if status >= 100 && status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.wroteHeader = true
+
+	for _, t := range w.transformers {
+		status = t.TransformHeader(status, w.Header())
+	}
+	w.ResponseWriter.WriteHeader(status)
+
+	body := io.Writer(w.ResponseWriter)
+	w.closers = make([]io.Closer, len(w.transformers))
+	for i := len(w.transformers) - 1; i >= 0; i-- {
+		wc := w.transformers[i].TransformBody(body)
+		w.closers[i] = wc
+		body = wc
+	}
+	w.body = body
+}
+
+func (w *transformWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.body.Write(p)
+}
+
+// Unwrap allows http.ResponseController to reach the wrapped writer
+func (w *transformWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close flushes the transformers once the handler returned
+func (w *transformWriter) close() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	for _, c := range w.closers {
+		_ = c.Close()
+	}
+}
@@ -0,0 +1,99 @@
+package route
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replaceTransformer replaces a string in the buffered response body
+type replaceTransformer struct {
+	old, new string
+}
+
+func (t replaceTransformer) TransformHeader(status int, header http.Header) int {
+	header.Del("Content-Length")
+	header.Add("X-Transformed", t.old)
+	return status
+}
+
+func (t replaceTransformer) TransformBody(w io.Writer) io.WriteCloser {
+	return &replaceBody{w: w, t: t}
+}
+
+type replaceBody struct {
+	w   io.Writer
+	t   replaceTransformer
+	buf bytes.Buffer
+}
+
+func (b *replaceBody) Write(p []byte) (int, error) {
+	return b.buf.Write(p)
+}
+
+func (b *replaceBody) Close() error {
+	_, err := io.WriteString(b.w, strings.ReplaceAll(b.buf.String(), b.t.old, b.t.new))
+	return err
+}
+
+// statusTransformer replaces the status of the responses
+type statusTransformer int
+
+func (t statusTransformer) TransformHeader(int, http.Header) int {
+	return int(t)
+}
+
+func (t statusTransformer) TransformBody(w io.Writer) io.WriteCloser {
+	return nopWriteCloser{w}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func TestSetTransformers(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleFunc(`Path("/page")`, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", "17")
+		_, _ = w.Write([]byte(`<a href="/docs">`))
+		_, _ = w.Write([]byte("\n"))
+	}))
+	require.NoError(t, m.SetTransformers(`Path("/page")`,
+		replaceTransformer{old: `"/docs`, new: `"/v1/docs`},
+		replaceTransformer{old: `/v1`, new: `/mounted/v1`},
+		statusTransformer(http.StatusAccepted),
+	))
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/page"}))
+	assert.Equal(t, http.StatusAccepted, w.header)
+	assert.Equal(t, "<a href=\"/mounted/v1/docs\">\n", w.buf.String())
+	assert.Empty(t, w.Header().Get("Content-Length"))
+	assert.Equal(t, []string{`"/docs`, `/v1`}, w.Header().Values("X-Transformed"))
+
+	require.NoError(t, m.SetTransformers(`Path("/page")`))
+	w = newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/page"}))
+	assert.Equal(t, "<a href=\"/docs\">\n", w.buf.String())
+
+	assert.Error(t, m.SetTransformers(`Path("/other")`, statusTransformer(http.StatusOK)))
+}
+
+func TestTransformEmptyResponse(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.HandleFunc(`Path("/empty")`, func(http.ResponseWriter, *http.Request) {}))
+	require.NoError(t, m.SetTransformers(`Path("/empty")`, statusTransformer(http.StatusNoContent)))
+
+	w := newWriter()
+	m.ServeHTTP(w, makeReq(req{url: "/empty"}))
+	assert.Equal(t, http.StatusNoContent, w.header)
+}
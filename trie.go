@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"slices"
 	"strings"
 	"unicode"
 )
@@ -69,6 +70,17 @@ func (t *trie) setMatch(result *match) {
 	t.root.setMatch(result)
 }
 
+func (t *trie) setSettings(*settings) {}
+
+// clone returns a copy of the trie and of its nodes, the mapper and the pattern matchers are shared
+func (t *trie) clone() matcher {
+	c := &trie{mapper: t.mapper}
+	if t.root != nil {
+		c.root = t.root.clone(c)
+	}
+	return c
+}
+
 // Tries can merge with other tries
 func (t *trie) canMerge(m matcher) bool {
 	ot, ok := m.(*trie)
@@ -126,6 +138,23 @@ func (t *trieNode) setMatch(m *match) {
 	n.matches = []*match{m}
 }
 
+func (t *trieNode) clone(owner *trie) *trieNode {
+	c := &trieNode{
+		trie:           owner,
+		char:           t.char,
+		patternMatcher: t.patternMatcher,
+		matches:        slices.Clone(t.matches),
+		level:          t.level,
+	}
+	if len(t.children) != 0 {
+		c.children = make([]*trieNode, len(t.children))
+		for i, child := range t.children {
+			c.children[i] = child.clone(owner)
+		}
+	}
+	return c
+}
+
 func (t *trieNode) setLevel(level int) {
 	if t.isRoot() {
 		level++
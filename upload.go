@@ -0,0 +1,129 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// BlobSink stores the uploaded objects, e.g. in an S3-compatible bucket
+type BlobSink interface {
+	// Put stores the object read from body under the key. The size is the size of the body,
+	// -1 if it is unknown. The body returns an error if the upload is aborted or too large,
+	// in which case the object should not be stored.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+}
+
+// UploadOptions are the guards and the naming of the objects uploaded by an Upload handler
+type UploadOptions struct {
+	// MaxSize is the maximum size in bytes of the uploaded objects, zero for no limit
+	MaxSize int64
+	// ContentTypes are the media types accepted, e.g. image/png or image/*, any if empty
+	ContentTypes []string
+	// Key returns the key the object is stored under, by default the request path without its leading slash.
+	// An error is answered with 400 Bad Request.
+	Key func(r *http.Request) (string, error)
+}
+
+// Upload returns a handler streaming the request bodies to the sink, so upload endpoints can be declared
+// instead of implemented. Bodies larger than MaxSize are answered with 413 Request Entity Too Large and
+// bodies of other media types than ContentTypes with 415 Unsupported Media Type. Stored objects are
+// answered with 201 Created and sink errors with 502 Bad Gateway.
+func Upload(sink BlobSink, options UploadOptions) (http.Handler, error) {
+	if sink == nil {
+		return nil, errors.New("blob sink cannot be nil")
+	}
+	if options.MaxSize < 0 {
+		return nil, errors.New("max size cannot be negative")
+	}
+	for _, t := range options.ContentTypes {
+		if _, _, err := mime.ParseMediaType(t); err != nil {
+			return nil, err
+		}
+	}
+	return &uploader{sink: sink, options: options}, nil
+}
+
+// HandleUpload adds a route for the expression streaming the request bodies to the sink, see Upload
+func (m *Mux) HandleUpload(expr string, sink BlobSink, options UploadOptions) error {
+	h, err := Upload(sink, options)
+	if err != nil {
+		return err
+	}
+	return m.Handle(expr, h)
+}
+
+type uploader struct {
+	sink    BlobSink
+	options UploadOptions
+}
+
+func (u *uploader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !u.accepts(contentType) {
+		http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+	if u.options.MaxSize != 0 && r.ContentLength > u.options.MaxSize {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	key, err := u.key(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var body io.Reader = http.NoBody
+	if r.Body != nil {
+		body = r.Body
+	}
+	if u.options.MaxSize != 0 {
+		body = http.MaxBytesReader(w, io.NopCloser(body), u.options.MaxSize)
+	}
+	if err := u.sink.Put(r.Context(), key, body, r.ContentLength, contentType); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// accepts returns true if the media type of the content type is accepted
+func (u *uploader) accepts(contentType string) bool {
+	if len(u.options.ContentTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, accepted := range u.options.ContentTypes {
+		accepted, _, _ = mime.ParseMediaType(accepted)
+		if accepted == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(accepted, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *uploader) key(r *http.Request) (string, error) {
+	if u.options.Key != nil {
+		return u.options.Key(r)
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	if key == "" {
+		return "", errors.New("object key cannot be empty")
+	}
+	return key, nil
+}
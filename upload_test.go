@@ -0,0 +1,105 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type object struct {
+	data        string
+	size        int64
+	contentType string
+}
+
+// memorySink stores the objects in memory, failing the keys in failing
+type memorySink struct {
+	objects map[string]object
+	failing string
+}
+
+func (s *memorySink) Put(_ context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if key == s.failing {
+		return errors.New("bucket unavailable")
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	s.objects[key] = object{data: string(data), size: size, contentType: contentType}
+	return nil
+}
+
+func TestUpload(t *testing.T) {
+	sink := &memorySink{objects: map[string]object{}, failing: "uploads/broken.png"}
+	m := NewMux()
+	require.NoError(t, m.HandleUpload(`Method("PUT") && Path("/uploads/<path:name>")`, sink, UploadOptions{
+		MaxSize:      8,
+		ContentTypes: []string{"image/*", "application/pdf"},
+	}))
+
+	upload := func(path, contentType, body string, chunked bool) int {
+		r := httptest.NewRequest(http.MethodPut, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		if chunked {
+			r.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, upload("/uploads/cat.png", "image/png", "meow", false))
+	assert.Equal(t, object{data: "meow", size: 4, contentType: "image/png"}, sink.objects["uploads/cat.png"])
+	assert.Equal(t, http.StatusCreated, upload("/uploads/doc.pdf", "application/pdf; charset=binary", "%PDF", true))
+	assert.Equal(t, object{data: "%PDF", size: -1, contentType: "application/pdf; charset=binary"}, sink.objects["uploads/doc.pdf"])
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, upload("/uploads/page.html", "text/html", "<html>", false))
+	assert.Equal(t, http.StatusUnsupportedMediaType, upload("/uploads/page", "", "data", false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload("/uploads/big.png", "image/png", "0123456789", false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload("/uploads/big.png", "image/png", "0123456789", true))
+	assert.Equal(t, http.StatusBadGateway, upload("/uploads/broken.png", "image/png", "data", false))
+	assert.NotContains(t, sink.objects, "uploads/big.png")
+	assert.NotContains(t, sink.objects, "uploads/page.html")
+}
+
+func TestUploadKey(t *testing.T) {
+	sink := &memorySink{objects: map[string]object{}}
+	h, err := Upload(sink, UploadOptions{Key: func(r *http.Request) (string, error) {
+		if r.Header.Get("X-Tenant") == "" {
+			return "", errors.New("missing tenant")
+		}
+		return r.Header.Get("X-Tenant") + "/" + Param(r, "name"), nil
+	}})
+	require.NoError(t, err)
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/files/<name>")`, h))
+
+	r := httptest.NewRequest(http.MethodPost, "/files/report.csv", strings.NewReader("a,b"))
+	r.Header.Set("X-Tenant", "acme")
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "a,b", sink.objects["acme/report.csv"].data)
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/files/report.csv", strings.NewReader("a,b")))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUploadErrors(t *testing.T) {
+	_, err := Upload(nil, UploadOptions{})
+	assert.Error(t, err)
+	_, err = Upload(&memorySink{}, UploadOptions{MaxSize: -1})
+	assert.Error(t, err)
+	_, err = Upload(&memorySink{}, UploadOptions{ContentTypes: []string{"image/"}})
+	assert.Error(t, err)
+	assert.Error(t, NewMux().HandleUpload(`Path("/")`, nil, UploadOptions{}))
+}
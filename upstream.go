@@ -0,0 +1,112 @@
+package route
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// statsWindow is the number of most recent requests the upstream statistics are computed over
+const statsWindow = 1024
+
+// Upstream is a handler receiving requests from a balancer, it tracks the outcome of the requests
+// so passive health checks and metrics can detect outliers
+type Upstream struct {
+	handler  http.Handler
+	inflight atomic.Int64
+
+	mutex    sync.Mutex
+	requests int64
+	failures int64
+	// window holds the outcome of the most recent requests
+	window [statsWindow]sample
+	next   int
+}
+
+type sample struct {
+	latency time.Duration
+	failed  bool
+}
+
+// UpstreamStats are the statistics of an upstream
+type UpstreamStats struct {
+	// Requests and Failures are the total number of requests and failed requests (5xx responses)
+	Requests int64
+	Failures int64
+	// InFlight is the number of requests being served
+	InFlight int64
+	// SuccessRate is the share of the recent requests that did not fail, 1 if there were no requests
+	SuccessRate float64
+	// P50, P90 and P99 are the latency percentiles of the recent requests
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+}
+
+func newUpstream(h http.Handler) *Upstream {
+	return &Upstream{handler: h}
+}
+
+// ServeHTTP passes the request to the upstream handler and records the outcome
+func (u *Upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.inflight.Add(1)
+	defer u.inflight.Add(-1)
+
+	sw := newStatusWriter(w)
+	clock := clockFor(r)
+	start := clock.Now()
+	u.handler.ServeHTTP(sw, r)
+	u.record(clock.Now().Sub(start), sw.Status() >= http.StatusInternalServerError)
+}
+
+func (u *Upstream) record(latency time.Duration, failed bool) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.requests++
+	if failed {
+		u.failures++
+	}
+	u.window[u.next%statsWindow] = sample{latency: latency, failed: failed}
+	u.next++
+}
+
+// Stats returns the statistics of the upstream
+func (u *Upstream) Stats() UpstreamStats {
+	u.mutex.Lock()
+	stats := UpstreamStats{
+		Requests:    u.requests,
+		Failures:    u.failures,
+		InFlight:    u.inflight.Load(),
+		SuccessRate: 1,
+	}
+	samples := u.window[:min(u.next, statsWindow)]
+	latencies := make([]time.Duration, len(samples))
+	failed := 0
+	for i, s := range samples {
+		latencies[i] = s.latency
+		if s.failed {
+			failed++
+		}
+	}
+	u.mutex.Unlock()
+
+	if len(latencies) == 0 {
+		return stats
+	}
+
+	slices.Sort(latencies)
+	stats.SuccessRate = 1 - float64(failed)/float64(len(latencies))
+	stats.P50 = percentile(latencies, 0.5)
+	stats.P90 = percentile(latencies, 0.9)
+	stats.P99 = percentile(latencies, 0.99)
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of the sorted values
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(float64(len(sorted))*p+0.5) - 1
+	return sorted[max(0, min(i, len(sorted)-1))]
+}
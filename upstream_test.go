@@ -0,0 +1,50 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpstreamStats(t *testing.T) {
+	status := http.StatusOK
+	u := newUpstream(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	stats := u.Stats()
+	assert.Equal(t, UpstreamStats{SuccessRate: 1}, stats)
+
+	for i := 0; i < 3; i++ {
+		u.ServeHTTP(newWriter(), makeReq(req{url: "/"}))
+	}
+	status = http.StatusBadGateway
+	u.ServeHTTP(newWriter(), makeReq(req{url: "/"}))
+
+	stats = u.Stats()
+	assert.Equal(t, int64(4), stats.Requests)
+	assert.Equal(t, int64(1), stats.Failures)
+	assert.Equal(t, int64(0), stats.InFlight)
+	assert.InDelta(t, 0.75, stats.SuccessRate, 0.001)
+}
+
+func TestUpstreamStatsWindow(t *testing.T) {
+	u := newUpstream(newStatusHandler(http.StatusOK))
+
+	for i := 0; i < statsWindow; i++ {
+		u.record(time.Duration(i+1)*time.Millisecond, true)
+	}
+	for i := 0; i < statsWindow; i++ {
+		u.record(time.Duration(i+1)*time.Millisecond, false)
+	}
+
+	stats := u.Stats()
+	assert.Equal(t, int64(2*statsWindow), stats.Requests)
+	assert.Equal(t, int64(statsWindow), stats.Failures)
+	assert.InDelta(t, 1, stats.SuccessRate, 0.001)
+	assert.Equal(t, 512*time.Millisecond, stats.P50)
+	assert.Equal(t, 922*time.Millisecond, stats.P90)
+	assert.Equal(t, 1014*time.Millisecond, stats.P99)
+}
@@ -0,0 +1,114 @@
+package route
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProblemType is the type of problem found while validating a route table
+type ProblemType int
+
+const (
+	// ProblemHandler means the value registered for the expression is not an http.Handler
+	ProblemHandler ProblemType = iota
+	// ProblemParse means the expression, or the expression expanded with the aliases, can't be parsed
+	ProblemParse
+	// ProblemConflict means the expression defines the same route as another expression
+	ProblemConflict
+	// ProblemOrphanRoute means the route table and the registered routes disagree,
+	// e.g. an alias route left behind by a removed route, see Mux.Verify
+	ProblemOrphanRoute
+	// ProblemUnreachable means the route is shadowed by another route, see Mux.Verify
+	ProblemUnreachable
+	// ProblemPlaceholder means a placeholder of the expression is malformed or captures a param twice
+	ProblemPlaceholder
+	// ProblemPriority means the route has the same explicit priority as an overlapping route
+	ProblemPriority
+)
+
+func (t ProblemType) String() string {
+	switch t {
+	case ProblemHandler:
+		return "handler"
+	case ProblemParse:
+		return "parse"
+	case ProblemConflict:
+		return "conflict"
+	case ProblemOrphanRoute:
+		return "orphan route"
+	case ProblemUnreachable:
+		return "unreachable"
+	case ProblemPlaceholder:
+		return "placeholder"
+	case ProblemPriority:
+		return "priority"
+	}
+	return "unknown"
+}
+
+// Problem is a problem found with an expression while validating a route table
+type Problem struct {
+	Type ProblemType
+	Expr string
+	Err  error
+}
+
+// ValidationError is returned by InitHandlers and DryRunInitHandlers when the route table is invalid,
+// it lists every offending expression instead of the first one so rule authors can fix them at once
+type ValidationError struct {
+	// Problems are sorted by expression
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", p.Type, p.Err))
+	}
+	return fmt.Sprintf("%d invalid route(s): %s", len(e.Problems), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the problems
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		errs = append(errs, p.Err)
+	}
+	return errs
+}
+
+func (e *ValidationError) add(t ProblemType, expr string, err error) {
+	e.Problems = append(e.Problems, Problem{Type: t, Expr: expr, Err: err})
+}
+
+// errOrNil returns the validation error if it has problems
+func (e *ValidationError) errOrNil() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	sort.SliceStable(e.Problems, func(i, j int) bool {
+		return e.Problems[i].Expr < e.Problems[j].Expr
+	})
+	return e
+}
+
+// compileError explains the failure of the compilation of the routes by parsing the expressions
+// one by one, the error is returned as is if no expression fails on its own
+func (m *Mux) compileError(routes map[string]interface{}, err error) error {
+	report := &ValidationError{}
+	for expr, e := range routes {
+		if _, perr := m.router.Load().pool.parse(expr, &match{}); perr != nil {
+			if registered := e.(*entry).expr; registered != expr {
+				perr = fmt.Errorf("alias '%s' of '%s': %w", expr, registered, perr)
+			} else {
+				perr = fmt.Errorf("'%s': %w", expr, perr)
+			}
+			report.add(ProblemParse, e.(*entry).expr, perr)
+		}
+	}
+	if verr := report.errOrNil(); verr != nil {
+		return verr
+	}
+	return err
+}
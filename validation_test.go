@@ -0,0 +1,57 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationError(t *testing.T) {
+	m := NewMux()
+	m.AddAlias(`Host("$legacy")`, `Host(`)
+
+	err := m.InitHandlers(map[string]interface{}{
+		`Path("/a")`:   newStatusHandler(http.StatusOK),
+		`Path( "/a" )`: newStatusHandler(http.StatusOK),
+		`Path("/b")`:   "not a handler",
+	})
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.Len(t, verr.Problems, 2)
+	assert.Equal(t, ProblemConflict, verr.Problems[0].Type)
+	assert.Equal(t, `Path("/a")`, verr.Problems[0].Expr)
+	assert.Equal(t, ProblemHandler, verr.Problems[1].Type)
+	assert.Equal(t, `Path("/b")`, verr.Problems[1].Expr)
+
+	err = m.InitHandlers(map[string]interface{}{
+		`Path("/a")`:                          newStatusHandler(http.StatusOK),
+		`Path("/b"`:                           newStatusHandler(http.StatusOK),
+		`Host("$legacy") && Path("/c")`:       newStatusHandler(http.StatusOK),
+		`PathRegexp("[[")`:                    newStatusHandler(http.StatusOK),
+		`Host("localhost") && Path("/valid")`: newStatusHandler(http.StatusOK),
+	})
+	require.True(t, errors.As(err, &verr))
+	require.Len(t, verr.Problems, 3)
+	for _, p := range verr.Problems {
+		assert.Equal(t, ProblemParse, p.Type)
+	}
+	assert.Equal(t, `Host("$legacy") && Path("/c")`, verr.Problems[0].Expr)
+	assert.Contains(t, verr.Problems[0].Err.Error(), "alias")
+	assert.Equal(t, `Path("/b"`, verr.Problems[1].Expr)
+	assert.Equal(t, `PathRegexp("[[")`, verr.Problems[2].Expr)
+	assert.Contains(t, err.Error(), "3 invalid route(s)")
+
+	_, err = m.DryRunInitHandlers(map[string]interface{}{`Path("/b"`: newStatusHandler(http.StatusOK)})
+	require.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Problems, 1)
+}
+
+func TestProblemType(t *testing.T) {
+	assert.Equal(t, "handler", ProblemHandler.String())
+	assert.Equal(t, "parse", ProblemParse.String())
+	assert.Equal(t, "conflict", ProblemConflict.String())
+	assert.Equal(t, "unknown", ProblemType(42).String())
+}
@@ -0,0 +1,184 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Verify checks the invariants of the mux, it is meant to be called after loading the configuration
+// and before serving requests. It returns a *ValidationError listing the problems found:
+//   - ProblemOrphanRoute for the routes of the route table that are not registered, e.g. aliases left
+//     behind by removed routes, and for the registered routes missing from the route table,
+//   - ProblemUnreachable for the routes whose sample request, built from the Host, Path, Method, Header
+//     and Query matchers of routes without patterns, is served by another route,
+//   - ProblemPlaceholder for the '<' and '>' of the Host and Path matchers not part of a placeholder
+//     and for the params captured twice,
+//   - ProblemPriority for the routes with the same explicit priority, host, path and method.
+func (m *Mux) Verify() error {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	report := &ValidationError{}
+	table := m.router.Load()
+	m.verifyTable(table, report)
+	for _, e := range m.sorted {
+		verifyPlaceholders(e, report)
+		m.verifyReachable(table, e, report)
+	}
+	m.verifyPriorities(report)
+	return report.errOrNil()
+}
+
+// verifyTable checks that the route table holds exactly the expressions and the aliases of the registered routes
+func (m *Mux) verifyTable(table *router, report *ValidationError) {
+	table.mutex.RLock()
+	defer table.mutex.RUnlock()
+
+	for expr, res := range table.routes {
+		e, ok := res.val.(*entry)
+		switch {
+		case !ok || m.keys[m.keyFunc(e.expr)] != e:
+			report.add(ProblemOrphanRoute, expr, fmt.Errorf("'%s' is in the route table but is not registered", expr))
+		case expr != e.expr && expr != e.alias:
+			report.add(ProblemOrphanRoute, expr, fmt.Errorf("'%s' is in the route table for '%s' but is not its alias", expr, e.expr))
+		}
+	}
+	for _, e := range m.sorted {
+		for _, expr := range []string{e.expr, e.alias} {
+			if res, ok := table.routes[expr]; expr != "" && (!ok || res.val != e) {
+				report.add(ProblemOrphanRoute, e.expr, fmt.Errorf("'%s' is registered but is not routed to '%s'", expr, e.expr))
+			}
+		}
+	}
+}
+
+// verifyPlaceholders checks the placeholders of the Host and Path matchers of the route
+func verifyPlaceholders(e *entry, report *ValidationError) {
+	ts, err := terms(e.expr)
+	if err != nil {
+		return
+	}
+	names := make(map[string]bool)
+	for _, t := range ts {
+		if (t.name != "Host" && t.name != "Path") || len(t.args) == 0 {
+			continue
+		}
+		pattern := t.args[0]
+		for i := 0; i < len(pattern); i++ {
+			switch pattern[i] {
+			case '<':
+				pm, next, err := parsePatternMatcher(i, pattern)
+				if err != nil || pm == nil {
+					report.add(ProblemPlaceholder, e.expr, fmt.Errorf("'%s': '<' at offset %d of %s does not start a placeholder", e.expr, i, pattern))
+					continue
+				}
+				if names[pm.getName()] {
+					report.add(ProblemPlaceholder, e.expr, fmt.Errorf("'%s': param %s is captured twice", e.expr, pm.getName()))
+				}
+				names[pm.getName()] = true
+				i = next - 1
+			case '>':
+				report.add(ProblemPlaceholder, e.expr, fmt.Errorf("'%s': '>' at offset %d of %s does not end a placeholder", e.expr, i, pattern))
+			}
+		}
+	}
+	if e.pathRegexp != nil {
+		for _, name := range e.pathRegexp.SubexpNames() {
+			if name != "" && names[name] {
+				report.add(ProblemPlaceholder, e.expr, fmt.Errorf("'%s': param %s is captured twice", e.expr, name))
+			}
+		}
+	}
+}
+
+// verifyReachable checks that the sample request of the route is served by the route
+func (m *Mux) verifyReachable(table *router, e *entry, report *ValidationError) {
+	req, ok := sampleRequest(e.expr)
+	if !ok {
+		return
+	}
+	// routes without Method matcher are reachable if they serve any of the methods
+	methods := []string{req.Method}
+	if e.method == "" {
+		methods = standardMethods
+	}
+	var other *entry
+	for _, method := range methods {
+		req.Method = method
+		res := table.route(req)
+		if res == nil || res == e {
+			return
+		}
+		if other == nil {
+			other = res.(*entry)
+		}
+	}
+	req.Method = methods[0]
+	report.add(ProblemUnreachable, e.expr, fmt.Errorf("'%s' is shadowed by '%s': %s %s%s is routed to it",
+		e.expr, other.expr, req.Method, req.Host, req.URL.RequestURI()))
+}
+
+// sampleRequest builds a request matched by the expression, false if the expression has other matchers
+// than Host, Path, Method, Header and Query, patterns or operators other than &&
+func sampleRequest(expr string) (*http.Request, bool) {
+	if !conjunction(expr) {
+		return nil, false
+	}
+	ts, err := terms(expr)
+	if err != nil {
+		return nil, false
+	}
+	req := &http.Request{Method: http.MethodGet, Host: "sample.invalid", URL: &url.URL{Path: "/"}, Header: http.Header{}}
+	query := url.Values{}
+	for _, t := range ts {
+		for _, arg := range t.args {
+			if strings.ContainsRune(arg, '<') {
+				return nil, false
+			}
+		}
+		switch {
+		case t.name == "Host" && len(t.args) == 1 && !strings.HasPrefix(t.args[0], "*."):
+			req.Host = t.args[0]
+		case t.name == "Path" && len(t.args) == 1:
+			u, err := url.ParseRequestURI(t.args[0])
+			if err != nil {
+				return nil, false
+			}
+			req.URL = u
+		case t.name == "Method" && len(t.args) == 1:
+			req.Method = t.args[0]
+		case t.name == "Header" && len(t.args) == 2:
+			req.Header.Add(t.args[0], t.args[1])
+		case t.name == "Query" && len(t.args) == 2:
+			query.Add(t.args[0], t.args[1])
+		default:
+			return nil, false
+		}
+	}
+	req.URL.RawQuery = query.Encode()
+	req.RequestURI = req.URL.RequestURI()
+	return req, true
+}
+
+// verifyPriorities checks that the routes with an explicit priority are not ordered by the implicit rules
+func (m *Mux) verifyPriorities(report *ValidationError) {
+	type slot struct {
+		priority           int
+		host, path, method string
+	}
+	seen := make(map[slot]*entry)
+	for _, e := range m.sorted {
+		if e.priority == 0 {
+			continue
+		}
+		s := slot{priority: e.priority, host: strings.ToLower(e.host), path: e.path, method: e.method}
+		if prev, ok := seen[s]; ok {
+			report.add(ProblemPriority, e.expr, fmt.Errorf("'%s' has the same priority %d as '%s' for the same host, path and method",
+				e.expr, e.priority, prev.expr))
+			continue
+		}
+		seen[s] = e
+	}
+}
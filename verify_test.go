@@ -0,0 +1,54 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	m := NewMux()
+	m.AddAlias(`Host("localhost")`, `Host("example.com")`)
+	require.NoError(t, m.Handle(`Host("localhost") && Path("/")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path("/orders/<id>")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Method("GET") && Path("/users/me")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Method("POST") && Path("/users/me")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`HeaderRegexp("X-A", ".*") && Path("/regexp")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.HandleWithPriority(`Path("/api") && !Header("X-Internal", "true")`, newStatusHandler(http.StatusOK), 1))
+	require.NoError(t, m.HandleWithPriority(`Path("/api") && Header("X-Internal", "true")`, newStatusHandler(http.StatusOK), 2))
+	assert.NoError(t, m.Verify())
+}
+
+func TestVerifyProblems(t *testing.T) {
+	m := NewMux()
+	// the Path route is tried first and serves the requests of the Method route
+	require.NoError(t, m.Handle(`Path("/a")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Method("GET") && Path("/a")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Path("/users/<id")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.Handle(`Host("<id>.example.com") && Path("/users/<id>")`, newStatusHandler(http.StatusOK)))
+	require.NoError(t, m.HandleWithPriority(`Path("/b") && Header("X-Version", "1")`, newStatusHandler(http.StatusOK), 5))
+	require.NoError(t, m.HandleWithPriority(`Path("/b") && Header("X-Version", "2")`, newStatusHandler(http.StatusOK), 5))
+	require.NoError(t, m.update(func(r *router) error {
+		return r.UpsertRoute(`Path("/ghost")`, newEntry(`Path("/ghost")`, newStatusHandler(http.StatusOK)))
+	}))
+
+	err := m.Verify()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+
+	var problems []string
+	for _, p := range verr.Problems {
+		problems = append(problems, p.Type.String()+" "+p.Expr)
+	}
+	assert.Equal(t, []string{
+		`placeholder Host("<id>.example.com") && Path("/users/<id>")`,
+		`unreachable Method("GET") && Path("/a")`,
+		`priority Path("/b") && Header("X-Version", "2")`,
+		`orphan route Path("/ghost")`,
+		`placeholder Path("/users/<id")`,
+	}, problems)
+	assert.Contains(t, err.Error(), `'Method("GET") && Path("/a")' is shadowed by 'Path("/a")': GET sample.invalid/a is routed to it`)
+}
@@ -0,0 +1,252 @@
+package route
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// versionSource is where the Version matchers read the version of the request
+type versionSource struct {
+	// header holds the version of the request, e.g. Accept-Version: 2.1
+	header string
+	// param is the media type parameter of the Accept header holding the version when the header is missing,
+	// e.g. Accept: application/vnd.example+json; version=2.1
+	param string
+}
+
+var defaultVersionSource = versionSource{header: "Accept-Version", param: "version"}
+
+// SetVersionSource sets where the Version matchers of the mux read the version of the request: from the header,
+// then from the media type parameter of the Accept header. By default the version is read from
+// the Accept-Version header, then from the version parameter, e.g. application/vnd.example+json; version=2.1.
+// An empty header or parameter is not read, both empty restore the default.
+func (m *Mux) SetVersionSource(header, param string) {
+	if header == "" && param == "" {
+		m.settings.version = nil
+		return
+	}
+	m.settings.version = &versionSource{header: header, param: param}
+}
+
+// requestVersion returns the version of the request, false if it has none or if it is malformed
+func requestVersion(req *http.Request, source versionSource) (semver, bool) {
+	header, param := source.header, source.param
+	if header != "" {
+		if value := strings.TrimSpace(req.Header.Get(header)); value != "" {
+			v, _, err := parseSemver(value)
+			return v, err == nil
+		}
+	}
+	if param == "" {
+		return semver{}, false
+	}
+	for _, value := range req.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(value, ",") {
+			_, params, err := mime.ParseMediaType(mediaType)
+			if err != nil || params[param] == "" {
+				continue
+			}
+			v, _, err := parseSemver(params[param])
+			return v, err == nil
+		}
+	}
+	return semver{}, false
+}
+
+// versionMatcher matches requests whose version is in any of the ranges. A range is a space-separated
+// list of comparisons all satisfied by the version, e.g. ">=2.1 <3", with the operators
+// =, >, >=, <, <=, ^ (same major version) and ~ (same minor version). A version without operator and
+// missing parts match any value of the missing parts, e.g. "2" matches 2.0.0 and 2.5.1.
+func versionMatcher(ranges ...string) (matcher, error) {
+	if len(ranges) == 0 {
+		return nil, errors.New("at least one version range is required")
+	}
+	parsed := make([]versionRange, len(ranges))
+	for i, r := range ranges {
+		vr, err := parseVersionRange(r)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = vr
+	}
+	return newSettingsMatcher(fmt.Sprintf("Version(%s)", strings.Join(ranges, ", ")), func(req *http.Request, s *settings) bool {
+		v, ok := requestVersion(req, s.getVersionSource())
+		if !ok {
+			return false
+		}
+		for _, vr := range parsed {
+			if vr.contains(v) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// semver is a semantic version, the build metadata is ignored
+type semver struct {
+	parts      [3]int
+	prerelease string
+}
+
+// parseSemver parses a version with up to three parts and an optional 'v' prefix, e.g. v2, 2.1 or 2.1.0-beta.1.
+// It returns the number of parts given, the missing parts are zero.
+func parseSemver(s string) (semver, int, error) {
+	var v semver
+	value := strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+	if i := strings.IndexByte(value, '+'); i >= 0 {
+		value = value[:i]
+	}
+	if i := strings.IndexByte(value, '-'); i >= 0 {
+		value, v.prerelease = value[:i], value[i+1:]
+		if v.prerelease == "" {
+			return semver{}, 0, fmt.Errorf("version %q has an empty pre-release", s)
+		}
+	}
+	parts := strings.Split(value, ".")
+	if len(parts) > 3 {
+		return semver{}, 0, fmt.Errorf("version %q has more than 3 parts", s)
+	}
+	if v.prerelease != "" && len(parts) != 3 {
+		return semver{}, 0, fmt.Errorf("version %q with a pre-release should have 3 parts", s)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part[0] == '+' {
+			return semver{}, 0, fmt.Errorf("version %q is not a semantic version", s)
+		}
+		v.parts[i] = n
+	}
+	return v, len(parts), nil
+}
+
+// compare returns -1, 0 or 1 if the version is lower, equal or greater than the other version,
+// a pre-release is lower than its release
+func (v semver) compare(other semver) int {
+	for i := range v.parts {
+		if v.parts[i] != other.parts[i] {
+			return cmp.Compare(v.parts[i], other.parts[i])
+		}
+	}
+	switch {
+	case v.prerelease == other.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case other.prerelease == "":
+		return -1
+	}
+	return comparePrerelease(v.prerelease, other.prerelease)
+}
+
+// comparePrerelease compares the dot-separated identifiers of the pre-releases,
+// numeric identifiers are compared numerically and are lower than the other identifiers
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return cmp.Compare(an, bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmp.Compare(len(as), len(bs))
+}
+
+// bump returns the lowest version above the versions sharing the first n+1 parts of the version
+func (v semver) bump(n int) semver {
+	out := semver{}
+	copy(out.parts[:n], v.parts[:n])
+	out.parts[n] = v.parts[n] + 1
+	return out
+}
+
+// versionComparison compares the versions to a bound with one of =, >, >=, < and <=
+type versionComparison struct {
+	op    string
+	bound semver
+}
+
+func (c versionComparison) satisfied(v semver) bool {
+	diff := v.compare(c.bound)
+	switch c.op {
+	case ">":
+		return diff > 0
+	case ">=":
+		return diff >= 0
+	case "<":
+		return diff < 0
+	case "<=":
+		return diff <= 0
+	}
+	return diff == 0
+}
+
+// versionRange is satisfied by the versions satisfying all its comparisons
+type versionRange []versionComparison
+
+func (r versionRange) contains(v semver) bool {
+	for _, c := range r {
+		if !c.satisfied(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseVersionRange parses the space-separated comparisons of the range into comparisons of full versions
+func parseVersionRange(s string) (versionRange, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, errors.New("version range cannot be empty")
+	}
+	var out versionRange
+	for _, field := range fields {
+		op := field[:len(field)-len(strings.TrimLeft(field, "<>=^~"))]
+		v, n, err := parseSemver(field[len(op):])
+		if err != nil {
+			return nil, fmt.Errorf("version range %q: %w", s, err)
+		}
+		full := n == len(v.parts)
+		switch {
+		case op == ">=" || op == "<" || (full && (op == ">" || op == "<=")):
+			out = append(out, versionComparison{op: op, bound: v})
+		case op == ">":
+			out = append(out, versionComparison{op: ">=", bound: v.bump(n - 1)})
+		case op == "<=":
+			out = append(out, versionComparison{op: "<", bound: v.bump(n - 1)})
+		case (op == "" || op == "=") && full:
+			out = append(out, versionComparison{op: "=", bound: v})
+		case op == "" || op == "=":
+			out = append(out, versionComparison{op: ">=", bound: v}, versionComparison{op: "<", bound: v.bump(n - 1)})
+		case op == "^":
+			// the first non-zero part given can't change, e.g. ^0.2 is below 0.3.0
+			i := 0
+			for i < n-1 && v.parts[i] == 0 {
+				i++
+			}
+			out = append(out, versionComparison{op: ">=", bound: v}, versionComparison{op: "<", bound: v.bump(i)})
+		case op == "~":
+			out = append(out, versionComparison{op: ">=", bound: v}, versionComparison{op: "<", bound: v.bump(min(n-1, 1))})
+		default:
+			return nil, fmt.Errorf("version range %q: unsupported operator %q", s, op)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,125 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionRange(t *testing.T) {
+	tcs := []struct {
+		r     string
+		in    []string
+		notIn []string
+	}{
+		{r: ">=2.1 <3", in: []string{"2.1", "2.1.0", "2.9.9", "v2.4"}, notIn: []string{"2.0.9", "3", "3.0.0", "1.5"}},
+		{r: "2", in: []string{"2", "2.0.0", "2.5.1"}, notIn: []string{"1.9.9", "3.0.0"}},
+		{r: "=2.1.0", in: []string{"2.1", "2.1.0"}, notIn: []string{"2.1.1", "2.1.0-beta"}},
+		{r: ">2.1", in: []string{"2.2.0", "3"}, notIn: []string{"2.1.9"}},
+		{r: "<=2.1", in: []string{"2.1.9", "1"}, notIn: []string{"2.2.0"}},
+		{r: ">2.1.0 <=2.2.0", in: []string{"2.1.1", "2.2.0"}, notIn: []string{"2.1.0", "2.2.1"}},
+		{r: "^1.2", in: []string{"1.2.0", "1.9.0"}, notIn: []string{"1.1.9", "2.0.0"}},
+		{r: "^0.2", in: []string{"0.2.5"}, notIn: []string{"0.3.0"}},
+		{r: "~1.2.3", in: []string{"1.2.3", "1.2.9"}, notIn: []string{"1.2.2", "1.3.0"}},
+		{r: "~1", in: []string{"1.9.0"}, notIn: []string{"2.0.0"}},
+		// pre-releases are lower than their release
+		{r: ">=2.0.0-beta.2 <2.0.0", in: []string{"2.0.0-beta.2", "2.0.0-beta.10", "2.0.0-rc.1"}, notIn: []string{"2.0.0-beta.1", "2.0.0-alpha", "2.0.0"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.r, func(t *testing.T) {
+			r, err := parseVersionRange(tc.r)
+			require.NoError(t, err)
+			for _, s := range tc.in {
+				v, _, err := parseSemver(s)
+				require.NoError(t, err)
+				assert.True(t, r.contains(v), s)
+			}
+			for _, s := range tc.notIn {
+				v, _, err := parseSemver(s)
+				require.NoError(t, err)
+				assert.False(t, r.contains(v), s)
+			}
+		})
+	}
+}
+
+func TestVersionRangeErrors(t *testing.T) {
+	for _, r := range []string{"", ">=", "2.x", "1.2.3.4", "2.1-beta", "!2", "=>2", "2.-1"} {
+		_, err := parseVersionRange(r)
+		assert.Error(t, err, r)
+	}
+	_, err := versionMatcher()
+	assert.Error(t, err)
+}
+
+func TestVersionMatcher(t *testing.T) {
+	m, err := versionMatcher(">=2.1 <3", "^1")
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name    string
+		headers http.Header
+		match   bool
+	}{
+		{name: "header", headers: http.Header{"Accept-Version": {"2.4"}}, match: true},
+		{name: "second range", headers: http.Header{"Accept-Version": {"1.0"}}, match: true},
+		{name: "out of range", headers: http.Header{"Accept-Version": {"2.0"}}},
+		{name: "malformed", headers: http.Header{"Accept-Version": {"latest"}}},
+		{name: "missing"},
+		{name: "media type", headers: http.Header{"Accept": {"text/html, application/vnd.example+json; version=2.1"}}, match: true},
+		// the header takes precedence over the media type
+		{name: "both", headers: http.Header{"Accept-Version": {"3"}, "Accept": {"application/json; version=2.1"}}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := makeReq(req{url: "/", headers: tc.headers})
+			assert.Equal(t, tc.match, m.match(r) != nil)
+		})
+	}
+
+	m.setSettings(&settings{version: &versionSource{header: "X-API-Version"}})
+	assert.NotNil(t, m.match(makeReq(req{url: "/", headers: http.Header{"X-Api-Version": {"2.2"}}})))
+	assert.Nil(t, m.match(makeReq(req{url: "/", headers: http.Header{"Accept": {"application/json; version=2.1"}}})))
+}
+
+func TestVersionRoutes(t *testing.T) {
+	r := New()
+	require.NoError(t, r.AddRoute(`Path("/users") && Version(">=2 <3")`, "v2"))
+	require.NoError(t, r.AddRoute(`Path("/users") && Version("1")`, "v1"))
+
+	val, err := r.Route(makeReq(req{url: "/users", headers: http.Header{"Accept-Version": {"2.3"}}}))
+	require.NoError(t, err)
+	assert.Equal(t, "v2", val)
+
+	val, err = r.Route(makeReq(req{url: "/users", headers: http.Header{"Accept-Version": {"v1.4"}}}))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", val)
+
+	val, err = r.Route(makeReq(req{url: "/users"}))
+	require.NoError(t, err)
+	assert.Nil(t, val)
+
+	assert.Error(t, r.AddRoute(`Path("/users") && Version("2.x")`, "broken"))
+}
+
+func TestMuxVersionSource(t *testing.T) {
+	m := NewMux()
+	require.NoError(t, m.Handle(`Path("/users") && Version("2")`, newStatusHandler(http.StatusOK)))
+
+	serve := func(headers http.Header) int {
+		w := newWriter()
+		m.ServeHTTP(w, makeReq(req{url: "/users", headers: headers}))
+		return w.header
+	}
+	assert.Equal(t, http.StatusOK, serve(http.Header{"Accept-Version": {"2.1"}}))
+	assert.Equal(t, http.StatusNotFound, serve(http.Header{"X-Api-Version": {"2.1"}}))
+
+	m.SetVersionSource("X-API-Version", "")
+	assert.Equal(t, http.StatusOK, serve(http.Header{"X-Api-Version": {"2.1"}}))
+	assert.Equal(t, http.StatusNotFound, serve(http.Header{"Accept-Version": {"2.1"}}))
+
+	m.SetVersionSource("", "")
+	assert.Equal(t, http.StatusOK, serve(http.Header{"Accept-Version": {"2.1"}}))
+}
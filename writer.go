@@ -0,0 +1,87 @@
+package route
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// statusWriter records the status code and the size of the response written by a handler
+type statusWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func newStatusWriter(w http.ResponseWriter) *statusWriter {
+	return &statusWriter{ResponseWriter: w}
+}
+
+// WriteHeader records the first final status, informational statuses like 103 Early Hints
+// are sent before the final one so they are not recorded, as in net/http
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 && !informational(status) {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func informational(status int) bool {
+	return status >= 100 && status <= 199 && status != http.StatusSwitchingProtocols
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher when the wrapped writer supports it
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker when the wrapped writer supports it,
+// a hijacked connection is recorded as switching protocols
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap allows http.ResponseController to reach the wrapped writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns the status code of the response, http.StatusOK if the handler did not write anything
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// headWriter discards the response body, it is used to answer HEAD requests with GET handlers
+type headWriter struct {
+	http.ResponseWriter
+}
+
+func (w headWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// Unwrap allows http.ResponseController to reach the wrapped writer
+func (w headWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,61 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := newStatusWriter(rec)
+	assert.Equal(t, http.StatusOK, w.Status())
+
+	w.WriteHeader(http.StatusTeapot)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("hello"))
+	w.Flush()
+
+	assert.Equal(t, http.StatusTeapot, w.Status())
+	assert.Equal(t, int64(5), w.written)
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, rec, w.Unwrap())
+}
+
+func TestStatusWriterInformational(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := newStatusWriter(rec)
+
+	w.WriteHeader(http.StatusEarlyHints)
+	assert.Equal(t, http.StatusOK, w.Status())
+	w.WriteHeader(http.StatusInternalServerError)
+	assert.Equal(t, http.StatusInternalServerError, w.Status())
+}
+
+func TestStatusWriterHijack(t *testing.T) {
+	var hijacked bool
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		w := newStatusWriter(rw)
+		conn, _, err := w.Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		hijacked = true
+		assert.Equal(t, http.StatusSwitchingProtocols, w.Status())
+		_, _ = conn.Write([]byte("HTTP/1.1 204 No Content\r\n\r\n"))
+		_ = conn.Close()
+	}))
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.True(t, hijacked)
+
+	// the recorder can't be hijacked
+	_, _, err = newStatusWriter(httptest.NewRecorder()).Hijack()
+	assert.Error(t, err)
+}